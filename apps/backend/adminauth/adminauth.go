@@ -0,0 +1,128 @@
+// Package adminauth restricts the admin routes of the custom plugins to the configured admins.
+package adminauth
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Authula/authula/models"
+	coreservices "github.com/Authula/authula/services"
+)
+
+// Config lists who is an admin, roles are only read from the session values set by hooks of the app
+type Config struct {
+	UserIDs []string
+	Emails  []string
+	// RoleClaim is the session value holding the roles of the user
+	RoleClaim string
+	Roles     []string
+}
+
+// IsEmpty reports whether nobody can be an admin
+func (c Config) IsEmpty() bool {
+	return len(c.UserIDs) == 0 && len(c.Emails) == 0 && len(c.Roles) == 0
+}
+
+// Checker is the handler of the admin hook of a plugin
+type Checker struct {
+	config   Config
+	registry models.ServiceRegistry
+	logger   models.Logger
+}
+
+func NewChecker(config Config, registry models.ServiceRegistry, logger models.Logger) *Checker {
+	return &Checker{config: config, registry: registry, logger: logger}
+}
+
+// RouteMetadata makes the admin hook run on a route even when it is missing from the route mappings
+func RouteMetadata(hookID string) map[string]any {
+	return map[string]any{
+		"plugins": []string{hookID},
+	}
+}
+
+// RequireAdmin rejects the request unless the signed in user is an admin
+func (c *Checker) RequireAdmin(reqCtx *models.RequestContext) error {
+	if reqCtx.UserID == nil || *reqCtx.UserID == "" {
+		reqCtx.SetJSONResponse(http.StatusUnauthorized, map[string]any{"message": "Unauthorized"})
+		reqCtx.Handled = true
+		return nil
+	}
+
+	isAdmin, err := c.IsAdmin(reqCtx, *reqCtx.UserID)
+	if err != nil {
+		c.logger.Error("failed to authorize admin", "user_id", *reqCtx.UserID, "error", err)
+		reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{"message": "failed to authorize request"})
+		reqCtx.Handled = true
+		return nil
+	}
+
+	if !isAdmin {
+		reqCtx.SetJSONResponse(http.StatusForbidden, map[string]any{"message": "Forbidden"})
+		reqCtx.Handled = true
+		return nil
+	}
+
+	return nil
+}
+
+// IsAdmin checks the user IDs and session roles first and only loads the user for the emails
+func (c *Checker) IsAdmin(reqCtx *models.RequestContext, userID string) (bool, error) {
+	if slices.Contains(c.config.UserIDs, userID) {
+		return true, nil
+	}
+	if c.hasAdminRole(reqCtx.Values[c.config.RoleClaim]) {
+		return true, nil
+	}
+	if len(c.config.Emails) == 0 {
+		return false, nil
+	}
+
+	userService, ok := c.registry.Get(models.ServiceUser.String()).(coreservices.UserService)
+	if !ok {
+		return false, nil
+	}
+	user, err := userService.GetByID(reqCtx.Request.Context(), userID)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, nil
+	}
+
+	for _, email := range c.config.Emails {
+		if strings.EqualFold(strings.TrimSpace(email), user.Email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasAdminRole reports whether a role claim, a single role or a list of roles, grants access
+func (c *Checker) hasAdminRole(claim any) bool {
+	if len(c.config.Roles) == 0 {
+		return false
+	}
+
+	var roles []string
+	switch claim := claim.(type) {
+	case string:
+		roles = []string{claim}
+	case []string:
+		roles = claim
+	case []any:
+		for _, role := range claim {
+			if role, ok := role.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	for _, role := range roles {
+		if slices.Contains(c.config.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package adminauth
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Authula/authula/models"
+	coreservices "github.com/Authula/authula/services"
+)
+
+// testUserService only implements the lookup the checker needs
+type testUserService struct {
+	coreservices.UserService
+	users map[string]*models.User
+}
+
+func (s *testUserService) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return s.users[id], nil
+}
+
+// testServiceRegistry only holds the user service
+type testServiceRegistry struct {
+	models.ServiceRegistry
+	userService coreservices.UserService
+}
+
+func (r *testServiceRegistry) Get(name string) any {
+	if name == models.ServiceUser.String() {
+		return r.userService
+	}
+	return nil
+}
+
+func TestChecker_RequireAdmin(t *testing.T) {
+	registry := &testServiceRegistry{userService: &testUserService{users: map[string]*models.User{
+		"owner": {ID: "owner", Email: "Owner@Example.com"},
+		// The metadata is sent by the user at sign-up
+		"mallory": {ID: "mallory", Email: "mallory@example.com", Metadata: json.RawMessage(`{"role":"admin"}`)},
+	}}}
+	checker := NewChecker(Config{
+		UserIDs:   []string{"admin"},
+		Emails:    []string{"owner@example.com"},
+		RoleClaim: "role",
+		Roles:     []string{"admin"},
+	}, registry, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name       string
+		userID     string
+		role       any
+		wantStatus int
+	}{
+		{name: "signed out", wantStatus: http.StatusUnauthorized},
+		{name: "admin user id", userID: "admin"},
+		{name: "admin email", userID: "owner"},
+		{name: "admin role of the session", userID: "u1", role: "admin"},
+		{name: "admin role in a list of the session", userID: "u1", role: []any{"member", "admin"}},
+		{name: "other role of the session", userID: "u1", role: "member", wantStatus: http.StatusForbidden},
+		{name: "admin role in the user metadata", userID: "mallory", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqCtx := &models.RequestContext{
+				Request: httptest.NewRequest(http.MethodGet, "/", nil),
+				Values:  make(map[string]any),
+			}
+			if tt.userID != "" {
+				reqCtx.UserID = &tt.userID
+			}
+			if tt.role != nil {
+				reqCtx.Values["role"] = tt.role
+			}
+
+			assert.NoError(t, checker.RequireAdmin(reqCtx))
+			assert.Equal(t, tt.wantStatus != 0, reqCtx.Handled)
+			assert.Equal(t, tt.wantStatus, reqCtx.ResponseStatus)
+		})
+	}
+}
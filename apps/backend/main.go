@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -34,6 +36,9 @@ import (
 
 	loggerplugin "github.com/Authula/authula-playground/plugins/logger"
 	loggerplugintypes "github.com/Authula/authula-playground/plugins/logger/types"
+	webhooksplugin "github.com/Authula/authula-playground/plugins/webhooks"
+	webhooksplugintypes "github.com/Authula/authula-playground/plugins/webhooks/types"
+	"github.com/Authula/authula-playground/requestid"
 )
 
 func main() {
@@ -42,9 +47,9 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
-	}))
+	})))
 	slog.SetDefault(logger)
 
 	// -------------------------------------
@@ -83,8 +88,8 @@ func main() {
 				AllowCredentials: true,
 				AllowedOrigins:   []string{"http://localhost:3000"},
 				AllowedMethods:   []string{"OPTIONS", "GET", "POST", "PATCH", "PUT", "DELETE"},
-				AllowedHeaders:   []string{"Authorization", "Content-Type", "Set-Cookie", "Cookie", "X-AUTHULA-CSRF-TOKEN"},
-				ExposedHeaders:   []string{"X-AUTHULA-CSRF-TOKEN"},
+				AllowedHeaders:   []string{"Authorization", "Content-Type", "Set-Cookie", "Cookie", "X-AUTHULA-CSRF-TOKEN", requestid.HeaderName},
+				ExposedHeaders:   []string{"X-AUTHULA-CSRF-TOKEN", requestid.HeaderName},
 				MaxAge:           24 * time.Hour,
 			},
 		}),
@@ -135,6 +140,69 @@ func main() {
 					csrfplugin.HookIDCSRFProtect.String(),
 				},
 			},
+			// Logger Routes
+			{
+				Paths:   []string{"GET:/logger/me"},
+				Plugins: []string{sessionplugin.HookIDSessionAuth.String()},
+			},
+			{
+				Paths: []string{
+					"GET:/logger/count",
+					"GET:/logger/entries",
+					"GET:/logger/entries/{id}",
+					"GET:/logger/export",
+					"GET:/logger/stats",
+					"GET:/logger/verify",
+					"GET:/logger/config",
+					"GET:/logger/stream",
+					"GET:/logger/alerts",
+					"GET:/logger/dead-letters",
+				},
+				Plugins: []string{
+					sessionplugin.HookIDSessionAuth.String(),
+					loggerplugin.HookIDLoggerAdmin.String(),
+				},
+			},
+			{
+				Paths: []string{
+					"POST:/logger/alerts/{id}/acknowledge",
+					"POST:/logger/dead-letters/{id}/replay",
+					"POST:/logger/users/{user_id}/erase",
+					"DELETE:/logger/entries",
+					"DELETE:/logger/entries/{id}",
+				},
+				Plugins: []string{
+					sessionplugin.HookIDSessionAuth.String(),
+					csrfplugin.HookIDCSRFProtect.String(),
+					loggerplugin.HookIDLoggerAdmin.String(),
+				},
+			},
+			// Webhooks Routes
+			{
+				Paths: []string{
+					"GET:/webhooks/endpoints",
+					"GET:/webhooks/endpoints/{id}",
+					"GET:/webhooks/endpoints/{id}/deliveries",
+					"GET:/webhooks/deliveries/{id}",
+				},
+				Plugins: []string{
+					sessionplugin.HookIDSessionAuth.String(),
+					webhooksplugin.HookIDWebhooksAdmin.String(),
+				},
+			},
+			{
+				Paths: []string{
+					"POST:/webhooks/endpoints",
+					"PATCH:/webhooks/endpoints/{id}",
+					"DELETE:/webhooks/endpoints/{id}",
+					"POST:/webhooks/deliveries/{id}/redeliver",
+				},
+				Plugins: []string{
+					sessionplugin.HookIDSessionAuth.String(),
+					csrfplugin.HookIDCSRFProtect.String(),
+					webhooksplugin.HookIDWebhooksAdmin.String(),
+				},
+			},
 			// Custom Routes
 			{
 				Paths:   []string{"GET:/api/v1/health"},
@@ -143,6 +211,16 @@ func main() {
 		}),
 	)
 
+	// -------------------------------------
+	// Restore an archived log partition instead of running the server:
+	// go run main.go restore-log-archive <path/to/log_entries_pYYYYMM.ndjson.gz>
+	// -------------------------------------
+
+	if len(os.Args) == 3 && os.Args[1] == "restore-log-archive" {
+		restoreLogArchive(config, os.Args[2])
+		return
+	}
+
 	// -------------------------------------
 	// Init Authula instance
 	// -------------------------------------
@@ -222,8 +300,27 @@ func main() {
 
 			// Custom plugins
 			loggerplugin.New(loggerplugintypes.LoggerPluginConfig{
+				Enabled:       true,
+				MaxLogCount:   10,
+				RetentionMode: loggerplugintypes.RetentionModePrune,
+				AdminEmails:   adminEmails(),
+				// Only the newest logs are kept, so the charts count from the rollup
+				StatsFromRollup: true,
+				Detection: loggerplugintypes.DetectionConfig{
+					Enabled: true,
+				},
+				// Security alerts also show up in the application logs
+				Sinks: []loggerplugintypes.SinkConfig{
+					{
+						Type:              loggerplugintypes.SinkTypeStdout,
+						IncludeEventTypes: []string{"security.alert.*"},
+					},
+				},
+			}),
+			// After the logger, the webhooks redact their payloads with its rules and are erased with its users
+			webhooksplugin.New(webhooksplugintypes.WebhooksPluginConfig{
 				Enabled:     true,
-				MaxLogCount: 10,
+				AdminEmails: adminEmails(),
 			}),
 		},
 	})
@@ -259,6 +356,10 @@ func main() {
 		}),
 	})
 
+	// Correlate every request with the events it publishes, the log entries they produce and the
+	// slog records written while it is handled
+	authula.RegisterHook(requestid.Hook())
+
 	// authula.RegisterHook(authulamodels.Hook{
 	// 	Stage: authulamodels.HookBefore,
 	// 	Matcher: func(ctx *authulamodels.RequestContext) bool {
@@ -283,7 +384,32 @@ func main() {
 
 	port := os.Getenv(authulaenv.EnvPort)
 	slog.Debug(fmt.Sprintf("Server running on http://localhost:%s", port))
-	if err := http.ListenAndServe(fmt.Sprintf(":%s", port), authula.Handler()); err != nil {
+	// The logger plugin streams its exports and its live tail through the wrapper
+	if err := http.ListenAndServe(fmt.Sprintf(":%s", port), loggerplugin.StreamingHandler(authula.Handler())); err != nil {
 		slog.Error("Server error", "err", err)
 	}
 }
+
+// adminEmails returns the comma separated email addresses of the ADMIN_EMAILS variable
+func adminEmails() []string {
+	var emails []string
+	for email := range strings.SplitSeq(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// restoreLogArchive attaches a log partition archived by the logger plugin to the database again
+func restoreLogArchive(config *authulamodels.Config, path string) {
+	db, err := authula.InitDatabase(config, authula.InitLogger(config), config.Logger.Level)
+	if err != nil {
+		log.Fatalf("failed to connect to the database: %v", err)
+	}
+	result, err := loggerplugin.RestoreLogArchive(context.Background(), db, path)
+	if err != nil {
+		log.Fatalf("failed to restore log archive: %v", err)
+	}
+	slog.Info("restored log archive", "partition", result.Partition.Name, "entries", result.Entries)
+}
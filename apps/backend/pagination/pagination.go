@@ -0,0 +1,50 @@
+// Package pagination holds the cursor and limit handling shared by the paginated routes of the plugins
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ParseQuery reads the cursor and limit query parameters, falling back to defaultLimit
+func ParseQuery(values url.Values, defaultLimit int) (*string, int, error) {
+	var cursor *string
+	if raw := strings.TrimSpace(values.Get("cursor")); raw != "" {
+		cursor = &raw
+	}
+
+	limit := defaultLimit
+	if raw := strings.TrimSpace(values.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return nil, 0, fmt.Errorf("invalid limit")
+		}
+		limit = parsed
+	}
+
+	return cursor, limit, nil
+}
+
+// EncodeIDCursor builds the opaque cursor of the page after the row with the ID
+func EncodeIDCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// DecodeIDCursor parses a cursor produced by EncodeIDCursor
+func DecodeIDCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
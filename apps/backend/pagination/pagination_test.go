@@ -0,0 +1,51 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantCursor *string
+		wantLimit  int
+		wantErr    bool
+	}{
+		{name: "defaults", wantLimit: 50},
+		{name: "cursor and limit", query: "cursor=+abc+&limit=10", wantCursor: new("abc"), wantLimit: 10},
+		{name: "invalid limit", query: "limit=ten", wantErr: true},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			cursor, limit, err := ParseQuery(values, 50)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCursor, cursor)
+			assert.Equal(t, tt.wantLimit, limit)
+		})
+	}
+}
+
+func TestDecodeIDCursor(t *testing.T) {
+	id, err := DecodeIDCursor(EncodeIDCursor(42))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	for _, cursor := range []string{"not base64!", EncodeIDCursor(42) + "=", "bm90IGFuIGlk"} {
+		_, err := DecodeIDCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+	}
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// MyActivityHandler lists the account activity of the user signed in through the session auth hook
+type MyActivityHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *MyActivityHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		userID, ok := models.GetUserIDFromContext(ctx)
+		if !ok || userID == "" {
+			reqCtx.SetJSONResponse(http.StatusUnauthorized, map[string]any{
+				"message": "Unauthorized",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		cursor, limit, err := pagination.ParseQuery(r.URL.Query(), types.DefaultActivityLimit)
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		page, err := h.service.ListUserActivity(ctx, userID, cursor, limit)
+		if err != nil {
+			if errors.Is(err, constants.ErrInvalidCursor) {
+				reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+					"message": "invalid cursor",
+				})
+				reqCtx.Handled = true
+				return
+			}
+
+			h.logger.Error("failed to list user activity", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to list activity",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, page)
+	}
+}
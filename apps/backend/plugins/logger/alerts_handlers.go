@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+type ListSecurityAlertsHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *ListSecurityAlertsHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		values := r.URL.Query()
+		cursor, limit, err := pagination.ParseQuery(values, types.DefaultSecurityAlertsLimit)
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		status := types.SecurityAlertStatus(strings.ToLower(strings.TrimSpace(values.Get("status"))))
+		switch status {
+		case "", types.SecurityAlertStatusOpen, types.SecurityAlertStatusAcknowledged:
+		default:
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": "invalid status, expected \"open\" or \"acknowledged\"",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		page, err := h.service.ListSecurityAlerts(ctx, types.SecurityAlertQuery{
+			Status: status,
+			Cursor: cursor,
+			Limit:  limit,
+		})
+		if err != nil {
+			if errors.Is(err, constants.ErrInvalidCursor) {
+				reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+					"message": err.Error(),
+				})
+				reqCtx.Handled = true
+				return
+			}
+			h.logger.Error("failed to list security alerts", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to list security alerts",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, page)
+	}
+}
+
+type AcknowledgeSecurityAlertHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *AcknowledgeSecurityAlertHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": "invalid alert id",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		// The admin hook has already rejected requests without a user
+		var userID string
+		if reqCtx.UserID != nil {
+			userID = *reqCtx.UserID
+		}
+
+		alert, err := h.service.AcknowledgeSecurityAlert(ctx, id, userID)
+		if err != nil {
+			if errors.Is(err, constants.ErrSecurityAlertNotFound) {
+				reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+					"message": err.Error(),
+				})
+				reqCtx.Handled = true
+				return
+			}
+			h.logger.Error("failed to acknowledge security alert", "alert_id", id, "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to acknowledge security alert",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, alert)
+	}
+}
@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// RestoreLogArchive attaches a partition archive to log_entries again for the restore-log-archive command
+func RestoreLogArchive(ctx context.Context, db bun.IDB, path string) (*types.PartitionRestore, error) {
+	if db.Dialect().Name() != dialect.PG {
+		return nil, constants.ErrPartitionsUnsupported
+	}
+	service := services.NewPartitionService(repositories.NewBunPartitionRepository(db), types.PartitionConfig{})
+	return service.RestoreArchive(ctx, path)
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+)
+
+// ChainCheckpointer periodically signs the head of the hash chain
+type ChainCheckpointer struct {
+	logger   models.Logger
+	service  services.LoggerService
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewChainCheckpointer(logger models.Logger, service services.LoggerService, interval time.Duration) *ChainCheckpointer {
+	return &ChainCheckpointer{
+		logger:   logger,
+		service:  service,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start makes a checkpoint every interval until Close is called
+func (c *ChainCheckpointer) Start() {
+	go c.runCheckpointLoop()
+}
+
+// Close stops the checkpoint loop and waits for a running checkpoint to finish
+func (c *ChainCheckpointer) Close() {
+	close(c.stop)
+	<-c.done
+}
+
+func (c *ChainCheckpointer) runCheckpointLoop() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	defer close(c.done)
+
+	for {
+		select {
+		case <-c.stop:
+			c.logger.Debug("log chain checkpointer stopped")
+			return
+		case <-ticker.C:
+			c.checkpoint()
+		}
+	}
+}
+
+func (c *ChainCheckpointer) checkpoint() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.interval)
+	defer cancel()
+
+	checkpoint, err := c.service.CreateChainCheckpoint(ctx)
+	if err != nil {
+		c.logger.Error("failed to create log chain checkpoint", "error", err)
+		return
+	}
+	if checkpoint != nil {
+		c.logger.Info("created log chain checkpoint", "entry_id", checkpoint.EntryID, "hash", checkpoint.Hash)
+	}
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"net/http"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+)
+
+type VerifyChainHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *VerifyChainHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		result, err := h.service.VerifyChain(ctx)
+		if err != nil {
+			h.logger.Error("failed to verify log chain", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to verify log chain",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		if !result.Valid {
+			h.logger.Warn("log chain verification failed", "entry_id", result.FirstBrokenLink.EntryID, "reason", result.FirstBrokenLink.Reason)
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, result)
+	}
+}
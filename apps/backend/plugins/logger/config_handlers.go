@@ -0,0 +1,19 @@
+package logger
+
+import (
+	"net/http"
+
+	"github.com/Authula/authula/models"
+)
+
+type EventFilterConfigHandler struct {
+	eventFilter *EventFilter
+}
+
+func (h *EventFilterConfigHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reqCtx, _ := models.GetRequestContext(r.Context())
+
+		reqCtx.SetJSONResponse(http.StatusOK, h.eventFilter.Config())
+	}
+}
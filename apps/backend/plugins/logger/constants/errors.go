@@ -0,0 +1,23 @@
+package constants
+
+import (
+	"errors"
+
+	"github.com/Authula/authula-playground/pagination"
+)
+
+var (
+	ErrInvalidCursor            = pagination.ErrInvalidCursor
+	ErrMaxLogCountReached       = errors.New("max log count reached")
+	ErrLogWriterClosed          = errors.New("log writer is closed")
+	ErrChainCheckpointsDisabled = errors.New("chain checkpoints are disabled")
+	ErrSecurityAlertNotFound    = errors.New("security alert not found")
+	ErrDuplicateLogEntry        = errors.New("a log entry already exists for the event")
+	ErrDeadLetterNotFound       = errors.New("dead letter not found")
+	ErrPartitionsUnsupported    = errors.New("log partitions are only supported on postgres")
+	ErrPartitionExists          = errors.New("a log partition already exists for the month")
+	ErrPartitionChanged         = errors.New("log partition changed while it was archived")
+	ErrInvalidArchive           = errors.New("invalid log archive")
+	ErrLogEntryNotFound         = errors.New("log entry not found")
+	ErrDeletionFilterRequired   = errors.New("at least one filter is required to delete log entries")
+)
@@ -0,0 +1,18 @@
+package constants
+
+const (
+	// EventLoggerMaxLogCountReached is published once when the stop retention mode starts dropping logs
+	EventLoggerMaxLogCountReached = "logger.max_log_count_reached"
+	// EventUserSignInFailed is published when an email-password sign-in is rejected, Authula does
+	// not publish failed sign-ins itself
+	EventUserSignInFailed = "user.sign_in_failed"
+	// EventUserDeleted is published when a user is deleted through DELETE /admin/users/{user_id} of
+	// the admin plugin, Authula does not publish deleted users itself and no other deletion publishes it
+	EventUserDeleted = "user.deleted"
+	// EventLoggerUserErased is the type of the receipt entry written when the entries of a user are erased
+	EventLoggerUserErased = "logger.user_erased"
+	// EventLoggerEntriesDeleted is the type of the audit entry written when an admin deletes entries
+	EventLoggerEntriesDeleted = "logger.entries_deleted"
+	// EventSecurityAlertPrefix prefixes the events published when a detection threshold is crossed
+	EventSecurityAlertPrefix = "security.alert."
+)
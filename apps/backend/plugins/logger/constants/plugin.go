@@ -0,0 +1,4 @@
+package constants
+
+// PluginID is the ID of the logger plugin, the plugins that use its services depend on it
+const PluginID = "logger"
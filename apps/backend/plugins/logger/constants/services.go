@@ -0,0 +1,10 @@
+package constants
+
+const (
+	// ServiceRedactor is the name of the *services.Redactor in the service registry, other plugins
+	// apply the redaction rules of the logger with it
+	ServiceRedactor = "logger_redactor_service"
+	// ServiceUserDataErasers is the name of the *services.UserDataErasers in the service registry,
+	// other plugins register the erasure of the user data they keep with it
+	ServiceUserDataErasers = "logger_user_data_erasers_service"
+)
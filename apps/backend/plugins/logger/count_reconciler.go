@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+)
+
+// LogCountReconciler periodically recounts the rows of log_entries into the shared log count
+type LogCountReconciler struct {
+	logger   models.Logger
+	service  services.LoggerService
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewLogCountReconciler(logger models.Logger, service services.LoggerService, interval time.Duration) *LogCountReconciler {
+	return &LogCountReconciler{
+		logger:   logger,
+		service:  service,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start reconciles the count every interval until Close is called
+func (r *LogCountReconciler) Start() {
+	go r.runReconcileLoop()
+}
+
+// Close stops the reconcile loop and waits for a running reconciliation to finish
+func (r *LogCountReconciler) Close() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *LogCountReconciler) runReconcileLoop() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	defer close(r.done)
+
+	for {
+		select {
+		case <-r.stop:
+			r.logger.Debug("log count reconciler stopped")
+			return
+		case <-ticker.C:
+			r.reconcile()
+		}
+	}
+}
+
+func (r *LogCountReconciler) reconcile() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+
+	count, err := r.service.SyncLogCount(ctx)
+	if err != nil {
+		r.logger.Error("failed to reconcile log count", "error", err)
+		return
+	}
+	r.logger.Debug("reconciled log count", "count", count)
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+type ListDeadLettersHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *ListDeadLettersHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		cursor, limit, err := pagination.ParseQuery(r.URL.Query(), types.DefaultDeadLettersLimit)
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		page, err := h.service.ListDeadLetters(ctx, types.DeadLetterQuery{
+			Cursor: cursor,
+			Limit:  limit,
+		})
+		if err != nil {
+			if errors.Is(err, constants.ErrInvalidCursor) {
+				reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+					"message": err.Error(),
+				})
+				reqCtx.Handled = true
+				return
+			}
+			h.logger.Error("failed to list dead letters", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to list dead letters",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, page)
+	}
+}
+
+type ReplayDeadLetterHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *ReplayDeadLetterHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": "invalid dead letter id",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		replay, err := h.service.ReplayDeadLetter(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrDeadLetterNotFound):
+				reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+					"message": err.Error(),
+				})
+			case errors.Is(err, constants.ErrMaxLogCountReached):
+				reqCtx.SetJSONResponse(http.StatusConflict, map[string]any{
+					"message": err.Error(),
+				})
+			default:
+				h.logger.Error("failed to replay dead letter", "dead_letter_id", id, "error", err)
+				reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+					"message": "failed to replay dead letter",
+				})
+			}
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, replay)
+	}
+}
@@ -0,0 +1,333 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+type ListLogEntriesHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *ListLogEntriesHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		query, err := parseLogEntryQuery(r.URL.Query())
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		terms, err := parseSearchParam(r.URL.Query())
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		var page *types.LogEntriesPage
+		if terms != nil {
+			page, err = h.service.SearchLogEntries(ctx, terms, query)
+		} else {
+			page, err = h.service.ListLogEntries(ctx, query)
+		}
+		if err != nil {
+			if errors.Is(err, constants.ErrInvalidCursor) {
+				reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+					"message": "invalid cursor",
+				})
+				reqCtx.Handled = true
+				return
+			}
+
+			h.logger.Error("failed to list log entries", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to list log entries",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, page)
+	}
+}
+
+type GetLogEntryHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *GetLogEntryHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": "invalid log entry id",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		entry, err := h.service.GetLogEntry(ctx, id)
+		if err != nil {
+			if errors.Is(err, constants.ErrLogEntryNotFound) {
+				reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+					"message": err.Error(),
+				})
+				reqCtx.Handled = true
+				return
+			}
+			h.logger.Error("failed to get log entry", "entry_id", id, "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to get log entry",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, entry)
+	}
+}
+
+type DeleteLogEntryHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *DeleteLogEntryHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": "invalid log entry id",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		result, err := h.service.DeleteLogEntry(ctx, id, adminRequestedBy(reqCtx))
+		if err != nil {
+			if errors.Is(err, constants.ErrLogEntryNotFound) {
+				reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+					"message": err.Error(),
+				})
+				reqCtx.Handled = true
+				return
+			}
+			h.logger.Error("failed to delete log entry", "entry_id", id, "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to delete log entry",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, result)
+	}
+}
+
+type DeleteLogEntriesHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+// Handler deletes the entries matching a non-empty filter, dry_run=true only counts them
+func (h *DeleteLogEntriesHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		values := r.URL.Query()
+		filter, err := parseLogEntryFilter(values)
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		dryRun := false
+		if raw := strings.TrimSpace(values.Get("dry_run")); raw != "" {
+			dryRun, err = strconv.ParseBool(raw)
+			if err != nil {
+				reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+					"message": "invalid dry_run",
+				})
+				reqCtx.Handled = true
+				return
+			}
+		}
+
+		result, err := h.service.DeleteLogEntries(ctx, types.DeletionRequest{
+			Filter:      filter,
+			RequestedBy: adminRequestedBy(reqCtx),
+			DryRun:      dryRun,
+		})
+		if err != nil {
+			if errors.Is(err, constants.ErrDeletionFilterRequired) {
+				reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+					"message": err.Error(),
+				})
+				reqCtx.Handled = true
+				return
+			}
+			h.logger.Error("failed to delete log entries", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to delete log entries",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, result)
+	}
+}
+
+// adminRequestedBy identifies the admin of a request in audit entries
+func adminRequestedBy(reqCtx *models.RequestContext) string {
+	if reqCtx.UserID == nil {
+		return "admin"
+	}
+	return "admin:" + *reqCtx.UserID
+}
+
+// parseLogEntryQuery reads the pagination, sorting and filter query parameters
+func parseLogEntryQuery(values url.Values) (types.LogEntryQuery, error) {
+	filter, err := parseLogEntryFilter(values)
+	if err != nil {
+		return types.LogEntryQuery{}, err
+	}
+
+	cursor, limit, err := pagination.ParseQuery(values, types.DefaultLogEntriesLimit)
+	if err != nil {
+		return types.LogEntryQuery{}, err
+	}
+
+	query := types.LogEntryQuery{
+		Filter: filter,
+		Cursor: cursor,
+		Limit:  limit,
+		Order:  types.SortOrderDesc,
+	}
+
+	order, err := parseSortOrder(values, types.SortOrderDesc)
+	if err != nil {
+		return types.LogEntryQuery{}, err
+	}
+	query.Order = order
+
+	return query, nil
+}
+
+// parseSearchParam reads the q query parameter into its search terms, nil when there is no search
+func parseSearchParam(values url.Values) ([]string, error) {
+	search := parseStringParam(values, "q")
+	if search == nil {
+		return nil, nil
+	}
+	return types.ParseSearchTerms(*search)
+}
+
+// parseSortOrder reads the order query parameter, falling back to fallback when it is missing
+func parseSortOrder(values url.Values, fallback types.SortOrder) (types.SortOrder, error) {
+	switch order := types.SortOrder(strings.ToLower(strings.TrimSpace(values.Get("order")))); order {
+	case "":
+		return fallback, nil
+	case types.SortOrderAsc, types.SortOrderDesc:
+		return order, nil
+	default:
+		return "", fmt.Errorf("invalid order, expected %q or %q", types.SortOrderAsc, types.SortOrderDesc)
+	}
+}
+
+// parseLogEntryFilter reads the filter query parameters, event_type accepts glob patterns such as "user.*"
+func parseLogEntryFilter(values url.Values) (types.LogEntryFilter, error) {
+	var filter types.LogEntryFilter
+
+	filter.EventTypes = parseEventTypesParam(values)
+	filter.UserID = parseStringParam(values, "user_id")
+	filter.SessionID = parseStringParam(values, "session_id")
+	filter.IPAddress = parseStringParam(values, "ip_address")
+	filter.RequestID = parseStringParam(values, "request_id")
+
+	from, err := parseTimeParam(values, "from")
+	if err != nil {
+		return types.LogEntryFilter{}, err
+	}
+	filter.From = from
+
+	to, err := parseTimeParam(values, "to")
+	if err != nil {
+		return types.LogEntryFilter{}, err
+	}
+	filter.To = to
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return types.LogEntryFilter{}, fmt.Errorf("from must be before to")
+	}
+
+	return filter, nil
+}
+
+// parseEventTypesParam reads the event_type query parameter, which may be repeated or comma separated
+func parseEventTypesParam(values url.Values) []string {
+	var eventTypes []string
+	for _, value := range values["event_type"] {
+		for eventType := range strings.SplitSeq(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				eventTypes = append(eventTypes, eventType)
+			}
+		}
+	}
+	return eventTypes
+}
+
+func parseStringParam(values url.Values, key string) *string {
+	value := strings.TrimSpace(values.Get(key))
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func parseTimeParam(values url.Values, key string) (*time.Time, error) {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, expected an RFC 3339 timestamp", key)
+	}
+
+	value = value.UTC()
+	return &value, nil
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+type EraseUserLogsHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *EraseUserLogsHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		userID := strings.TrimSpace(r.PathValue("user_id"))
+		if userID == "" {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": "invalid user id",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		result, err := h.service.EraseUserLogs(ctx, types.ErasureRequest{
+			UserID:      userID,
+			RequestedBy: adminRequestedBy(reqCtx),
+		})
+		if err != nil {
+			h.logger.Error("failed to erase user log entries", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to erase user log entries",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, result)
+	}
+}
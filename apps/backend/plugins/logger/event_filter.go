@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// EventFilter decides which events of the event bus are logged
+type EventFilter struct {
+	include  []string
+	exclude  []string
+	sampling []types.SamplingRule
+}
+
+func NewEventFilter(config types.LoggerPluginConfig) *EventFilter {
+	return &EventFilter{
+		include:  config.IncludeEventTypes,
+		exclude:  config.ExcludeEventTypes,
+		sampling: config.SamplingRules,
+	}
+}
+
+// Topics returns the exact event types to subscribe to, or the wildcard topic for glob patterns
+func (f *EventFilter) Topics() []string {
+	if len(f.include) == 0 {
+		return []string{models.EventTypeWildcard}
+	}
+
+	topics := make([]string, 0, len(f.include))
+	for _, eventType := range f.include {
+		if types.IsEventTypePattern(eventType) {
+			return []string{models.EventTypeWildcard}
+		}
+		if !types.MatchesAnyEventType(f.exclude, eventType) && !slices.Contains(topics, eventType) {
+			topics = append(topics, eventType)
+		}
+	}
+	return topics
+}
+
+// Allows reports whether the event is included, not excluded and picked by its sampling rule
+func (f *EventFilter) Allows(event models.Event) bool {
+	if len(f.include) > 0 && !types.MatchesAnyEventType(f.include, event.Type) {
+		return false
+	}
+	if types.MatchesAnyEventType(f.exclude, event.Type) {
+		return false
+	}
+	for _, rule := range f.sampling {
+		if types.MatchesAnyEventType(rule.EventTypes, event.Type) {
+			return sampleEvent(event, rule.Rate)
+		}
+	}
+	return true
+}
+
+// Config returns the effective filter set
+func (f *EventFilter) Config() types.EventFilterConfig {
+	config := types.EventFilterConfig{
+		IncludeEventTypes: []string{},
+		ExcludeEventTypes: []string{},
+		SamplingRules:     []types.SamplingRule{},
+		Subscriptions:     f.Topics(),
+	}
+	config.IncludeEventTypes = append(config.IncludeEventTypes, f.include...)
+	config.ExcludeEventTypes = append(config.ExcludeEventTypes, f.exclude...)
+	config.SamplingRules = append(config.SamplingRules, f.sampling...)
+	return config
+}
+
+// sampleEvent keeps the given share of events, deciding from the event ID when there is one
+func sampleEvent(event models.Event, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	if event.ID == "" {
+		return rand.Float64() < rate
+	}
+
+	sum := sha256.Sum256([]byte(event.ID))
+	return float64(binary.BigEndian.Uint64(sum[:8]))/math.MaxUint64 < rate
+}
@@ -0,0 +1,265 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+type exportFormat string
+
+const (
+	exportFormatNDJSON exportFormat = "ndjson"
+	exportFormatCSV    exportFormat = "csv"
+)
+
+// exportFlushEvery is the number of entries written between two flushes to the client
+const exportFlushEvery = 500
+
+var logEntryCSVHeader = []string{
+	"id",
+	"created_at",
+	"event_type",
+	"event_id",
+	"user_id",
+	"session_id",
+	"ip_address",
+	"user_agent",
+	"details",
+	"content_hash",
+	"prev_hash",
+	"hash",
+	"request_id",
+}
+
+type ExportLogEntriesHandler struct {
+	service   services.LoggerService
+	logger    models.Logger
+	getConfig func() *models.Config
+}
+
+func (h *ExportLogEntriesHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		format, filter, order, err := parseExportRequest(r)
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		h.export(w, r, format, filter, order)
+	}
+}
+
+// parseExportRequest reads the format and the same filter and order query parameters as the entries route
+func parseExportRequest(r *http.Request) (exportFormat, types.LogEntryFilter, types.SortOrder, error) {
+	format, err := parseExportFormat(r)
+	if err != nil {
+		return "", types.LogEntryFilter{}, "", err
+	}
+	filter, err := parseLogEntryFilter(r.URL.Query())
+	if err != nil {
+		return "", types.LogEntryFilter{}, "", err
+	}
+	order, err := parseSortOrder(r.URL.Query(), types.SortOrderAsc)
+	if err != nil {
+		return "", types.LogEntryFilter{}, "", err
+	}
+	return format, filter, order, nil
+}
+
+// export streams the matching entries to the connection when the app serves the plugin through StreamingHandler
+func (h *ExportLogEntriesHandler) export(w http.ResponseWriter, r *http.Request, format exportFormat, filter types.LogEntryFilter, order types.SortOrder) {
+	raw, streaming := connectionWriter(r)
+	if !streaming {
+		raw = w
+	}
+	controller := http.NewResponseController(raw)
+
+	header := raw.Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="log-entries.%s"`, format))
+	header.Add("Vary", "Accept, Accept-Encoding")
+	switch format {
+	case exportFormatCSV:
+		header.Set("Content-Type", "text/csv; charset=utf-8")
+	default:
+		header.Set("Content-Type", "application/x-ndjson")
+	}
+
+	var out io.Writer = raw
+	var gzipWriter *gzip.Writer
+	if acceptsGzip(r) {
+		header.Set("Content-Encoding", "gzip")
+		gzipWriter = gzip.NewWriter(raw)
+		out = gzipWriter
+	}
+
+	if streaming {
+		writeStreamingHeader(raw, r, h.getConfig().Security.CORS, http.StatusOK)
+	} else {
+		raw.WriteHeader(http.StatusOK)
+	}
+
+	buffered := bufio.NewWriterSize(out, 32<<10)
+	write, flushRows := newLogEntryRowWriter(format, buffered)
+
+	flush := func() error {
+		if err := flushRows(); err != nil {
+			return err
+		}
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+		if gzipWriter != nil {
+			if err := gzipWriter.Flush(); err != nil {
+				return err
+			}
+		}
+		// Writers that cannot flush still receive the whole body once the handler returns
+		if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	var exported int
+	err := h.service.ExportLogEntries(r.Context(), filter, order, func(entry *types.LogEntry) error {
+		if err := write(entry); err != nil {
+			return err
+		}
+		exported++
+		if exported%exportFlushEvery == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if gzipWriter != nil {
+		if closeErr := gzipWriter.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		h.logger.Error("failed to export log entries", "exported", exported, "error", err)
+		return
+	}
+
+	h.logger.Debug("exported log entries", "format", format, "exported", exported)
+}
+
+// newLogEntryRowWriter returns functions writing a single entry in the given format and flushing it
+func newLogEntryRowWriter(format exportFormat, w io.Writer) (func(entry *types.LogEntry) error, func() error) {
+	if format == exportFormatCSV {
+		csvWriter := csv.NewWriter(w)
+		// Write errors are sticky, they surface through Error once the writer is flushed
+		_ = csvWriter.Write(logEntryCSVHeader)
+		write := func(entry *types.LogEntry) error {
+			return csvWriter.Write(logEntryCSVRecord(entry))
+		}
+		flush := func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+		return write, flush
+	}
+
+	encoder := json.NewEncoder(w)
+	write := func(entry *types.LogEntry) error {
+		return encoder.Encode(entry)
+	}
+	return write, func() error { return nil }
+}
+
+func logEntryCSVRecord(entry *types.LogEntry) []string {
+	optional := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+
+	return []string{
+		strconv.FormatInt(entry.ID, 10),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		entry.EventType,
+		optional(entry.EventID),
+		optional(entry.UserID),
+		optional(entry.SessionID),
+		optional(entry.IPAddress),
+		optional(entry.UserAgent),
+		string(entry.Details),
+		optional(entry.ContentHash),
+		optional(entry.PrevHash),
+		optional(entry.Hash),
+		optional(entry.RequestID),
+	}
+}
+
+// parseExportFormat reads the format query parameter, then the Accept header, defaulting to NDJSON
+func parseExportFormat(r *http.Request) (exportFormat, error) {
+	switch format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))); format {
+	case "":
+	case "ndjson", "jsonl":
+		return exportFormatNDJSON, nil
+	case "csv":
+		return exportFormatCSV, nil
+	default:
+		return "", fmt.Errorf("invalid format, expected %q or %q", exportFormatNDJSON, exportFormatCSV)
+	}
+
+	for mediaRange := range strings.SplitSeq(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return exportFormatCSV, nil
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return exportFormatNDJSON, nil
+		}
+	}
+
+	return exportFormatNDJSON, nil
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows a gzip compressed body
+func acceptsGzip(r *http.Request) bool {
+	for coding := range strings.SplitSeq(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(key) == "q" {
+				quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				return err == nil && quality > 0
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"github.com/Authula/authula/models"
+)
+
+type LoggerHookID string
+
+const (
+	// HookIDLoggerAdmin restricts a route to the admins of the logger plugin. Every route of the
+	// plugin except the self-service ones carries it, the route must also run the session auth hook.
+	HookIDLoggerAdmin LoggerHookID = "logger.admin"
+)
+
+func (id LoggerHookID) String() string {
+	return string(id)
+}
+
+func (p *LoggerPlugin) Hooks() []models.Hook {
+	return []models.Hook{
+		{
+			Stage:    models.HookBefore,
+			PluginID: HookIDLoggerAdmin.String(),
+			Handler:  p.adminChecker.RequireAdmin,
+			// Runs after the session auth hook has resolved the user
+			Order: 20,
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"sync"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// LogStream fans out the stored entries to the live tail, dropping clients that fall behind
+type LogStream struct {
+	bufferSize int
+	mu         sync.Mutex
+	clients    map[*StreamClient]struct{}
+	closed     bool
+}
+
+// StreamClient receives the entries matching its filter until Entries is closed
+type StreamClient struct {
+	// Entries is closed once the client no longer receives entries
+	Entries <-chan *types.LogEntry
+	entries chan *types.LogEntry
+	filter  types.LogEntryFilter
+	dropped bool
+}
+
+func NewLogStream(bufferSize int) *LogStream {
+	return &LogStream{
+		bufferSize: bufferSize,
+		clients:    make(map[*StreamClient]struct{}),
+	}
+}
+
+// Subscribe registers a client receiving the entries that match the event types and user ID of the filter
+func (s *LogStream) Subscribe(filter types.LogEntryFilter) *StreamClient {
+	entries := make(chan *types.LogEntry, s.bufferSize)
+	client := &StreamClient{Entries: entries, entries: entries, filter: filter}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		close(entries)
+		return client
+	}
+	s.clients[client] = struct{}{}
+	return client
+}
+
+// Unsubscribe removes the client, it is safe to call after the client was dropped
+func (s *LogStream) Unsubscribe(client *StreamClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[client]; ok {
+		delete(s.clients, client)
+		close(client.entries)
+	}
+}
+
+// Dropped reports whether the client was removed for falling behind, once Entries is closed
+func (s *LogStream) Dropped(client *StreamClient) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return client.dropped
+}
+
+// Publish hands the entries to every matching client without waiting for any of them
+func (s *LogStream) Publish(entries []*types.LogEntry) {
+	if s == nil || len(entries) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for client := range s.clients {
+		for _, entry := range entries {
+			if !streamFilterMatches(client.filter, entry) {
+				continue
+			}
+			select {
+			case client.entries <- entry:
+			default:
+				client.dropped = true
+				delete(s.clients, client)
+				close(client.entries)
+			}
+			if client.dropped {
+				break
+			}
+		}
+	}
+}
+
+// Close disconnects every client and rejects new ones
+func (s *LogStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for client := range s.clients {
+		delete(s.clients, client)
+		close(client.entries)
+	}
+}
+
+func streamFilterMatches(filter types.LogEntryFilter, entry *types.LogEntry) bool {
+	if len(filter.EventTypes) > 0 && !types.MatchesAnyEventType(filter.EventTypes, entry.EventType) {
+		return false
+	}
+	if filter.UserID != nil && (entry.UserID == nil || *entry.UserID != *filter.UserID) {
+		return false
+	}
+	return true
+}
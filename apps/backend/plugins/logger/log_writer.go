@@ -0,0 +1,293 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// LogWriter buffers events off the event bus handler goroutine and stores them in batches
+type LogWriter struct {
+	logger          models.Logger
+	service         services.LoggerService
+	stream          *LogStream
+	eventFilter     *EventFilter
+	sinks           []*SinkWorker
+	batchSize       int
+	flushInterval   time.Duration
+	maxRetries      int
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+	retryMaxTime    time.Duration
+	ackMode         types.AckMode
+	events          chan queuedEvent
+	// mu keeps Close from finishing while a Write is still handing over its event
+	mu     sync.RWMutex
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// queuedEvent is an event waiting to be written with the decision of the event filter
+type queuedEvent struct {
+	event  models.Event
+	store  bool
+	result chan error
+}
+
+// failedEntry is an entry that could not be stored together with the error of its last attempt
+type failedEntry struct {
+	entry *types.LogEntry
+	err   error
+}
+
+func NewLogWriter(logger models.Logger, service services.LoggerService, stream *LogStream, eventFilter *EventFilter, sinks []*SinkWorker, config types.LoggerPluginConfig) *LogWriter {
+	return &LogWriter{
+		logger:          logger,
+		service:         service,
+		stream:          stream,
+		eventFilter:     eventFilter,
+		sinks:           sinks,
+		batchSize:       config.WriteBatchSize,
+		flushInterval:   config.WriteFlushInterval,
+		maxRetries:      config.WriteMaxRetries,
+		retryBackoff:    config.WriteRetryBackoff,
+		retryMaxBackoff: config.WriteRetryMaxBackoff,
+		retryMaxTime:    config.WriteRetryMaxTime,
+		ackMode:         config.AckMode,
+		events:          make(chan queuedEvent, config.WriteBufferSize),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// Start runs the write loop until Close is called
+func (w *LogWriter) Start() {
+	go w.runWriteLoop()
+}
+
+// Write queues the event, waiting for it to be stored in AckModeStored
+func (w *LogWriter) Write(ctx context.Context, event models.Event) error {
+	queued := queuedEvent{event: event, store: w.eventFilter.Allows(event)}
+	if !queued.store && !w.sinksAccept(event.Type) {
+		return nil
+	}
+	if queued.store && w.ackMode == types.AckModeStored {
+		queued.result = make(chan error, 1)
+	}
+
+	if err := w.enqueue(ctx, queued); err != nil {
+		return err
+	}
+	if queued.result == nil {
+		return nil
+	}
+
+	select {
+	case err := <-queued.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *LogWriter) enqueue(ctx context.Context, queued queuedEvent) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return constants.ErrLogWriterClosed
+	}
+
+	select {
+	case w.events <- queued:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events and waits until every queued event has been stored
+func (w *LogWriter) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+}
+
+func (w *LogWriter) runWriteLoop() {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+	defer close(w.done)
+
+	batch := make([]queuedEvent, 0, w.batchSize)
+	add := func(event queuedEvent) {
+		batch = append(batch, event)
+		if len(batch) >= w.batchSize {
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case event := <-w.events:
+			add(event)
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		case <-w.stop:
+			// No Write is in flight once closed is set, drain what is left in the buffer
+			for {
+				select {
+				case event := <-w.events:
+					add(event)
+				default:
+					w.flush(batch)
+					w.logger.Debug("log writer stopped")
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *LogWriter) flush(batch []queuedEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	events := make([]models.Event, 0, len(batch))
+	for _, queued := range batch {
+		events = append(events, queued.event)
+	}
+	entries := w.service.NewLogEntries(events)
+
+	toStore := make([]*types.LogEntry, 0, len(entries))
+	for i, queued := range batch {
+		if queued.store {
+			toStore = append(toStore, entries[i])
+		}
+	}
+	lost := w.store(toStore)
+
+	for i, queued := range batch {
+		if queued.result != nil {
+			queued.result <- lost[entries[i]]
+		}
+	}
+
+	// The sinks get the entries after they were stored, so that the stored ones carry their ID and
+	// hash. They also get redelivered events, only the database drops those.
+	for _, sink := range w.sinks {
+		sink.Enqueue(entries)
+	}
+}
+
+// store stores the entries and returns the ones that were neither stored nor dead-lettered
+func (w *LogWriter) store(entries []*types.LogEntry) map[*types.LogEntry]error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	stored, attempts, err := w.storeWithRetry(entries)
+	// The entries stored before the max log count was reached are published as well
+	w.stream.Publish(stored)
+	if err == nil || errors.Is(err, constants.ErrMaxLogCountReached) {
+		return nil
+	}
+
+	if len(entries) == 1 {
+		return w.deadLetter([]failedEntry{{entry: entries[0], err: err}}, attempts)
+	}
+
+	w.logger.Warn("failed to store log entries, storing them one by one", "count", len(entries), "error", err)
+	var failed []failedEntry
+	for _, entry := range entries {
+		stored, err := w.service.StoreLogEntries(context.Background(), []*types.LogEntry{entry})
+		w.stream.Publish(stored)
+		if err != nil && !errors.Is(err, constants.ErrMaxLogCountReached) {
+			failed = append(failed, failedEntry{entry: entry, err: err})
+		}
+	}
+	return w.deadLetter(failed, attempts+1)
+}
+
+// storeWithRetry stores the entries with an exponential backoff until Close, retryMaxTime or the retries end
+func (w *LogWriter) storeWithRetry(entries []*types.LogEntry) ([]*types.LogEntry, int, error) {
+	deadline := time.Now().Add(w.retryMaxTime)
+	backoff := w.retryBackoff
+	for attempt := 1; ; attempt++ {
+		stored, err := w.service.StoreLogEntries(context.Background(), entries)
+		if err == nil || errors.Is(err, constants.ErrMaxLogCountReached) || attempt > w.maxRetries {
+			return stored, attempt, err
+		}
+		if time.Until(deadline) < backoff {
+			w.logger.Warn("failed to store log entries, retry time spent", "count", len(entries), "attempt", attempt, "error", err)
+			return stored, attempt, err
+		}
+
+		w.logger.Warn("failed to store log entries, retrying", "count", len(entries), "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-w.stop:
+			w.logger.Warn("failed to store log entries, log writer closing", "count", len(entries), "attempt", attempt, "error", err)
+			return stored, attempt, err
+		}
+		backoff = min(backoff*2, w.retryMaxBackoff)
+	}
+}
+
+// deadLetter keeps the failed entries for an admin to replay and returns the ones it could not keep
+func (w *LogWriter) deadLetter(failed []failedEntry, attempts int) map[*types.LogEntry]error {
+	if len(failed) == 0 {
+		return nil
+	}
+
+	lost := make(map[*types.LogEntry]error)
+	deadLetters := make([]*types.DeadLetter, 0, len(failed))
+	now := time.Now()
+	for _, f := range failed {
+		deadLetter, err := types.NewDeadLetter(f.entry, f.err, attempts, now)
+		if err != nil {
+			w.logger.Error("failed to dead-letter log entry", "event_type", f.entry.EventType, "error", err)
+			lost[f.entry] = f.err
+			continue
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	if err := w.service.CreateDeadLetters(context.Background(), deadLetters); err != nil {
+		w.logger.Error("failed to dead-letter log entries, they are lost", "count", len(deadLetters), "error", err)
+		for _, f := range failed {
+			lost[f.entry] = errors.Join(f.err, err)
+		}
+		return lost
+	}
+	if len(deadLetters) > 0 {
+		w.logger.Error("dead-lettered log entries that could not be stored", "count", len(deadLetters), "error", failed[len(failed)-1].err)
+	}
+	return lost
+}
+
+func (w *LogWriter) sinksAccept(eventType string) bool {
+	for _, sink := range w.sinks {
+		if sink.Accepts(eventType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// failingLoggerRepository fails to store entries and keeps everything else in memory
+type failingLoggerRepository struct {
+	*repositories.MemoryLoggerRepository
+}
+
+func (r *failingLoggerRepository) CreateBatch(ctx context.Context, entries []*types.LogEntry) ([]*types.LogEntry, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func TestLogWriter_RetryBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		config types.LoggerPluginConfig
+		// closeAfter closes the writer while it waits for a retry, zero waits for the dead letter instead
+		closeAfter time.Duration
+	}{
+		{
+			name:       "close interrupts the backoff",
+			config:     types.LoggerPluginConfig{WriteMaxRetries: 5, WriteRetryBackoff: time.Hour, WriteRetryMaxBackoff: time.Hour, WriteRetryMaxTime: 24 * time.Hour},
+			closeAfter: 50 * time.Millisecond,
+		},
+		{
+			name:   "retry time caps the retries",
+			config: types.LoggerPluginConfig{WriteMaxRetries: 100, WriteRetryBackoff: 10 * time.Millisecond, WriteRetryMaxBackoff: 10 * time.Millisecond, WriteRetryMaxTime: 50 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			config := tt.config
+			config.WriteFlushInterval = time.Millisecond
+			require.NoError(t, config.Validate())
+
+			repo := &failingLoggerRepository{MemoryLoggerRepository: repositories.NewMemoryLoggerRepository()}
+			logger := slog.New(slog.DiscardHandler)
+			redactor, err := services.NewRedactor(config.EffectiveRedactionRules(), config.RedactionHMACKey)
+			require.NoError(t, err)
+			service := services.NewService(repo, services.NewDatabaseLogCounter(repo), redactor, services.NewUserDataErasers(), nil, logger, config)
+			writer := NewLogWriter(logger, service, NewLogStream(config.StreamClientBufferSize), NewEventFilter(config), nil, config)
+			writer.Start()
+
+			require.NoError(t, writer.Write(ctx, models.Event{ID: "e1", Type: "user.signed_in", Payload: []byte(`{}`)}))
+
+			start := time.Now()
+			if tt.closeAfter > 0 {
+				time.Sleep(tt.closeAfter)
+				writer.Close()
+			} else {
+				assert.Eventually(t, func() bool {
+					deadLetters, _, err := repo.ListDeadLetters(ctx, types.DeadLetterQuery{Limit: 10})
+					return err == nil && len(deadLetters) == 1
+				}, 5*time.Second, 10*time.Millisecond)
+				writer.Close()
+			}
+			assert.Less(t, time.Since(start), 5*time.Second)
+
+			// The entry is dead-lettered rather than lost
+			deadLetters, _, err := repo.ListDeadLetters(ctx, types.DeadLetterQuery{Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, deadLetters, 1)
+			assert.Equal(t, "e1", *deadLetters[0].EventID)
+		})
+	}
+}
@@ -2,22 +2,68 @@ package logger
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/uptrace/bun"
 
 	"github.com/Authula/authula/migrations"
+
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/types"
 )
 
 func loggerMigrations(provider string) []migrations.Migration {
 	return migrations.ForProvider(provider, migrations.ProviderVariants{
 		"sqlite": func() []migrations.Migration {
-			return []migrations.Migration{loggerSQLiteInitial()}
+			return []migrations.Migration{
+				loggerSQLiteInitial(),
+				loggerSQLiteStructuredColumns(),
+				loggerSQLiteHashChain(),
+				loggerSQLiteStatsRollup(),
+				loggerSQLiteSecurityAlerts(),
+				loggerSQLiteUniqueEventID(),
+				loggerSQLiteDeadLetters(),
+				loggerSQLiteErasedEntries(),
+				loggerSQLiteSearch(),
+				loggerSQLiteRequestID(),
+				loggerSQLiteProcessedEvents(),
+				loggerSQLiteErasedUsers(),
+			}
 		},
 		"postgres": func() []migrations.Migration {
-			return []migrations.Migration{loggerPostgresInitial()}
+			return []migrations.Migration{
+				loggerPostgresInitial(),
+				loggerPostgresStructuredColumns(),
+				loggerPostgresHashChain(),
+				loggerPostgresStatsRollup(),
+				loggerPostgresSecurityAlerts(),
+				loggerPostgresUniqueEventID(),
+				loggerPostgresDeadLetters(),
+				loggerPostgresErasedEntries(),
+				loggerPostgresPartitions(),
+				loggerPostgresSearch(),
+				loggerPostgresRequestID(),
+				loggerPostgresProcessedEvents(),
+				loggerPostgresErasedUsers(),
+			}
 		},
 		"mysql": func() []migrations.Migration {
-			return []migrations.Migration{loggerMySQLInitial()}
+			return []migrations.Migration{
+				loggerMySQLInitial(),
+				loggerMySQLStructuredColumns(),
+				loggerMySQLHashChain(),
+				loggerMySQLStatsRollup(),
+				loggerMySQLSecurityAlerts(),
+				loggerMySQLUniqueEventID(),
+				loggerMySQLDeadLetters(),
+				loggerMySQLErasedEntries(),
+				loggerMySQLSearch(),
+				loggerMySQLRequestID(),
+				loggerMySQLProcessedEvents(),
+				loggerMySQLErasedUsers(),
+			}
 		},
 	})
 }
@@ -96,3 +142,1289 @@ func loggerMySQLInitial() migrations.Migration {
 		},
 	}
 }
+
+func loggerSQLiteStructuredColumns() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017072244_logger_structured_columns",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN event_id VARCHAR(255);`,
+				`ALTER TABLE log_entries ADD COLUMN user_id VARCHAR(255);`,
+				`ALTER TABLE log_entries ADD COLUMN session_id VARCHAR(255);`,
+				`ALTER TABLE log_entries ADD COLUMN ip_address VARCHAR(45);`,
+				`ALTER TABLE log_entries ADD COLUMN user_agent VARCHAR(512);`,
+			); err != nil {
+				return err
+			}
+			if err := backfillLogEntryStructuredColumns(ctx, tx); err != nil {
+				return err
+			}
+			// SQLite has no JSON column type, details stays TEXT and is queried through the JSON1 functions
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_event_id ON log_entries(event_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_id ON log_entries(user_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_session_id ON log_entries(session_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_ip_address ON log_entries(ip_address);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_agent ON log_entries(user_agent);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_entries_user_agent;`,
+				`DROP INDEX IF EXISTS idx_log_entries_ip_address;`,
+				`DROP INDEX IF EXISTS idx_log_entries_session_id;`,
+				`DROP INDEX IF EXISTS idx_log_entries_user_id;`,
+				`DROP INDEX IF EXISTS idx_log_entries_event_id;`,
+				`ALTER TABLE log_entries DROP COLUMN user_agent;`,
+				`ALTER TABLE log_entries DROP COLUMN ip_address;`,
+				`ALTER TABLE log_entries DROP COLUMN session_id;`,
+				`ALTER TABLE log_entries DROP COLUMN user_id;`,
+				`ALTER TABLE log_entries DROP COLUMN event_id;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresStructuredColumns() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017072244_logger_structured_columns",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  ADD COLUMN IF NOT EXISTS event_id VARCHAR(255),
+  ADD COLUMN IF NOT EXISTS user_id VARCHAR(255),
+  ADD COLUMN IF NOT EXISTS session_id VARCHAR(255),
+  ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45),
+  ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512);`,
+			); err != nil {
+				return err
+			}
+			if err := backfillLogEntryStructuredColumns(ctx, tx); err != nil {
+				return err
+			}
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ALTER COLUMN details TYPE JSONB USING details::JSONB;`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_event_id ON log_entries(event_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_id ON log_entries(user_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_session_id ON log_entries(session_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_ip_address ON log_entries(ip_address);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_agent ON log_entries(user_agent);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_entries_user_agent;`,
+				`DROP INDEX IF EXISTS idx_log_entries_ip_address;`,
+				`DROP INDEX IF EXISTS idx_log_entries_session_id;`,
+				`DROP INDEX IF EXISTS idx_log_entries_user_id;`,
+				`DROP INDEX IF EXISTS idx_log_entries_event_id;`,
+				`ALTER TABLE log_entries ALTER COLUMN details TYPE TEXT USING details::TEXT;`,
+				`ALTER TABLE log_entries
+  DROP COLUMN IF EXISTS user_agent,
+  DROP COLUMN IF EXISTS ip_address,
+  DROP COLUMN IF EXISTS session_id,
+  DROP COLUMN IF EXISTS user_id,
+  DROP COLUMN IF EXISTS event_id;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLStructuredColumns() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017072244_logger_structured_columns",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  ADD COLUMN event_id VARCHAR(255) NULL,
+  ADD COLUMN user_id VARCHAR(255) NULL,
+  ADD COLUMN session_id VARCHAR(255) NULL,
+  ADD COLUMN ip_address VARCHAR(45) NULL,
+  ADD COLUMN user_agent VARCHAR(512) NULL;`,
+			); err != nil {
+				return err
+			}
+			if err := backfillLogEntryStructuredColumns(ctx, tx); err != nil {
+				return err
+			}
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries MODIFY COLUMN details JSON NOT NULL;`,
+				`CREATE INDEX idx_log_entries_event_id ON log_entries(event_id);`,
+				`CREATE INDEX idx_log_entries_user_id ON log_entries(user_id);`,
+				`CREATE INDEX idx_log_entries_session_id ON log_entries(session_id);`,
+				`CREATE INDEX idx_log_entries_ip_address ON log_entries(ip_address);`,
+				`CREATE INDEX idx_log_entries_user_agent ON log_entries(user_agent);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries MODIFY COLUMN details TEXT NOT NULL;`,
+				`ALTER TABLE log_entries
+  DROP INDEX idx_log_entries_user_agent,
+  DROP INDEX idx_log_entries_ip_address,
+  DROP INDEX idx_log_entries_session_id,
+  DROP INDEX idx_log_entries_user_id,
+  DROP INDEX idx_log_entries_event_id,
+  DROP COLUMN user_agent,
+  DROP COLUMN ip_address,
+  DROP COLUMN session_id,
+  DROP COLUMN user_id,
+  DROP COLUMN event_id;`,
+			)
+		},
+	}
+}
+
+func loggerSQLiteHashChain() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017075512_logger_hash_chain",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN content_hash VARCHAR(64);`,
+				`ALTER TABLE log_entries ADD COLUMN prev_hash VARCHAR(64);`,
+				`ALTER TABLE log_entries ADD COLUMN hash VARCHAR(64);`,
+				`CREATE TABLE IF NOT EXISTS log_chain_head (
+  id INTEGER PRIMARY KEY,
+  entry_id INTEGER NOT NULL,
+  hash VARCHAR(64) NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+				`CREATE TABLE IF NOT EXISTS log_chain_checkpoints (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  entry_id INTEGER NOT NULL,
+  hash VARCHAR(64) NOT NULL,
+  signature VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+			); err != nil {
+				return err
+			}
+			return backfillLogEntryHashChain(ctx, tx)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_chain_checkpoints;`,
+				`DROP TABLE IF EXISTS log_chain_head;`,
+				`ALTER TABLE log_entries DROP COLUMN hash;`,
+				`ALTER TABLE log_entries DROP COLUMN prev_hash;`,
+				`ALTER TABLE log_entries DROP COLUMN content_hash;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresHashChain() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017075512_logger_hash_chain",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64),
+  ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64),
+  ADD COLUMN IF NOT EXISTS hash VARCHAR(64);`,
+				`CREATE TABLE IF NOT EXISTS log_chain_head (
+  id BIGINT PRIMARY KEY,
+  entry_id BIGINT NOT NULL,
+  hash VARCHAR(64) NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);`,
+				`CREATE TABLE IF NOT EXISTS log_chain_checkpoints (
+  id BIGSERIAL PRIMARY KEY,
+  entry_id BIGINT NOT NULL,
+  hash VARCHAR(64) NOT NULL,
+  signature VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);`,
+			); err != nil {
+				return err
+			}
+			return backfillLogEntryHashChain(ctx, tx)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_chain_checkpoints;`,
+				`DROP TABLE IF EXISTS log_chain_head;`,
+				`ALTER TABLE log_entries
+  DROP COLUMN IF EXISTS hash,
+  DROP COLUMN IF EXISTS prev_hash,
+  DROP COLUMN IF EXISTS content_hash;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLHashChain() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017075512_logger_hash_chain",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			// created_at keeps microseconds like the other dialects so that hashes can be recomputed
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  MODIFY COLUMN created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  ADD COLUMN content_hash VARCHAR(64) NULL,
+  ADD COLUMN prev_hash VARCHAR(64) NULL,
+  ADD COLUMN hash VARCHAR(64) NULL;`,
+				`CREATE TABLE IF NOT EXISTS log_chain_head (
+  id BIGINT NOT NULL PRIMARY KEY,
+  entry_id BIGINT NOT NULL,
+  hash VARCHAR(64) NOT NULL,
+  updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+				`CREATE TABLE IF NOT EXISTS log_chain_checkpoints (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  entry_id BIGINT NOT NULL,
+  hash VARCHAR(64) NOT NULL,
+  signature VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+			); err != nil {
+				return err
+			}
+			return backfillLogEntryHashChain(ctx, tx)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_chain_checkpoints;`,
+				`DROP TABLE IF EXISTS log_chain_head;`,
+				`ALTER TABLE log_entries
+  DROP COLUMN hash,
+  DROP COLUMN prev_hash,
+  DROP COLUMN content_hash,
+  MODIFY COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;`,
+			)
+		},
+	}
+}
+
+func loggerSQLiteStatsRollup() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017084703_logger_stats_rollup",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_stats_hourly (
+  bucket_start TIMESTAMP NOT NULL,
+  event_type VARCHAR(255) NOT NULL,
+  event_count INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (bucket_start, event_type)
+);`,
+			); err != nil {
+				return err
+			}
+			return backfillLogStatsRollup(ctx, tx)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_stats_hourly;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresStatsRollup() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017084703_logger_stats_rollup",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_stats_hourly (
+  bucket_start TIMESTAMP NOT NULL,
+  event_type VARCHAR(255) NOT NULL,
+  event_count BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (bucket_start, event_type)
+);`,
+			); err != nil {
+				return err
+			}
+			return backfillLogStatsRollup(ctx, tx)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_stats_hourly;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLStatsRollup() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017084703_logger_stats_rollup",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_stats_hourly (
+  bucket_start TIMESTAMP NOT NULL,
+  event_type VARCHAR(255) NOT NULL,
+  event_count BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (bucket_start, event_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+			); err != nil {
+				return err
+			}
+			return backfillLogStatsRollup(ctx, tx)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_stats_hourly;`,
+			)
+		},
+	}
+}
+
+func loggerSQLiteSecurityAlerts() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017085036_logger_security_alerts",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_security_alerts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  alert_type VARCHAR(64) NOT NULL,
+  email VARCHAR(255),
+  ip_address VARCHAR(45),
+  event_count INTEGER NOT NULL,
+  threshold INTEGER NOT NULL,
+  window_seconds INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  acknowledged_at TIMESTAMP,
+  acknowledged_by VARCHAR(255)
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_security_alerts_acknowledged_at ON log_security_alerts(acknowledged_at);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_security_alerts_acknowledged_at;`,
+				`DROP TABLE IF EXISTS log_security_alerts;`,
+			)
+		},
+	}
+}
+
+// loggerPostgresSecurityAlerts also widens event_type for alert events such as security.alert.credential_stuffing
+func loggerPostgresSecurityAlerts() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017085036_logger_security_alerts",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ALTER COLUMN event_type TYPE VARCHAR(255);`,
+				`CREATE TABLE IF NOT EXISTS log_security_alerts (
+  id BIGSERIAL PRIMARY KEY,
+  alert_type VARCHAR(64) NOT NULL,
+  email VARCHAR(255),
+  ip_address VARCHAR(45),
+  event_count INTEGER NOT NULL,
+  threshold INTEGER NOT NULL,
+  window_seconds BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  acknowledged_at TIMESTAMP,
+  acknowledged_by VARCHAR(255)
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_security_alerts_acknowledged_at ON log_security_alerts(acknowledged_at);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_security_alerts_acknowledged_at;`,
+				`DROP TABLE IF EXISTS log_security_alerts;`,
+				`ALTER TABLE log_entries ALTER COLUMN event_type TYPE VARCHAR(32);`,
+			)
+		},
+	}
+}
+
+func loggerMySQLSecurityAlerts() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017085036_logger_security_alerts",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries MODIFY COLUMN event_type VARCHAR(255) NOT NULL;`,
+				`CREATE TABLE IF NOT EXISTS log_security_alerts (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  alert_type VARCHAR(64) NOT NULL,
+  email VARCHAR(255) NULL,
+  ip_address VARCHAR(45) NULL,
+  event_count INT NOT NULL,
+  threshold INT NOT NULL,
+  window_seconds BIGINT NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  acknowledged_at TIMESTAMP(6) NULL,
+  acknowledged_by VARCHAR(255) NULL,
+  INDEX idx_log_security_alerts_acknowledged_at (acknowledged_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_security_alerts;`,
+				`ALTER TABLE log_entries MODIFY COLUMN event_type VARCHAR(32) NOT NULL;`,
+			)
+		},
+	}
+}
+
+func loggerSQLiteUniqueEventID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017092447_logger_unique_event_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN dedup_event_id VARCHAR(255);`,
+				`UPDATE log_entries SET dedup_event_id = event_id
+WHERE id IN (SELECT MIN(id) FROM log_entries WHERE event_id IS NOT NULL GROUP BY event_id);`,
+				`CREATE UNIQUE INDEX IF NOT EXISTS idx_log_entries_dedup_event_id ON log_entries(dedup_event_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_entries_dedup_event_id;`,
+				`ALTER TABLE log_entries DROP COLUMN dedup_event_id;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresUniqueEventID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017092447_logger_unique_event_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS dedup_event_id VARCHAR(255);`,
+				`UPDATE log_entries SET dedup_event_id = event_id
+WHERE id IN (SELECT MIN(id) FROM log_entries WHERE event_id IS NOT NULL GROUP BY event_id);`,
+				`CREATE UNIQUE INDEX IF NOT EXISTS idx_log_entries_dedup_event_id ON log_entries(dedup_event_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_entries_dedup_event_id;`,
+				`ALTER TABLE log_entries DROP COLUMN IF EXISTS dedup_event_id;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLUniqueEventID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017092447_logger_unique_event_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN dedup_event_id VARCHAR(255) NULL;`,
+				// MySQL cannot select from the updated table in a subquery, the derived table is materialized first
+				`UPDATE log_entries l
+JOIN (SELECT MIN(id) AS id FROM log_entries WHERE event_id IS NOT NULL GROUP BY event_id) f ON f.id = l.id
+SET l.dedup_event_id = l.event_id;`,
+				`ALTER TABLE log_entries ADD UNIQUE INDEX idx_log_entries_dedup_event_id (dedup_event_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  DROP INDEX idx_log_entries_dedup_event_id,
+  DROP COLUMN dedup_event_id;`,
+			)
+		},
+	}
+}
+
+func loggerSQLiteDeadLetters() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017092814_logger_dead_letters",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_dead_letters (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  event_id VARCHAR(255),
+  event_type VARCHAR(255) NOT NULL,
+  entry TEXT NOT NULL,
+  last_error TEXT NOT NULL,
+  attempts INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_dead_letters;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresDeadLetters() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017092814_logger_dead_letters",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_dead_letters (
+  id BIGSERIAL PRIMARY KEY,
+  event_id VARCHAR(255),
+  event_type VARCHAR(255) NOT NULL,
+  entry TEXT NOT NULL,
+  last_error TEXT NOT NULL,
+  attempts INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  last_attempt_at TIMESTAMP NOT NULL DEFAULT NOW()
+);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_dead_letters;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLDeadLetters() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017092814_logger_dead_letters",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_dead_letters (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  event_id VARCHAR(255) NULL,
+  event_type VARCHAR(255) NOT NULL,
+  entry MEDIUMTEXT NOT NULL,
+  last_error TEXT NOT NULL,
+  attempts INT NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  last_attempt_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_dead_letters;`,
+			)
+		},
+	}
+}
+
+func loggerSQLiteErasedEntries() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017093147_logger_erased_entries",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_erased_entries (
+  entry_id INTEGER PRIMARY KEY,
+  content_hash VARCHAR(64),
+  prev_hash VARCHAR(64),
+  hash VARCHAR(64),
+  mode VARCHAR(16) NOT NULL,
+  erased_at TIMESTAMP NOT NULL
+);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_erased_entries;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresErasedEntries() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017093147_logger_erased_entries",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_erased_entries (
+  entry_id BIGINT PRIMARY KEY,
+  content_hash VARCHAR(64),
+  prev_hash VARCHAR(64),
+  hash VARCHAR(64),
+  mode VARCHAR(16) NOT NULL,
+  erased_at TIMESTAMP NOT NULL
+);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_erased_entries;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLErasedEntries() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017093147_logger_erased_entries",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_erased_entries (
+  entry_id BIGINT NOT NULL PRIMARY KEY,
+  content_hash VARCHAR(64) NULL,
+  prev_hash VARCHAR(64) NULL,
+  hash VARCHAR(64) NULL,
+  mode VARCHAR(16) NOT NULL,
+  erased_at TIMESTAMP(6) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_erased_entries;`,
+			)
+		},
+	}
+}
+
+// logEntryColumns lists the columns of log_entries for copying the rows between tables
+const logEntryColumns = `id, event_type, details, created_at, event_id, user_id, session_id, ip_address, user_agent, content_hash, prev_hash, hash, dedup_event_id`
+
+// loggerPostgresPartitions moves log_entries to a table partitioned by created_at month
+func loggerPostgresPartitions() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017094329_logger_partitions",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries RENAME TO log_entries_unpartitioned;`,
+				`ALTER SEQUENCE log_entries_id_seq OWNED BY NONE;`,
+				`CREATE TABLE log_entries (
+  id BIGINT NOT NULL DEFAULT nextval('log_entries_id_seq'),
+  event_type VARCHAR(255) NOT NULL,
+  details JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  event_id VARCHAR(255),
+  user_id VARCHAR(255),
+  session_id VARCHAR(255),
+  ip_address VARCHAR(45),
+  user_agent VARCHAR(512),
+  content_hash VARCHAR(64),
+  prev_hash VARCHAR(64),
+  hash VARCHAR(64),
+  dedup_event_id VARCHAR(255)
+) PARTITION BY RANGE (created_at);`,
+				`ALTER SEQUENCE log_entries_id_seq OWNED BY log_entries.id;`,
+				`CREATE TABLE log_entries_default PARTITION OF log_entries DEFAULT;`,
+			); err != nil {
+				return err
+			}
+			if err := createLogEntryPartitions(ctx, tx); err != nil {
+				return err
+			}
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`INSERT INTO log_entries (`+logEntryColumns+`)
+SELECT `+logEntryColumns+` FROM log_entries_unpartitioned;`,
+				`DROP TABLE log_entries_unpartitioned;`,
+				`ALTER TABLE log_entries ADD PRIMARY KEY (id, created_at);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_event_id ON log_entries(event_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_id ON log_entries(user_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_session_id ON log_entries(session_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_ip_address ON log_entries(ip_address);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_agent ON log_entries(user_agent);`,
+				// Unique indexes of a partitioned table must include created_at, the chain head lock keeps events unique
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_dedup_event_id ON log_entries(dedup_event_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries RENAME TO log_entries_partitioned;`,
+				`ALTER SEQUENCE log_entries_id_seq OWNED BY NONE;`,
+				`CREATE TABLE log_entries (
+  id BIGINT NOT NULL DEFAULT nextval('log_entries_id_seq'),
+  event_type VARCHAR(255) NOT NULL,
+  details JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  event_id VARCHAR(255),
+  user_id VARCHAR(255),
+  session_id VARCHAR(255),
+  ip_address VARCHAR(45),
+  user_agent VARCHAR(512),
+  content_hash VARCHAR(64),
+  prev_hash VARCHAR(64),
+  hash VARCHAR(64),
+  dedup_event_id VARCHAR(255)
+);`,
+				`ALTER SEQUENCE log_entries_id_seq OWNED BY log_entries.id;`,
+				`INSERT INTO log_entries (`+logEntryColumns+`)
+SELECT `+logEntryColumns+` FROM log_entries_partitioned;`,
+				`DROP TABLE log_entries_partitioned;`,
+				`ALTER TABLE log_entries ADD PRIMARY KEY (id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_event_id ON log_entries(event_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_id ON log_entries(user_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_session_id ON log_entries(session_id);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_ip_address ON log_entries(ip_address);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_user_agent ON log_entries(user_agent);`,
+				`CREATE UNIQUE INDEX IF NOT EXISTS idx_log_entries_dedup_event_id ON log_entries(dedup_event_id);`,
+			)
+		},
+	}
+}
+
+// loggerSQLiteSearch indexes the entries in an FTS5 table when the build supports it
+func loggerSQLiteSearch() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017095317_logger_search",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			var hasFTS5 bool
+			if err := tx.NewSelect().ColumnExpr("sqlite_compileoption_used('ENABLE_FTS5')").Scan(ctx, &hasFTS5); err != nil {
+				return fmt.Errorf("failed to check for FTS5 support: %w", err)
+			}
+			if !hasFTS5 {
+				return nil
+			}
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE VIRTUAL TABLE IF NOT EXISTS log_entries_fts USING fts5(
+  event_type, user_id, ip_address, user_agent, details,
+  content='log_entries', content_rowid='id', tokenize='trigram'
+);`,
+				`CREATE TRIGGER IF NOT EXISTS log_entries_fts_insert AFTER INSERT ON log_entries BEGIN
+  INSERT INTO log_entries_fts(rowid, event_type, user_id, ip_address, user_agent, details)
+  VALUES (new.id, new.event_type, new.user_id, new.ip_address, new.user_agent, new.details);
+END;`,
+				`CREATE TRIGGER IF NOT EXISTS log_entries_fts_delete AFTER DELETE ON log_entries BEGIN
+  INSERT INTO log_entries_fts(log_entries_fts, rowid, event_type, user_id, ip_address, user_agent, details)
+  VALUES ('delete', old.id, old.event_type, old.user_id, old.ip_address, old.user_agent, old.details);
+END;`,
+				`CREATE TRIGGER IF NOT EXISTS log_entries_fts_update AFTER UPDATE ON log_entries BEGIN
+  INSERT INTO log_entries_fts(log_entries_fts, rowid, event_type, user_id, ip_address, user_agent, details)
+  VALUES ('delete', old.id, old.event_type, old.user_id, old.ip_address, old.user_agent, old.details);
+  INSERT INTO log_entries_fts(rowid, event_type, user_id, ip_address, user_agent, details)
+  VALUES (new.id, new.event_type, new.user_id, new.ip_address, new.user_agent, new.details);
+END;`,
+				`INSERT INTO log_entries_fts(log_entries_fts) VALUES ('rebuild');`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TRIGGER IF EXISTS log_entries_fts_insert;`,
+				`DROP TRIGGER IF EXISTS log_entries_fts_delete;`,
+				`DROP TRIGGER IF EXISTS log_entries_fts_update;`,
+				`DROP TABLE IF EXISTS log_entries_fts;`,
+			)
+		},
+	}
+}
+
+// loggerPostgresSearch adds a GIN index on the text search vector of the entries
+func loggerPostgresSearch() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017095317_logger_search",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_search ON log_entries USING GIN (`+repositories.PostgresSearchVector+`);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_entries_search;`,
+			)
+		},
+	}
+}
+
+// loggerMySQLSearch adds a FULLTEXT index on a generated column of the searchable fields
+func loggerMySQLSearch() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017095317_logger_search",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN search_text MEDIUMTEXT
+  GENERATED ALWAYS AS (CONCAT_WS(' ', event_type, user_id, ip_address, user_agent, CAST(details AS CHAR))) STORED;`,
+				`CREATE FULLTEXT INDEX idx_log_entries_search ON log_entries(search_text);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  DROP INDEX idx_log_entries_search,
+  DROP COLUMN search_text;`,
+			)
+		},
+	}
+}
+
+func loggerSQLiteRequestID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017100959_logger_request_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN request_id VARCHAR(128);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_request_id ON log_entries(request_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_entries_request_id;`,
+				`ALTER TABLE log_entries DROP COLUMN request_id;`,
+			)
+		},
+	}
+}
+
+// loggerPostgresRequestID adds the column and its index to every partition
+func loggerPostgresRequestID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017100959_logger_request_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_entries_request_id ON log_entries(request_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_log_entries_request_id;`,
+				`ALTER TABLE log_entries DROP COLUMN IF EXISTS request_id;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLRequestID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017100959_logger_request_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  ADD COLUMN request_id VARCHAR(128) NULL,
+  ADD INDEX idx_log_entries_request_id (request_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE log_entries
+  DROP INDEX idx_log_entries_request_id,
+  DROP COLUMN request_id;`,
+			)
+		},
+	}
+}
+
+// processedEventsBackfill remembers the events of the entries stored before the table existed
+const processedEventsBackfill = `INSERT INTO log_processed_events (event_id, processed_at)
+SELECT dedup_event_id, MIN(created_at) FROM log_entries
+WHERE dedup_event_id IS NOT NULL
+GROUP BY dedup_event_id;`
+
+func loggerSQLiteProcessedEvents() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017113243_logger_processed_events",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_processed_events (
+  event_id VARCHAR(255) PRIMARY KEY,
+  processed_at TIMESTAMP NOT NULL
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_processed_events_processed_at ON log_processed_events(processed_at);`,
+				processedEventsBackfill,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_processed_events;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresProcessedEvents() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017113243_logger_processed_events",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_processed_events (
+  event_id VARCHAR(255) PRIMARY KEY,
+  processed_at TIMESTAMP NOT NULL
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_log_processed_events_processed_at ON log_processed_events(processed_at);`,
+				processedEventsBackfill,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_processed_events;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLProcessedEvents() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017113243_logger_processed_events",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_processed_events (
+  event_id VARCHAR(255) NOT NULL PRIMARY KEY,
+  processed_at TIMESTAMP(6) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+				`CREATE INDEX idx_log_processed_events_processed_at ON log_processed_events(processed_at);`,
+				processedEventsBackfill,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_processed_events;`,
+			)
+		},
+	}
+}
+
+// loggerSQLiteErasedUsers records the erased users, the entries of restored archives are erased with them
+func loggerSQLiteErasedUsers() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017113748_logger_erased_users",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_erased_users (
+  subject VARCHAR(64) PRIMARY KEY,
+  mode VARCHAR(16) NOT NULL,
+  pseudonym VARCHAR(255),
+  erased_at TIMESTAMP NOT NULL
+);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_erased_users;`,
+			)
+		},
+	}
+}
+
+func loggerPostgresErasedUsers() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017113748_logger_erased_users",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_erased_users (
+  subject VARCHAR(64) PRIMARY KEY,
+  mode VARCHAR(16) NOT NULL,
+  pseudonym VARCHAR(255),
+  erased_at TIMESTAMP NOT NULL
+);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_erased_users;`,
+			)
+		},
+	}
+}
+
+func loggerMySQLErasedUsers() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017113748_logger_erased_users",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS log_erased_users (
+  subject VARCHAR(64) NOT NULL PRIMARY KEY,
+  mode VARCHAR(16) NOT NULL,
+  pseudonym VARCHAR(255) NULL,
+  erased_at TIMESTAMP(6) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS log_erased_users;`,
+			)
+		},
+	}
+}
+
+// logEntryBackfillBatchSize is the number of rows read and rewritten per backfill round
+const logEntryBackfillBatchSize = 500
+
+// backfillLogEntryStructuredColumns extracts the structured columns from the payload of every row
+func backfillLogEntryStructuredColumns(ctx context.Context, tx bun.Tx) error {
+	var lastID int64
+	for {
+		// Explicit columns, the LogEntry model may describe columns added by later migrations
+		var rows []struct {
+			ID      int64  `bun:"id"`
+			Details string `bun:"details"`
+		}
+		if err := tx.NewSelect().
+			Table("log_entries").
+			Column("id", "details").
+			Where("id > ?", lastID).
+			OrderExpr("id ASC").
+			Limit(logEntryBackfillBatchSize).
+			Scan(ctx, &rows); err != nil {
+			return fmt.Errorf("failed to read log entries for backfill: %w", err)
+		}
+
+		for _, row := range rows {
+			var entry types.LogEntry
+			entry.ApplyEventFields([]byte(row.Details), nil)
+
+			if _, err := tx.NewUpdate().
+				Table("log_entries").
+				Set("user_id = ?", entry.UserID).
+				Set("session_id = ?", entry.SessionID).
+				Set("ip_address = ?", entry.IPAddress).
+				Set("user_agent = ?", entry.UserAgent).
+				Set("details = ?", string(types.NormalizePayload([]byte(row.Details)))).
+				Where("id = ?", row.ID).
+				Exec(ctx); err != nil {
+				return fmt.Errorf("failed to backfill log entry %d: %w", row.ID, err)
+			}
+			lastID = row.ID
+		}
+
+		if len(rows) < logEntryBackfillBatchSize {
+			return nil
+		}
+	}
+}
+
+// backfillLogEntryHashChain seals the existing rows into the hash chain in insertion order
+func backfillLogEntryHashChain(ctx context.Context, tx bun.Tx) error {
+	head := types.ChainHead{ID: 1}
+	for {
+		var rows []struct {
+			ID        int64     `bun:"id"`
+			EventID   *string   `bun:"event_id"`
+			EventType string    `bun:"event_type"`
+			UserID    *string   `bun:"user_id"`
+			SessionID *string   `bun:"session_id"`
+			IPAddress *string   `bun:"ip_address"`
+			UserAgent *string   `bun:"user_agent"`
+			Details   string    `bun:"details"`
+			CreatedAt time.Time `bun:"created_at"`
+		}
+		if err := tx.NewSelect().
+			Table("log_entries").
+			Column("id", "event_id", "event_type", "user_id", "session_id", "ip_address", "user_agent", "details", "created_at").
+			Where("id > ?", head.EntryID).
+			OrderExpr("id ASC").
+			Limit(logEntryBackfillBatchSize).
+			Scan(ctx, &rows); err != nil {
+			return fmt.Errorf("failed to read log entries for hash chain backfill: %w", err)
+		}
+
+		for _, row := range rows {
+			entry := types.LogEntry{
+				EventID:   row.EventID,
+				EventType: row.EventType,
+				UserID:    row.UserID,
+				SessionID: row.SessionID,
+				IPAddress: row.IPAddress,
+				UserAgent: row.UserAgent,
+				Details:   json.RawMessage(row.Details),
+				CreatedAt: row.CreatedAt,
+			}
+			entry.Seal(head.Hash)
+
+			if _, err := tx.NewUpdate().
+				Table("log_entries").
+				Set("content_hash = ?", entry.ContentHash).
+				Set("prev_hash = ?", entry.PrevHash).
+				Set("hash = ?", entry.Hash).
+				Where("id = ?", row.ID).
+				Exec(ctx); err != nil {
+				return fmt.Errorf("failed to seal log entry %d: %w", row.ID, err)
+			}
+			head.EntryID = row.ID
+			head.Hash = *entry.Hash
+		}
+
+		if len(rows) < logEntryBackfillBatchSize {
+			break
+		}
+	}
+
+	head.UpdatedAt = time.Now().UTC()
+	if _, err := tx.NewInsert().Model(&head).Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create log chain head: %w", err)
+	}
+	return nil
+}
+
+// backfillLogStatsRollup counts the existing rows into the hourly stats rollup
+func backfillLogStatsRollup(ctx context.Context, tx bun.Tx) error {
+	var lastID int64
+	for {
+		var rows []struct {
+			ID        int64     `bun:"id"`
+			EventType string    `bun:"event_type"`
+			CreatedAt time.Time `bun:"created_at"`
+		}
+		if err := tx.NewSelect().
+			Table("log_entries").
+			Column("id", "event_type", "created_at").
+			Where("id > ?", lastID).
+			OrderExpr("id ASC").
+			Limit(logEntryBackfillBatchSize).
+			Scan(ctx, &rows); err != nil {
+			return fmt.Errorf("failed to read log entries for stats rollup backfill: %w", err)
+		}
+
+		counts := make(repositories.StatsRollupCounts)
+		for _, row := range rows {
+			counts.Add(row.EventType, row.CreatedAt)
+			lastID = row.ID
+		}
+		if err := repositories.UpsertStatsRollup(ctx, tx, counts); err != nil {
+			return err
+		}
+
+		if len(rows) < logEntryBackfillBatchSize {
+			return nil
+		}
+	}
+}
+
+// createLogEntryPartitions creates the partitions from the month of the oldest row on
+func createLogEntryPartitions(ctx context.Context, tx bun.Tx) error {
+	var oldest *time.Time
+	if err := tx.NewSelect().
+		Table("log_entries_unpartitioned").
+		ColumnExpr("MIN(created_at)").
+		Scan(ctx, &oldest); err != nil {
+		return fmt.Errorf("failed to read the oldest log entry: %w", err)
+	}
+
+	current := types.NewLogPartition(time.Now())
+	from := current
+	if oldest != nil && oldest.Before(current.From) {
+		from = types.NewLogPartition(*oldest)
+	}
+	to := types.NewLogPartition(current.From.AddDate(0, types.DefaultPartitionPremakeMonths, 0))
+	_, err := repositories.CreateLogPartitions(ctx, tx, from, to)
+	return err
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+)
+
+// PartitionMaintainer periodically creates and archives the monthly partitions of log_entries
+type PartitionMaintainer struct {
+	logger        models.Logger
+	partitions    services.PartitionService
+	loggerService services.LoggerService
+	interval      time.Duration
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+func NewPartitionMaintainer(logger models.Logger, partitions services.PartitionService, loggerService services.LoggerService, interval time.Duration) *PartitionMaintainer {
+	return &PartitionMaintainer{
+		logger:        logger,
+		partitions:    partitions,
+		loggerService: loggerService,
+		interval:      interval,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start runs a first maintenance right away and then one every interval until Close is called
+func (m *PartitionMaintainer) Start() {
+	go m.runMaintenanceLoop()
+}
+
+// Close stops the maintenance loop and waits for a running maintenance to finish
+func (m *PartitionMaintainer) Close() {
+	close(m.stop)
+	<-m.done
+}
+
+func (m *PartitionMaintainer) runMaintenanceLoop() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	defer close(m.done)
+
+	m.maintain()
+
+	for {
+		select {
+		case <-m.stop:
+			m.logger.Debug("log partition maintainer stopped")
+			return
+		case <-ticker.C:
+			m.maintain()
+		}
+	}
+}
+
+func (m *PartitionMaintainer) maintain() {
+	ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+	defer cancel()
+
+	// Close must not wait for a long archival, abort it instead
+	go func() {
+		select {
+		case <-m.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	result, err := m.partitions.MaintainPartitions(ctx)
+	if err != nil {
+		m.logger.Error("failed to maintain log partitions", "error", err)
+	}
+	if result == nil {
+		return
+	}
+	for _, partition := range result.Created {
+		m.logger.Info("created log partition", "partition", partition.Name)
+	}
+	for _, archived := range result.Archived {
+		m.logger.Info("archived log partition", "partition", archived.Partition.Name, "file", archived.File, "entries", archived.Entries)
+	}
+	if len(result.Archived) > 0 {
+		// The archived entries no longer count towards MaxLogCount
+		if _, err := m.loggerService.SyncLogCount(ctx); err != nil {
+			m.logger.Warn("failed to reconcile log count", "error", err)
+		}
+	}
+}
@@ -2,20 +2,46 @@ package logger
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 
+	"github.com/uptrace/bun/dialect"
+
+	"github.com/Authula/authula-playground/adminauth"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
 	"github.com/Authula/authula-playground/plugins/logger/repositories"
 	"github.com/Authula/authula-playground/plugins/logger/services"
 	"github.com/Authula/authula-playground/plugins/logger/types"
+	"github.com/Authula/authula-playground/requestid"
 	"github.com/Authula/authula/migrations"
 	"github.com/Authula/authula/models"
+	secondarystorageplugin "github.com/Authula/authula/plugins/secondary-storage"
+	coreservices "github.com/Authula/authula/services"
 )
 
 type LoggerPlugin struct {
-	config        types.LoggerPluginConfig
-	logger        models.Logger
-	ctx           *models.PluginContext
-	loggerService services.LoggerService
+	config            types.LoggerPluginConfig
+	logger            models.Logger
+	ctx               *models.PluginContext
+	adminChecker      *adminauth.Checker
+	loggerService     services.LoggerService
+	retentionPruner   *RetentionPruner
+	countReconciler   *LogCountReconciler
+	chainCheckpointer *ChainCheckpointer
+	logWriter         *LogWriter
+	logStream         *LogStream
+	sinkWorkers       []*SinkWorker
+	eventFilter       *EventFilter
+	subscriptions     map[string]models.SubscriptionID
+	// detectorSubscription is the failed sign-in subscription of the threat detector
+	detectorSubscription *models.SubscriptionID
+	// erasureSubscription erases the entries of deleted users
+	erasureSubscription *models.SubscriptionID
+	// partitionMaintainer keeps the monthly partitions of log_entries on Postgres
+	partitionMaintainer *PartitionMaintainer
 }
 
 func New(config types.LoggerPluginConfig) *LoggerPlugin {
@@ -24,7 +50,7 @@ func New(config types.LoggerPluginConfig) *LoggerPlugin {
 
 func (p *LoggerPlugin) Metadata() models.PluginMetadata {
 	return models.PluginMetadata{
-		ID:          "logger",
+		ID:          constants.PluginID,
 		Version:     "1.0.0",
 		Description: "Logs user authentication events to the database",
 	}
@@ -35,6 +61,8 @@ func (p *LoggerPlugin) Config() any {
 }
 
 func (p *LoggerPlugin) Init(ctx *models.PluginContext) error {
+	// Only the events published by the plugin carry the request ID, every plugin gets its own context
+	ctx.EventBus = requestid.WrapEventBus(ctx.EventBus)
 	p.ctx = ctx
 	p.logger = ctx.Logger
 
@@ -42,10 +70,71 @@ func (p *LoggerPlugin) Init(ctx *models.PluginContext) error {
 		return fmt.Errorf("invalid logger plugin configuration: %w", err)
 	}
 
-	p.loggerService = services.NewService(repositories.NewBunLoggerRepository(ctx.DB), p.logger, p.config)
+	p.adminChecker = adminauth.NewChecker(p.config.Admins(), ctx.ServiceRegistry, p.logger)
+	if p.config.Admins().IsEmpty() {
+		p.logger.Warn("no logger admins are configured, the admin routes will reject every request")
+	}
+
+	redactor, err := services.NewRedactor(p.config.EffectiveRedactionRules(), p.config.RedactionHMACKey)
+	if err != nil {
+		return fmt.Errorf("invalid logger plugin configuration: %w", err)
+	}
+
+	repo := repositories.NewBunLoggerRepository(ctx.DB)
+	erasers := services.NewUserDataErasers()
+	p.loggerService = services.NewService(repo, p.newLogCounter(repo), redactor, erasers, ctx.EventBus, p.logger, p.config)
+
+	// Plugins initialized after the logger redact what they keep of events and erase it with the logs
+	ctx.ServiceRegistry.Register(constants.ServiceRedactor, redactor)
+	ctx.ServiceRegistry.Register(constants.ServiceUserDataErasers, erasers)
+
+	p.logSearchBackend(repo)
+
+	// Seed the counter so the count survives restarts and includes logs written by other replicas
+	if _, err := p.loggerService.SyncLogCount(context.Background()); err != nil {
+		p.logger.Error("failed to seed log count", "error", err)
+	}
+
+	if err := p.startSinkWorkers(); err != nil {
+		return err
+	}
 
+	p.logStream = NewLogStream(p.config.StreamClientBufferSize)
+	p.eventFilter = NewEventFilter(p.config)
+	p.logWriter = NewLogWriter(p.logger, p.loggerService, p.logStream, p.eventFilter, p.sinkWorkers, p.config)
+	p.logWriter.Start()
 	p.subscribeToEvents()
 
+	if p.config.Detection.Enabled {
+		p.subscribeThreatDetector(repo)
+	}
+
+	if !p.config.DisableErasureOnUserDeleted {
+		p.subscribeUserErasure()
+	}
+
+	// Only the shared count can drift from the table, the database counter counts it on every read
+	if p.sharedSecondaryStorage() != nil {
+		p.countReconciler = NewLogCountReconciler(p.logger, p.loggerService, p.config.LogCountReconcileInterval)
+		p.countReconciler.Start()
+	}
+
+	// Always started, the processed events expire whatever the retention of the entries
+	p.retentionPruner = NewRetentionPruner(p.logger, p.loggerService, p.config)
+	p.retentionPruner.Start()
+
+	if p.config.HasChainCheckpoints() {
+		p.chainCheckpointer = NewChainCheckpointer(p.logger, p.loggerService, p.config.ChainCheckpointInterval)
+		p.chainCheckpointer.Start()
+	}
+
+	// Only the Postgres migrations partition log_entries
+	if ctx.DB.Dialect().Name() == dialect.PG {
+		partitions := services.NewPartitionService(repositories.NewBunPartitionRepository(ctx.DB), p.config.Partitions)
+		p.partitionMaintainer = NewPartitionMaintainer(p.logger, partitions, p.loggerService, p.config.Partitions.MaintenanceInterval)
+		p.partitionMaintainer.Start()
+	}
+
 	return nil
 }
 
@@ -56,13 +145,58 @@ func (p *LoggerPlugin) Routes() []models.Route {
 
 	logger := p.ctx.Logger
 
-	return Routes(logger, p.loggerService)
+	return Routes(logger, p.loggerService, p.eventFilter, p.logStream, p.ctx.GetConfig, p.config)
 }
 
 func (p *LoggerPlugin) Close() error {
+	for topic, id := range p.subscriptions {
+		p.ctx.EventBus.Unsubscribe(topic, id)
+	}
+	if p.detectorSubscription != nil {
+		p.ctx.EventBus.Unsubscribe(constants.EventUserSignInFailed, *p.detectorSubscription)
+	}
+	if p.erasureSubscription != nil {
+		p.ctx.EventBus.Unsubscribe(constants.EventUserDeleted, *p.erasureSubscription)
+	}
+	// Flush the queued events before the pruner goes away
+	if p.logWriter != nil {
+		p.logWriter.Close()
+	}
+	// The writer no longer hands out entries, let the sinks write what they have queued
+	for _, worker := range p.sinkWorkers {
+		worker.Close()
+	}
+	// Disconnect the live tail clients once the last entries were published to them
+	if p.logStream != nil {
+		p.logStream.Close()
+	}
+	if p.retentionPruner != nil {
+		p.retentionPruner.Close()
+	}
+	if p.countReconciler != nil {
+		p.countReconciler.Close()
+	}
+	if p.chainCheckpointer != nil {
+		p.chainCheckpointer.Close()
+	}
+	if p.partitionMaintainer != nil {
+		p.partitionMaintainer.Close()
+	}
 	return nil
 }
 
+// Middleware publishes the failed sign-ins and deleted users that Authula does not publish itself
+func (p *LoggerPlugin) Middleware() []func(http.Handler) http.Handler {
+	var middleware []func(http.Handler) http.Handler
+	if p.config.Detection.Enabled {
+		middleware = append(middleware, p.monitorSignIns)
+	}
+	if !p.config.DisableErasureOnUserDeleted {
+		middleware = append(middleware, p.monitorUserDeletions)
+	}
+	return middleware
+}
+
 func (p *LoggerPlugin) Migrations(provider string) []migrations.Migration {
 	return loggerMigrations(provider)
 }
@@ -71,15 +205,153 @@ func (p *LoggerPlugin) DependsOn() []string {
 	return nil
 }
 
-func (p *LoggerPlugin) subscribeToEvents() {
-	_, err := p.ctx.EventBus.Subscribe(models.EventTypeWildcard, func(ctx context.Context, event models.Event) error {
-		if _, err := p.loggerService.CreateLogEntry(ctx, event.Type, string(event.Payload)); err != nil {
-			p.logger.Error("failed to create log entry for user sign up event", "error", err)
+// logSearchBackend logs once how the entries are searched, SQLite silently scans the table without FTS5
+func (p *LoggerPlugin) logSearchBackend(repo *repositories.BunLoggerRepository) {
+	backend, err := repo.SearchBackend(context.Background())
+	switch {
+	case err != nil:
+		p.logger.Error("failed to look up the log search backend", "error", err)
+	case backend == repositories.SearchBackendSQLiteLike:
+		p.logger.Warn("SQLite was built without FTS5, log searches scan the table, build with -tags sqlite_fts5 to index them", "backend", backend)
+	default:
+		p.logger.Info("log search backend", "backend", backend)
+	}
+}
+
+// sharedSecondaryStorage returns the secondary storage unless it is the in-memory one
+func (p *LoggerPlugin) sharedSecondaryStorage() models.SecondaryStorage {
+	storageService, ok := p.ctx.ServiceRegistry.Get(models.ServiceSecondaryStorage.String()).(coreservices.SecondaryStorageService)
+	if ok && storageService.GetStorage() != nil &&
+		storageService.GetProviderName() != secondarystorageplugin.SecondaryStorageProviderMemory.String() {
+		return storageService.GetStorage()
+	}
+	return nil
+}
+
+// newLogCounter shares the log count through the secondary storage or counts the database rows
+func (p *LoggerPlugin) newLogCounter(repo repositories.LoggerRepository) services.LogCounter {
+	if storage := p.sharedSecondaryStorage(); storage != nil {
+		return services.NewSecondaryStorageLogCounter(storage)
+	}
+	return services.NewDatabaseLogCounter(repo)
+}
+
+// subscribeThreatDetector feeds the failed sign-ins to the threat detector
+func (p *LoggerPlugin) subscribeThreatDetector(repo repositories.LoggerRepository) {
+	store := services.NewMemoryDetectionStore()
+	if storage := p.sharedSecondaryStorage(); storage != nil {
+		store = services.NewSecondaryStorageDetectionStore(storage)
+	}
+	detector := services.NewThreatDetector(store, repo, p.ctx.EventBus, p.logger, p.config.Detection)
+
+	id, err := p.ctx.EventBus.Subscribe(constants.EventUserSignInFailed, requestid.HandleEvents(detector.HandleEvent))
+	if err != nil {
+		p.logger.Error("failed to subscribe the threat detector", "event", constants.EventUserSignInFailed, "error", err)
+		return
+	}
+	p.detectorSubscription = &id
+}
+
+// subscribeUserErasure erases the log entries of the users deleted through the admin plugin route
+func (p *LoggerPlugin) subscribeUserErasure() {
+	handler := func(ctx context.Context, event models.Event) error {
+		var deleted types.LogEntry
+		deleted.ApplyEventFields(event.Payload, event.Metadata)
+		if deleted.UserID == nil {
+			p.logger.Warn("user deleted event has no user id", "event_id", event.ID)
+			return nil
+		}
+
+		var payload struct {
+			DeletedBy string `json:"deleted_by"`
+		}
+		_ = json.Unmarshal(event.Payload, &payload)
+		requestedBy := constants.EventUserDeleted
+		if payload.DeletedBy != "" {
+			requestedBy = "admin:" + payload.DeletedBy
+		}
+
+		if _, err := p.loggerService.EraseUserLogs(ctx, types.ErasureRequest{
+			UserID:      *deleted.UserID,
+			RequestedBy: requestedBy,
+			EventID:     event.ID,
+		}); err != nil {
+			p.logger.Error("failed to erase the log entries of a deleted user", "event_id", event.ID, "error", err)
+			return err
 		}
 		return nil
-	})
+	}
+
+	id, err := p.ctx.EventBus.Subscribe(constants.EventUserDeleted, requestid.HandleEvents(handler))
 	if err != nil {
-		p.logger.Error("failed to subscribe to event", "event", models.EventTypeWildcard, "error", err)
+		p.logger.Error("failed to subscribe the user erasure", "event", constants.EventUserDeleted, "error", err)
 		return
 	}
+	p.erasureSubscription = &id
+}
+
+// startSinkWorkers creates the configured sinks, each fed by its own worker
+func (p *LoggerPlugin) startSinkWorkers() error {
+	for _, config := range p.config.Sinks {
+		sink, err := services.NewLogSink(config, p.logger)
+		if err != nil {
+			return fmt.Errorf("invalid log sink %q: %w", config.Name, err)
+		}
+		worker := NewSinkWorker(p.logger, config, sink)
+		worker.Start()
+		p.sinkWorkers = append(p.sinkWorkers, worker)
+	}
+	return nil
+}
+
+// subscribeToEvents subscribes the log writer to the topics of the event filter and of the sinks
+func (p *LoggerPlugin) subscribeToEvents() {
+	handler := func(ctx context.Context, event models.Event) error {
+		// The erasure receipt records the deletion, logging the event would store the user ID again
+		if event.Type == constants.EventUserDeleted && !p.config.DisableErasureOnUserDeleted {
+			return nil
+		}
+		err := p.logWriter.Write(ctx, event)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, constants.ErrLogWriterClosed) {
+			p.logger.Error("failed to write log entry", "event_id", event.ID, "event_type", event.Type, "error", err)
+		}
+		if p.config.AckMode == types.AckModeStored {
+			return err
+		}
+		return nil
+	}
+
+	topics := p.eventFilter.Topics()
+	for _, worker := range p.sinkWorkers {
+		topics = mergeTopics(topics, worker.Topics())
+	}
+	if len(topics) == 0 {
+		p.logger.Warn("every included event type is excluded, no events will be logged")
+	}
+
+	p.subscriptions = make(map[string]models.SubscriptionID, len(topics))
+	for _, topic := range topics {
+		id, err := p.ctx.EventBus.Subscribe(topic, handler)
+		if err != nil {
+			p.logger.Error("failed to subscribe to event", "event", topic, "error", err)
+			continue
+		}
+		p.subscriptions[topic] = id
+	}
+}
+
+// mergeTopics returns the topics of both lists, or only the wildcard topic when either has it
+func mergeTopics(topics []string, more []string) []string {
+	if slices.Contains(topics, models.EventTypeWildcard) || slices.Contains(more, models.EventTypeWildcard) {
+		return []string{models.EventTypeWildcard}
+	}
+	for _, topic := range more {
+		if !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
 }
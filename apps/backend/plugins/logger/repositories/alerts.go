@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// CreateSecurityAlert stores a new security alert and fills in its ID
+func (r *BunLoggerRepository) CreateSecurityAlert(ctx context.Context, alert *types.SecurityAlert) error {
+	if _, err := r.db.NewInsert().Model(alert).Returning("id").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create security alert: %w", err)
+	}
+	return nil
+}
+
+// ListSecurityAlerts retrieves a single page of security alerts, newest first
+func (r *BunLoggerRepository) ListSecurityAlerts(ctx context.Context, query types.SecurityAlertQuery) ([]types.SecurityAlert, *string, error) {
+	selectQuery := r.db.NewSelect().
+		Model((*types.SecurityAlert)(nil)).
+		OrderExpr("id DESC").
+		Limit(query.Limit + 1)
+
+	switch query.Status {
+	case types.SecurityAlertStatusOpen:
+		selectQuery = selectQuery.Where("acknowledged_at IS NULL")
+	case types.SecurityAlertStatusAcknowledged:
+		selectQuery = selectQuery.Where("acknowledged_at IS NOT NULL")
+	}
+
+	if query.Cursor != nil && strings.TrimSpace(*query.Cursor) != "" {
+		id, err := pagination.DecodeIDCursor(strings.TrimSpace(*query.Cursor))
+		if err != nil {
+			return nil, nil, err
+		}
+		selectQuery = selectQuery.Where("id < ?", id)
+	}
+
+	var alerts []types.SecurityAlert
+	if err := selectQuery.Scan(ctx, &alerts); err != nil {
+		return nil, nil, fmt.Errorf("failed to list security alerts: %w", err)
+	}
+
+	if alerts == nil {
+		alerts = []types.SecurityAlert{}
+	}
+
+	if len(alerts) <= query.Limit {
+		return alerts, nil, nil
+	}
+
+	next := pagination.EncodeIDCursor(alerts[query.Limit-1].ID)
+	return alerts[:query.Limit], &next, nil
+}
+
+// AcknowledgeSecurityAlert marks an alert as reviewed, keeping the first acknowledgement
+func (r *BunLoggerRepository) AcknowledgeSecurityAlert(ctx context.Context, id int64, userID string, at time.Time) (*types.SecurityAlert, error) {
+	if _, err := r.db.NewUpdate().
+		Model((*types.SecurityAlert)(nil)).
+		Set("acknowledged_at = ?", at.UTC()).
+		Set("acknowledged_by = ?", userID).
+		Where("id = ?", id).
+		Where("acknowledged_at IS NULL").
+		Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to acknowledge security alert: %w", err)
+	}
+
+	alert := new(types.SecurityAlert)
+	if err := r.db.NewSelect().Model(alert).Where("id = ?", id).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, constants.ErrSecurityAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to get security alert: %w", err)
+	}
+	return alert, nil
+}
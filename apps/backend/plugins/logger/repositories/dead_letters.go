@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// CreateDeadLetters stores the dead letters with a single insert statement
+func (r *BunLoggerRepository) CreateDeadLetters(ctx context.Context, deadLetters []*types.DeadLetter) error {
+	if len(deadLetters) == 0 {
+		return nil
+	}
+	if _, err := r.db.NewInsert().Model(&deadLetters).Returning("id").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create dead letters: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters retrieves a single page of dead letters, newest first
+func (r *BunLoggerRepository) ListDeadLetters(ctx context.Context, query types.DeadLetterQuery) ([]types.DeadLetter, *string, error) {
+	selectQuery := r.db.NewSelect().
+		Model((*types.DeadLetter)(nil)).
+		OrderExpr("id DESC").
+		Limit(query.Limit + 1)
+
+	if query.Cursor != nil && strings.TrimSpace(*query.Cursor) != "" {
+		id, err := pagination.DecodeIDCursor(strings.TrimSpace(*query.Cursor))
+		if err != nil {
+			return nil, nil, err
+		}
+		selectQuery = selectQuery.Where("id < ?", id)
+	}
+
+	var deadLetters []types.DeadLetter
+	if err := selectQuery.Scan(ctx, &deadLetters); err != nil {
+		return nil, nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	if deadLetters == nil {
+		deadLetters = []types.DeadLetter{}
+	}
+
+	if len(deadLetters) <= query.Limit {
+		return deadLetters, nil, nil
+	}
+
+	next := pagination.EncodeIDCursor(deadLetters[query.Limit-1].ID)
+	return deadLetters[:query.Limit], &next, nil
+}
+
+// GetDeadLetter retrieves a dead letter by ID
+func (r *BunLoggerRepository) GetDeadLetter(ctx context.Context, id int64) (*types.DeadLetter, error) {
+	deadLetter := new(types.DeadLetter)
+	if err := r.db.NewSelect().Model(deadLetter).Where("id = ?", id).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, constants.ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return deadLetter, nil
+}
+
+// RecordDeadLetterAttempt records a replay that failed again
+func (r *BunLoggerRepository) RecordDeadLetterAttempt(ctx context.Context, id int64, lastError string, at time.Time) error {
+	if _, err := r.db.NewUpdate().
+		Model((*types.DeadLetter)(nil)).
+		Set("attempts = attempts + 1").
+		Set("last_error = ?", lastError).
+		Set("last_attempt_at = ?", at.UTC()).
+		Where("id = ?", id).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update dead letter: %w", err)
+	}
+	return nil
+}
+
+// DeleteDeadLetter deletes a dead letter, it returns ErrDeadLetterNotFound when it no longer exists
+func (r *BunLoggerRepository) DeleteDeadLetter(ctx context.Context, id int64) error {
+	result, err := r.db.NewDelete().
+		Model((*types.DeadLetter)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return constants.ErrDeadLetterNotFound
+	}
+	return nil
+}
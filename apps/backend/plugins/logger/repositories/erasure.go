@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/feature"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// EraseUserEntries erases up to limit entries of the user, keeping their hashes as tombstones
+func (r *BunLoggerRepository) EraseUserEntries(ctx context.Context, userID string, mode types.ErasureMode, pseudonym string, at time.Time, limit int) (int64, error) {
+	return r.eraseEntries(ctx, func(qb bun.QueryBuilder) bun.QueryBuilder {
+		return qb.Where("user_id = ?", userID)
+	}, mode, pseudonym, at, limit)
+}
+
+// CreateErasedUser records an erased user, replacing the record of an earlier erasure
+func (r *BunLoggerRepository) CreateErasedUser(ctx context.Context, user *types.ErasedUser) error {
+	query := r.db.NewInsert().Model(user)
+	if r.db.Dialect().Name() == dialect.MySQL {
+		query = query.On("DUPLICATE KEY UPDATE").
+			Set("mode = VALUES(mode)").
+			Set("pseudonym = VALUES(pseudonym)").
+			Set("erased_at = VALUES(erased_at)")
+	} else {
+		query = query.On("CONFLICT (subject) DO UPDATE").
+			Set("mode = EXCLUDED.mode").
+			Set("pseudonym = EXCLUDED.pseudonym").
+			Set("erased_at = EXCLUDED.erased_at")
+	}
+	if _, err := query.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record erased user: %w", err)
+	}
+	return nil
+}
+
+// EraseMatching deletes up to limit of the oldest entries matching the filter, keeping tombstones
+func (r *BunLoggerRepository) EraseMatching(ctx context.Context, filter types.LogEntryFilter, at time.Time, limit int) (int64, error) {
+	return r.eraseEntries(ctx, applyLogEntryFilter(filter), types.ErasureModeDelete, "", at, limit)
+}
+
+// eraseEntries erases up to limit of the oldest entries selected by where in one transaction
+func (r *BunLoggerRepository) eraseEntries(ctx context.Context, where func(bun.QueryBuilder) bun.QueryBuilder, mode types.ErasureMode, pseudonym string, at time.Time, limit int) (int64, error) {
+	var erased int64
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var entries []types.LogEntry
+		if err := tx.NewSelect().
+			Model(&entries).
+			Column("id", "content_hash", "prev_hash", "hash").
+			ApplyQueryBuilder(where).
+			OrderExpr("id ASC").
+			Limit(limit).
+			Scan(ctx); err != nil {
+			return fmt.Errorf("failed to read log entries to erase: %w", err)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, 0, len(entries))
+		tombstones := make([]types.ErasedEntry, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+			tombstones = append(tombstones, types.ErasedEntry{
+				EntryID:     entry.ID,
+				ContentHash: entry.ContentHash,
+				PrevHash:    entry.PrevHash,
+				Hash:        entry.Hash,
+				Mode:        mode,
+				ErasedAt:    at.UTC(),
+			})
+		}
+		// An anonymized entry that is deleted later already has a tombstone with the same hashes
+		query := tx.NewInsert().Model(&tombstones)
+		if tx.Dialect().Name() == dialect.MySQL {
+			query = query.On("DUPLICATE KEY UPDATE").
+				Set("mode = VALUES(mode)").
+				Set("erased_at = VALUES(erased_at)")
+		} else {
+			query = query.On("CONFLICT (entry_id) DO UPDATE").
+				Set("mode = EXCLUDED.mode").
+				Set("erased_at = EXCLUDED.erased_at")
+		}
+		if _, err := query.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to create log entry tombstones: %w", err)
+		}
+
+		var err error
+		switch mode {
+		case types.ErasureModeDelete:
+			_, err = tx.NewDelete().
+				Model((*types.LogEntry)(nil)).
+				Where("id IN (?)", bun.In(ids)).
+				Exec(ctx)
+		default:
+			_, err = tx.NewUpdate().
+				Model((*types.LogEntry)(nil)).
+				Set("user_id = ?", pseudonym).
+				Set("session_id = NULL").
+				Set("ip_address = NULL").
+				Set("user_agent = NULL").
+				Set("details = ?", "{}").
+				Where("id IN (?)", bun.In(ids)).
+				Exec(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to erase log entries: %w", err)
+		}
+		erased = int64(len(entries))
+		return nil
+	})
+	return erased, err
+}
+
+// ListErasedEntries retrieves the tombstones after afterID up to upToID, or all when it is nil
+func (r *BunLoggerRepository) ListErasedEntries(ctx context.Context, afterID int64, upToID *int64) ([]types.ErasedEntry, error) {
+	query := r.db.NewSelect().
+		Model((*types.ErasedEntry)(nil)).
+		Where("entry_id > ?", afterID).
+		OrderExpr("entry_id ASC")
+	if upToID != nil {
+		query = query.Where("entry_id <= ?", *upToID)
+	}
+
+	var tombstones []types.ErasedEntry
+	if err := query.Scan(ctx, &tombstones); err != nil {
+		return nil, fmt.Errorf("failed to list log entry tombstones: %w", err)
+	}
+	return tombstones, nil
+}
+
+// DeleteErasedEntriesUpTo deletes at most limit of the oldest tombstones whose entry ID is at most maxEntryID
+func (r *BunLoggerRepository) DeleteErasedEntriesUpTo(ctx context.Context, maxEntryID int64, limit int) (int64, error) {
+	var (
+		result sql.Result
+		err    error
+	)
+	if r.db.Dialect().Features().Has(feature.DeleteOrderLimit) {
+		result, err = r.db.NewDelete().
+			Model((*types.ErasedEntry)(nil)).
+			Where("entry_id <= ?", maxEntryID).
+			OrderExpr("entry_id ASC").
+			Limit(limit).
+			Exec(ctx)
+	} else {
+		subquery := r.db.NewSelect().
+			Model((*types.ErasedEntry)(nil)).
+			Column("entry_id").
+			Where("entry_id <= ?", maxEntryID).
+			OrderExpr("entry_id ASC").
+			Limit(limit)
+		result, err = r.db.NewDelete().
+			Model((*types.ErasedEntry)(nil)).
+			Where("entry_id IN (?)", subquery).
+			Exec(ctx)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete log entry tombstones: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted log entry tombstones: %w", err)
+	}
+	return deleted, nil
+}
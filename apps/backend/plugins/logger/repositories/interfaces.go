@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/Authula/authula-playground/plugins/logger/types"
 )
@@ -9,9 +10,48 @@ import (
 // LoggerRepository defines the interface for log entry persistence
 type LoggerRepository interface {
 	Create(ctx context.Context, entry *types.LogEntry) error
+	CreateBatch(ctx context.Context, entries []*types.LogEntry) ([]*types.LogEntry, error)
 	GetByID(ctx context.Context, id int64) (*types.LogEntry, error)
 	GetAll(ctx context.Context) ([]types.LogEntry, error)
-	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context, query types.LogEntryQuery) ([]types.LogEntry, *string, error)
+	Search(ctx context.Context, terms []string, query types.LogEntryQuery) ([]types.LogEntry, *string, error)
+	Stream(ctx context.Context, filter types.LogEntryFilter, order types.SortOrder, fn func(entry *types.LogEntry) error) error
+	Delete(ctx context.Context, id int64, at time.Time) error
+	GetPruneCutoffID(ctx context.Context, keep int) (*int64, error)
+	DeleteOldestUpTo(ctx context.Context, maxID int64, limit int) (int64, error)
+	CountMatching(ctx context.Context, filter types.LogEntryFilter) (int64, error)
+	ListChain(ctx context.Context, afterID int64, limit int) ([]types.LogEntry, error)
+	GetChainHead(ctx context.Context) (*types.ChainHead, error)
+	CreateChainCheckpoint(ctx context.Context, checkpoint *types.ChainCheckpoint) error
+	GetLatestChainCheckpoint(ctx context.Context) (*types.ChainCheckpoint, error)
+	ListChainCheckpoints(ctx context.Context) ([]types.ChainCheckpoint, error)
+	CountByBucket(ctx context.Context, query types.StatsQuery) ([]types.StatsCount, error)
+	CountRollupByBucket(ctx context.Context, query types.StatsQuery) ([]types.StatsCount, error)
+	CreateSecurityAlert(ctx context.Context, alert *types.SecurityAlert) error
+	ListSecurityAlerts(ctx context.Context, query types.SecurityAlertQuery) ([]types.SecurityAlert, *string, error)
+	AcknowledgeSecurityAlert(ctx context.Context, id int64, userID string, at time.Time) (*types.SecurityAlert, error)
+	EraseUserEntries(ctx context.Context, userID string, mode types.ErasureMode, pseudonym string, at time.Time, limit int) (int64, error)
+	CreateErasedUser(ctx context.Context, user *types.ErasedUser) error
+	EraseMatching(ctx context.Context, filter types.LogEntryFilter, at time.Time, limit int) (int64, error)
+	ListErasedEntries(ctx context.Context, afterID int64, upToID *int64) ([]types.ErasedEntry, error)
+	DeleteErasedEntriesUpTo(ctx context.Context, maxEntryID int64, limit int) (int64, error)
+	CreateDeadLetters(ctx context.Context, deadLetters []*types.DeadLetter) error
+	ListDeadLetters(ctx context.Context, query types.DeadLetterQuery) ([]types.DeadLetter, *string, error)
+	GetDeadLetter(ctx context.Context, id int64) (*types.DeadLetter, error)
+	RecordDeadLetterAttempt(ctx context.Context, id int64, lastError string, at time.Time) error
+	DeleteDeadLetter(ctx context.Context, id int64) error
+	DeleteProcessedEventsBefore(ctx context.Context, before time.Time, limit int) (int64, error)
 	Count(ctx context.Context) (int, error)
 	Close() error
 }
+
+// PartitionRepository defines the interface for the monthly partitions of log_entries on Postgres
+type PartitionRepository interface {
+	ListPartitions(ctx context.Context) ([]types.LogPartition, error)
+	CreatePartition(ctx context.Context, partition types.LogPartition) (bool, error)
+	StreamPartition(ctx context.Context, partition types.LogPartition, fn func(entry *types.LogEntry) error) error
+	DropPartition(ctx context.Context, partition types.LogPartition, entries int64) error
+	RestorePartition(ctx context.Context, partition types.LogPartition, read func(insert func(entries []types.LogEntry, tombstones []types.ErasedEntry) error) error) (int64, error)
+	ListTombstones(ctx context.Context, entryIDs []int64) ([]types.ErasedEntry, error)
+	ListErasedUsers(ctx context.Context, subjects []string) ([]types.ErasedUser, error)
+}
@@ -0,0 +1,711 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// MemoryLoggerRepository implements LoggerRepository in memory, like BunLoggerRepository
+type MemoryLoggerRepository struct {
+	mu sync.Mutex
+	// entries and erased are kept ordered by entry ID
+	entries     []types.LogEntry
+	erased      []types.ErasedEntry
+	head        types.ChainHead
+	checkpoints []types.ChainCheckpoint
+	rollup      StatsRollupCounts
+	alerts      []types.SecurityAlert
+	deadLetters []types.DeadLetter
+	// erasedUsers maps the subjects of the erased users to their records
+	erasedUsers map[string]types.ErasedUser
+	// processed maps the event ids of the stored entries to the time they were processed
+	processed map[string]time.Time
+	// lastIDs holds the last ID handed out per table, IDs are never reused like autoincrement IDs
+	lastIDs map[string]int64
+}
+
+// NewMemoryLoggerRepository creates an empty in-memory repository
+func NewMemoryLoggerRepository() *MemoryLoggerRepository {
+	return &MemoryLoggerRepository{
+		head:        types.ChainHead{ID: chainHeadID},
+		rollup:      make(StatsRollupCounts),
+		processed:   make(map[string]time.Time),
+		erasedUsers: make(map[string]types.ErasedUser),
+		lastIDs:     make(map[string]int64),
+	}
+}
+
+// nextID returns the next ID of the table, the caller holds the lock
+func (r *MemoryLoggerRepository) nextID(table string) int64 {
+	r.lastIDs[table]++
+	return r.lastIDs[table]
+}
+
+// Create saves a new log entry, it returns ErrDuplicateLogEntry for an event already stored
+func (r *MemoryLoggerRepository) Create(ctx context.Context, entry *types.LogEntry) error {
+	created, err := r.CreateBatch(ctx, []*types.LogEntry{entry})
+	if err != nil {
+		return err
+	}
+	if len(created) == 0 {
+		return constants.ErrDuplicateLogEntry
+	}
+	return nil
+}
+
+// CreateBatch seals the entries into the hash chain and stores those of new events
+func (r *MemoryLoggerRepository) CreateBatch(ctx context.Context, entries []*types.LogEntry) ([]*types.LogEntry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]bool, len(r.entries)+len(r.processed))
+	for eventID := range r.processed {
+		seen[eventID] = true
+	}
+	for _, entry := range r.entries {
+		if entry.DedupEventID != nil {
+			seen[*entry.DedupEventID] = true
+		}
+	}
+	unique := make([]*types.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.EventID != nil {
+			if seen[*entry.EventID] {
+				continue
+			}
+			seen[*entry.EventID] = true
+			entry.DedupEventID = entry.EventID
+		}
+		unique = append(unique, entry)
+	}
+	if len(unique) == 0 {
+		return unique, nil
+	}
+
+	for _, entry := range unique {
+		// The column default of the database
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		}
+		entry.ID = r.nextID("log_entries")
+		entry.Seal(r.head.Hash)
+
+		stored := *entry
+		stored.Details = slices.Clone(entry.Details)
+		r.entries = append(r.entries, stored)
+		r.rollup.Add(entry.EventType, entry.CreatedAt)
+		if entry.EventID != nil {
+			r.processed[*entry.EventID] = time.Now().UTC()
+		}
+
+		r.head.EntryID = entry.ID
+		r.head.Hash = *entry.Hash
+	}
+	r.head.UpdatedAt = time.Now().UTC()
+	return unique, nil
+}
+
+// indexOf returns the position of the entry with the ID, the caller holds the lock
+func (r *MemoryLoggerRepository) indexOf(id int64) (int, bool) {
+	return slices.BinarySearchFunc(r.entries, id, func(entry types.LogEntry, id int64) int {
+		return cmp.Compare(entry.ID, id)
+	})
+}
+
+// GetByID retrieves a log entry by ID
+func (r *MemoryLoggerRepository) GetByID(ctx context.Context, id int64) (*types.LogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, found := r.indexOf(id)
+	if !found {
+		return nil, constants.ErrLogEntryNotFound
+	}
+	entry := r.entries[i]
+	return &entry, nil
+}
+
+// GetAll retrieves all log entries, newest first
+func (r *MemoryLoggerRepository) GetAll(ctx context.Context) ([]types.LogEntry, error) {
+	return r.matching(types.LogEntryFilter{}, types.SortOrderDesc, nil), nil
+}
+
+// matching returns the entries matching the filter and match, ordered by creation time
+func (r *MemoryLoggerRepository) matching(filter types.LogEntryFilter, order types.SortOrder, match func(entry *types.LogEntry) bool) []types.LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := []types.LogEntry{}
+	for i := range r.entries {
+		if matchesFilter(filter, &r.entries[i]) && (match == nil || match(&r.entries[i])) {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	slices.SortFunc(entries, func(a, b types.LogEntry) int {
+		c := cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+		if order == types.SortOrderDesc {
+			return -c
+		}
+		return c
+	})
+	return entries
+}
+
+// List retrieves a single page of log entries matching the query, ordered by creation time
+func (r *MemoryLoggerRepository) List(ctx context.Context, query types.LogEntryQuery) ([]types.LogEntry, *string, error) {
+	return r.list(query, nil)
+}
+
+// Search retrieves a page of the entries matching every term as a case-insensitive substring
+func (r *MemoryLoggerRepository) Search(ctx context.Context, terms []string, query types.LogEntryQuery) ([]types.LogEntry, *string, error) {
+	return r.list(query, func(entry *types.LogEntry) bool {
+		document := strings.ToLower(strings.Join([]string{
+			entry.EventType,
+			optionalString(entry.UserID),
+			optionalString(entry.IPAddress),
+			optionalString(entry.UserAgent),
+			string(entry.Details),
+		}, " "))
+		for _, term := range terms {
+			if !strings.Contains(document, strings.ToLower(term)) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// list retrieves a single page of log entries matching the query and match
+func (r *MemoryLoggerRepository) list(query types.LogEntryQuery, match func(entry *types.LogEntry) bool) ([]types.LogEntry, *string, error) {
+	order := types.SortOrderDesc
+	if query.Order == types.SortOrderAsc {
+		order = types.SortOrderAsc
+	}
+	entries := r.matching(query.Filter, order, match)
+
+	if query.Cursor != nil && strings.TrimSpace(*query.Cursor) != "" {
+		createdAt, id, err := decodeCursor(strings.TrimSpace(*query.Cursor))
+		if err != nil {
+			return nil, nil, err
+		}
+		start := 0
+		for start < len(entries) {
+			c := cmp.Or(entries[start].CreatedAt.Compare(createdAt), cmp.Compare(entries[start].ID, id))
+			if (order == types.SortOrderAsc && c > 0) || (order == types.SortOrderDesc && c < 0) {
+				break
+			}
+			start++
+		}
+		entries = entries[start:]
+	}
+
+	if len(entries) <= query.Limit {
+		return entries, nil, nil
+	}
+
+	next := encodeCursor(entries[query.Limit-1])
+	return entries[:query.Limit], &next, nil
+}
+
+// Stream calls fn for every log entry matching the filter in creation order
+func (r *MemoryLoggerRepository) Stream(ctx context.Context, filter types.LogEntryFilter, order types.SortOrder, fn func(entry *types.LogEntry) error) error {
+	if order != types.SortOrderDesc {
+		order = types.SortOrderAsc
+	}
+	// fn runs without the lock, it may call back into the repository
+	for _, entry := range r.matching(filter, order, nil) {
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a log entry by ID and keeps its hashes as a tombstone
+func (r *MemoryLoggerRepository) Delete(ctx context.Context, id int64, at time.Time) error {
+	deleted := r.eraseEntries(func(entry *types.LogEntry) bool {
+		return entry.ID == id
+	}, types.ErasureModeDelete, "", at, 1)
+	if deleted == 0 {
+		return constants.ErrLogEntryNotFound
+	}
+	return nil
+}
+
+// CountMatching counts the entries matching the filter
+func (r *MemoryLoggerRepository) CountMatching(ctx context.Context, filter types.LogEntryFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for i := range r.entries {
+		if matchesFilter(filter, &r.entries[i]) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetPruneCutoffID returns the ID of the newest entry outside the newest keep entries
+func (r *MemoryLoggerRepository) GetPruneCutoffID(ctx context.Context, keep int) (*int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) <= keep {
+		return nil, nil
+	}
+	id := r.entries[len(r.entries)-1-keep].ID
+	return &id, nil
+}
+
+// DeleteOldestUpTo deletes at most limit of the oldest entries whose ID is at most maxID
+func (r *MemoryLoggerRepository) DeleteOldestUpTo(ctx context.Context, maxID int64, limit int) (int64, error) {
+	return r.deleteBatch(func(entry *types.LogEntry) bool {
+		return entry.ID <= maxID
+	}, limit), nil
+}
+
+// deleteBatch deletes at most limit of the oldest entries selected by match without tombstones
+func (r *MemoryLoggerRepository) deleteBatch(match func(entry *types.LogEntry) bool, limit int) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	r.entries = slices.DeleteFunc(r.entries, func(entry types.LogEntry) bool {
+		if deleted >= int64(limit) || !match(&entry) {
+			return false
+		}
+		deleted++
+		return true
+	})
+	return deleted
+}
+
+// ListChain retrieves up to limit entries with an ID greater than afterID in insertion order
+func (r *MemoryLoggerRepository) ListChain(ctx context.Context, afterID int64, limit int) ([]types.LogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start, found := r.indexOf(afterID)
+	if found {
+		start++
+	}
+	end := min(start+limit, len(r.entries))
+	return slices.Clone(r.entries[start:end]), nil
+}
+
+// GetChainHead retrieves the last sealed entry of the hash chain
+func (r *MemoryLoggerRepository) GetChainHead(ctx context.Context) (*types.ChainHead, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	head := r.head
+	return &head, nil
+}
+
+// CreateChainCheckpoint saves a signed checkpoint of the hash chain
+func (r *MemoryLoggerRepository) CreateChainCheckpoint(ctx context.Context, checkpoint *types.ChainCheckpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checkpoint.ID = r.nextID("log_chain_checkpoints")
+	r.checkpoints = append(r.checkpoints, *checkpoint)
+	return nil
+}
+
+// GetLatestChainCheckpoint retrieves the most recent checkpoint, nil when there is none
+func (r *MemoryLoggerRepository) GetLatestChainCheckpoint(ctx context.Context) (*types.ChainCheckpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.checkpoints) == 0 {
+		return nil, nil
+	}
+	checkpoint := r.checkpoints[len(r.checkpoints)-1]
+	return &checkpoint, nil
+}
+
+// ListChainCheckpoints retrieves every checkpoint, oldest first
+func (r *MemoryLoggerRepository) ListChainCheckpoints(ctx context.Context) ([]types.ChainCheckpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.checkpoints), nil
+}
+
+// CountByBucket counts the log entries matching the query by bucket and event type
+func (r *MemoryLoggerRepository) CountByBucket(ctx context.Context, query types.StatsQuery) ([]types.StatsCount, error) {
+	filter := types.LogEntryFilter{EventTypes: query.EventTypes, From: &query.From, To: &query.To}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[statsRollupKey]int64)
+	for i := range r.entries {
+		entry := &r.entries[i]
+		if matchesFilter(filter, entry) {
+			counts[statsRollupKey{bucketStart: query.Bucket.Start(entry.CreatedAt), eventType: entry.EventType}]++
+		}
+	}
+	return memoryStatsCounts(counts), nil
+}
+
+// CountRollupByBucket sums the hourly rollup matching the query by bucket and event type
+func (r *MemoryLoggerRepository) CountRollupByBucket(ctx context.Context, query types.StatsQuery) ([]types.StatsCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[statsRollupKey]int64)
+	for key, count := range r.rollup {
+		if key.bucketStart.Before(query.From) || !key.bucketStart.Before(query.To) {
+			continue
+		}
+		if len(query.EventTypes) > 0 && !types.MatchesAnyEventType(query.EventTypes, key.eventType) {
+			continue
+		}
+		counts[statsRollupKey{bucketStart: query.Bucket.Start(key.bucketStart), eventType: key.eventType}] += count
+	}
+	return memoryStatsCounts(counts), nil
+}
+
+// memoryStatsCounts orders the counts by bucket and event type
+func memoryStatsCounts(counts map[statsRollupKey]int64) []types.StatsCount {
+	stats := make([]types.StatsCount, 0, len(counts))
+	for key, count := range counts {
+		stats = append(stats, types.StatsCount{
+			BucketStart: key.bucketStart,
+			EventType:   key.eventType,
+			Count:       count,
+		})
+	}
+	slices.SortFunc(stats, func(a, b types.StatsCount) int {
+		return cmp.Or(a.BucketStart.Compare(b.BucketStart), strings.Compare(a.EventType, b.EventType))
+	})
+	return stats
+}
+
+// CreateSecurityAlert stores a new security alert and fills in its ID
+func (r *MemoryLoggerRepository) CreateSecurityAlert(ctx context.Context, alert *types.SecurityAlert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alert.ID = r.nextID("log_security_alerts")
+	r.alerts = append(r.alerts, *alert)
+	return nil
+}
+
+// ListSecurityAlerts retrieves a single page of security alerts, newest first
+func (r *MemoryLoggerRepository) ListSecurityAlerts(ctx context.Context, query types.SecurityAlertQuery) ([]types.SecurityAlert, *string, error) {
+	var beforeID *int64
+	if query.Cursor != nil && strings.TrimSpace(*query.Cursor) != "" {
+		id, err := pagination.DecodeIDCursor(strings.TrimSpace(*query.Cursor))
+		if err != nil {
+			return nil, nil, err
+		}
+		beforeID = &id
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alerts := []types.SecurityAlert{}
+	for i := len(r.alerts) - 1; i >= 0 && len(alerts) <= query.Limit; i-- {
+		alert := r.alerts[i]
+		if beforeID != nil && alert.ID >= *beforeID {
+			continue
+		}
+		switch {
+		case query.Status == types.SecurityAlertStatusOpen && alert.AcknowledgedAt != nil,
+			query.Status == types.SecurityAlertStatusAcknowledged && alert.AcknowledgedAt == nil:
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if len(alerts) <= query.Limit {
+		return alerts, nil, nil
+	}
+
+	next := pagination.EncodeIDCursor(alerts[query.Limit-1].ID)
+	return alerts[:query.Limit], &next, nil
+}
+
+// AcknowledgeSecurityAlert marks an alert as reviewed, keeping the first acknowledgement
+func (r *MemoryLoggerRepository) AcknowledgeSecurityAlert(ctx context.Context, id int64, userID string, at time.Time) (*types.SecurityAlert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.alerts {
+		alert := &r.alerts[i]
+		if alert.ID != id {
+			continue
+		}
+		if alert.AcknowledgedAt == nil {
+			acknowledgedAt := at.UTC()
+			alert.AcknowledgedAt = &acknowledgedAt
+			alert.AcknowledgedBy = &userID
+		}
+		acknowledged := *alert
+		return &acknowledged, nil
+	}
+	return nil, constants.ErrSecurityAlertNotFound
+}
+
+// EraseUserEntries erases up to limit entries of the user and keeps their hashes as tombstones
+func (r *MemoryLoggerRepository) EraseUserEntries(ctx context.Context, userID string, mode types.ErasureMode, pseudonym string, at time.Time, limit int) (int64, error) {
+	return r.eraseEntries(func(entry *types.LogEntry) bool {
+		return entry.UserID != nil && *entry.UserID == userID
+	}, mode, pseudonym, at, limit), nil
+}
+
+// CreateErasedUser records an erased user, replacing the record of an earlier erasure
+func (r *MemoryLoggerRepository) CreateErasedUser(ctx context.Context, user *types.ErasedUser) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.erasedUsers[user.Subject] = *user
+	return nil
+}
+
+// EraseMatching deletes up to limit of the oldest entries matching the filter, keeping tombstones
+func (r *MemoryLoggerRepository) EraseMatching(ctx context.Context, filter types.LogEntryFilter, at time.Time, limit int) (int64, error) {
+	return r.eraseEntries(func(entry *types.LogEntry) bool { return matchesFilter(filter, entry) }, types.ErasureModeDelete, "", at, limit), nil
+}
+
+// eraseEntries erases up to limit of the oldest entries selected by match
+func (r *MemoryLoggerRepository) eraseEntries(match func(entry *types.LogEntry) bool, mode types.ErasureMode, pseudonym string, at time.Time, limit int) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	erased := make(map[int64]bool)
+	for i := range r.entries {
+		if len(erased) >= limit {
+			break
+		}
+		entry := &r.entries[i]
+		if !match(entry) {
+			continue
+		}
+		erased[entry.ID] = true
+		r.putTombstone(types.ErasedEntry{
+			EntryID:     entry.ID,
+			ContentHash: entry.ContentHash,
+			PrevHash:    entry.PrevHash,
+			Hash:        entry.Hash,
+			Mode:        mode,
+			ErasedAt:    at.UTC(),
+		})
+		if mode != types.ErasureModeDelete {
+			entry.Anonymize(pseudonym)
+		}
+	}
+
+	if mode == types.ErasureModeDelete {
+		r.entries = slices.DeleteFunc(r.entries, func(entry types.LogEntry) bool {
+			return erased[entry.ID]
+		})
+	}
+	return int64(len(erased))
+}
+
+// putTombstone stores the tombstone in entry ID order, replacing an older one of the entry
+func (r *MemoryLoggerRepository) putTombstone(tombstone types.ErasedEntry) {
+	i, found := slices.BinarySearchFunc(r.erased, tombstone.EntryID, func(erased types.ErasedEntry, id int64) int {
+		return cmp.Compare(erased.EntryID, id)
+	})
+	if found {
+		r.erased[i].Mode = tombstone.Mode
+		r.erased[i].ErasedAt = tombstone.ErasedAt
+		return
+	}
+	r.erased = slices.Insert(r.erased, i, tombstone)
+}
+
+// DeleteErasedEntriesUpTo deletes at most limit of the oldest tombstones whose entry ID is at most maxEntryID
+func (r *MemoryLoggerRepository) DeleteErasedEntriesUpTo(ctx context.Context, maxEntryID int64, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	r.erased = slices.DeleteFunc(r.erased, func(tombstone types.ErasedEntry) bool {
+		if deleted >= int64(limit) || tombstone.EntryID > maxEntryID {
+			return false
+		}
+		deleted++
+		return true
+	})
+	return deleted, nil
+}
+
+// ListErasedEntries retrieves the tombstones after afterID up to upToID, or all when it is nil
+func (r *MemoryLoggerRepository) ListErasedEntries(ctx context.Context, afterID int64, upToID *int64) ([]types.ErasedEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tombstones []types.ErasedEntry
+	for _, tombstone := range r.erased {
+		if tombstone.EntryID > afterID && (upToID == nil || tombstone.EntryID <= *upToID) {
+			tombstones = append(tombstones, tombstone)
+		}
+	}
+	return tombstones, nil
+}
+
+// CreateDeadLetters stores the dead letters and fills in their IDs
+func (r *MemoryLoggerRepository) CreateDeadLetters(ctx context.Context, deadLetters []*types.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, deadLetter := range deadLetters {
+		deadLetter.ID = r.nextID("log_dead_letters")
+		r.deadLetters = append(r.deadLetters, *deadLetter)
+	}
+	return nil
+}
+
+// ListDeadLetters retrieves a single page of dead letters, newest first
+func (r *MemoryLoggerRepository) ListDeadLetters(ctx context.Context, query types.DeadLetterQuery) ([]types.DeadLetter, *string, error) {
+	var beforeID *int64
+	if query.Cursor != nil && strings.TrimSpace(*query.Cursor) != "" {
+		id, err := pagination.DecodeIDCursor(strings.TrimSpace(*query.Cursor))
+		if err != nil {
+			return nil, nil, err
+		}
+		beforeID = &id
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deadLetters := []types.DeadLetter{}
+	for i := len(r.deadLetters) - 1; i >= 0 && len(deadLetters) <= query.Limit; i-- {
+		if beforeID != nil && r.deadLetters[i].ID >= *beforeID {
+			continue
+		}
+		deadLetters = append(deadLetters, r.deadLetters[i])
+	}
+
+	if len(deadLetters) <= query.Limit {
+		return deadLetters, nil, nil
+	}
+
+	next := pagination.EncodeIDCursor(deadLetters[query.Limit-1].ID)
+	return deadLetters[:query.Limit], &next, nil
+}
+
+// GetDeadLetter retrieves a dead letter by ID
+func (r *MemoryLoggerRepository) GetDeadLetter(ctx context.Context, id int64) (*types.DeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, deadLetter := range r.deadLetters {
+		if deadLetter.ID == id {
+			return &deadLetter, nil
+		}
+	}
+	return nil, constants.ErrDeadLetterNotFound
+}
+
+// RecordDeadLetterAttempt records a replay that failed again
+func (r *MemoryLoggerRepository) RecordDeadLetterAttempt(ctx context.Context, id int64, lastError string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deadLetters {
+		if r.deadLetters[i].ID == id {
+			r.deadLetters[i].Attempts++
+			r.deadLetters[i].LastError = lastError
+			r.deadLetters[i].LastAttemptAt = at.UTC()
+		}
+	}
+	return nil
+}
+
+// DeleteDeadLetter deletes a dead letter, it returns ErrDeadLetterNotFound when it no longer exists
+func (r *MemoryLoggerRepository) DeleteDeadLetter(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before := len(r.deadLetters)
+	r.deadLetters = slices.DeleteFunc(r.deadLetters, func(deadLetter types.DeadLetter) bool {
+		return deadLetter.ID == id
+	})
+	if len(r.deadLetters) == before {
+		return constants.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// DeleteProcessedEventsBefore deletes up to limit processed events older than before
+func (r *MemoryLoggerRepository) DeleteProcessedEventsBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for eventID, processedAt := range r.processed {
+		if deleted >= int64(limit) {
+			break
+		}
+		if processedAt.Before(before) {
+			delete(r.processed, eventID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Count returns the total number of log entries
+func (r *MemoryLoggerRepository) Count(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.entries), nil
+}
+
+// Close closes the repository
+func (r *MemoryLoggerRepository) Close() error {
+	return nil
+}
+
+// matchesFilter reports whether the entry is matched by the filter, like the database queries
+func matchesFilter(filter types.LogEntryFilter, entry *types.LogEntry) bool {
+	if len(filter.EventTypes) > 0 && !types.MatchesAnyEventType(filter.EventTypes, entry.EventType) {
+		return false
+	}
+	if types.MatchesAnyEventType(filter.ExcludeEventTypes, entry.EventType) {
+		return false
+	}
+	equal := func(want *string, value *string) bool {
+		return want == nil || (value != nil && *value == *want)
+	}
+	if !equal(filter.UserID, entry.UserID) || !equal(filter.SessionID, entry.SessionID) ||
+		!equal(filter.IPAddress, entry.IPAddress) || !equal(filter.RequestID, entry.RequestID) {
+		return false
+	}
+	if filter.From != nil && entry.CreatedAt.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && !entry.CreatedAt.Before(*filter.To) {
+		return false
+	}
+	return filter.AfterID == nil || entry.ID > *filter.AfterID
+}
+
+func optionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,263 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/uptrace/bun"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// partitionTimeLayout formats the partition bounds as TIMESTAMP literals
+const partitionTimeLayout = "2006-01-02 15:04:05"
+
+// BunPartitionRepository manages the monthly partitions of log_entries on Postgres
+type BunPartitionRepository struct {
+	db bun.IDB
+}
+
+// NewBunPartitionRepository creates a partition repository, the database must be Postgres
+func NewBunPartitionRepository(db bun.IDB) *BunPartitionRepository {
+	return &BunPartitionRepository{db: db}
+}
+
+// ListPartitions returns the attached monthly partitions, oldest first
+func (r *BunPartitionRepository) ListPartitions(ctx context.Context) ([]types.LogPartition, error) {
+	var rows []struct {
+		Name    string  `bun:"name"`
+		Comment *string `bun:"comment"`
+	}
+	if err := r.db.NewSelect().
+		TableExpr("pg_inherits AS i").
+		Join("JOIN pg_class AS c ON c.oid = i.inhrelid").
+		ColumnExpr("c.relname AS name").
+		ColumnExpr("obj_description(c.oid, 'pg_class') AS comment").
+		Where("i.inhparent = to_regclass('log_entries')").
+		Scan(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to list log partitions: %w", err)
+	}
+
+	partitions := make([]types.LogPartition, 0, len(rows))
+	for _, row := range rows {
+		if partition, ok := types.ParseLogPartition(row.Name); ok {
+			partition.Restored = row.Comment != nil && *row.Comment == types.LogRestoredPartitionComment
+			partitions = append(partitions, partition)
+		}
+	}
+	slices.SortFunc(partitions, func(a, b types.LogPartition) int {
+		return a.From.Compare(b.From)
+	})
+	return partitions, nil
+}
+
+// CreatePartition creates and attaches the partition unless it exists
+func (r *BunPartitionRepository) CreatePartition(ctx context.Context, partition types.LogPartition) (bool, error) {
+	created := false
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockLogPartitions(ctx, tx); err != nil {
+			return err
+		}
+		exists, err := logPartitionExists(ctx, tx, partition)
+		if err != nil || exists {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "CREATE TABLE ? (LIKE log_entries INCLUDING DEFAULTS)", bun.Ident(partition.Name)); err != nil {
+			return err
+		}
+		if err := attachLogPartition(ctx, tx, partition); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create log partition %s: %w", partition.Name, err)
+	}
+	return created, nil
+}
+
+// StreamPartition calls fn for every entry of the partition in insertion order
+func (r *BunPartitionRepository) StreamPartition(ctx context.Context, partition types.LogPartition, fn func(entry *types.LogEntry) error) error {
+	selectQuery := r.db.NewSelect().
+		Model((*types.LogEntry)(nil)).
+		ModelTableExpr("? AS log_entry", bun.Ident(partition.Name)).
+		OrderExpr("id ASC")
+
+	rows, err := selectQuery.Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to stream log partition %s: %w", partition.Name, err)
+	}
+	defer rows.Close()
+
+	var entry types.LogEntry
+	for rows.Next() {
+		entry = types.LogEntry{}
+		if err := selectQuery.DB().ScanRow(ctx, rows, &entry); err != nil {
+			return fmt.Errorf("failed to scan log entry: %w", err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream log partition %s: %w", partition.Name, err)
+	}
+	return nil
+}
+
+// DropPartition drops an archived partition unless entries were added since it was archived
+func (r *BunPartitionRepository) DropPartition(ctx context.Context, partition types.LogPartition, entries int64) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockLogPartitions(ctx, tx); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "ALTER TABLE log_entries DETACH PARTITION ?", bun.Ident(partition.Name)); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.NewSelect().TableExpr("?", bun.Ident(partition.Name)).ColumnExpr("COUNT(*)").Scan(ctx, &count); err != nil {
+			return err
+		}
+		if count != entries {
+			return constants.ErrPartitionChanged
+		}
+		_, err := tx.ExecContext(ctx, "DROP TABLE ?", bun.Ident(partition.Name))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop log partition %s: %w", partition.Name, err)
+	}
+	return nil
+}
+
+// RestorePartition fills and attaches the partition from the entries of an archive
+func (r *BunPartitionRepository) RestorePartition(ctx context.Context, partition types.LogPartition, read func(insert func(entries []types.LogEntry, tombstones []types.ErasedEntry) error) error) (int64, error) {
+	var restored int64
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockLogPartitions(ctx, tx); err != nil {
+			return err
+		}
+		exists, err := logPartitionExists(ctx, tx, partition)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return constants.ErrPartitionExists
+		}
+		if _, err := tx.ExecContext(ctx, "CREATE TABLE ? (LIKE log_entries INCLUDING DEFAULTS)", bun.Ident(partition.Name)); err != nil {
+			return err
+		}
+
+		insert := func(entries []types.LogEntry, tombstones []types.ErasedEntry) error {
+			// A tombstone kept from before the archive already has the hashes of the entry
+			if len(tombstones) > 0 {
+				if _, err := tx.NewInsert().Model(&tombstones).On("CONFLICT (entry_id) DO NOTHING").Exec(ctx); err != nil {
+					return fmt.Errorf("failed to create log entry tombstones: %w", err)
+				}
+			}
+			if len(entries) == 0 {
+				return nil
+			}
+			if _, err := tx.NewInsert().Model(&entries).ModelTableExpr("?", bun.Ident(partition.Name)).Exec(ctx); err != nil {
+				return err
+			}
+			restored += int64(len(entries))
+			return nil
+		}
+		if err := read(insert); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "COMMENT ON TABLE ? IS ?", bun.Ident(partition.Name), types.LogRestoredPartitionComment); err != nil {
+			return err
+		}
+		return attachLogPartition(ctx, tx, partition)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore log partition %s: %w", partition.Name, err)
+	}
+	return restored, nil
+}
+
+// ListTombstones retrieves the tombstones of the entries
+func (r *BunPartitionRepository) ListTombstones(ctx context.Context, entryIDs []int64) ([]types.ErasedEntry, error) {
+	var tombstones []types.ErasedEntry
+	if len(entryIDs) == 0 {
+		return tombstones, nil
+	}
+	if err := r.db.NewSelect().
+		Model(&tombstones).
+		Where("entry_id IN (?)", bun.In(entryIDs)).
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list log entry tombstones: %w", err)
+	}
+	return tombstones, nil
+}
+
+// ListErasedUsers retrieves the records of the erased users among the subjects
+func (r *BunPartitionRepository) ListErasedUsers(ctx context.Context, subjects []string) ([]types.ErasedUser, error) {
+	var users []types.ErasedUser
+	if len(subjects) == 0 {
+		return users, nil
+	}
+	if err := r.db.NewSelect().
+		Model(&users).
+		Where("subject IN (?)", bun.In(subjects)).
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list erased users: %w", err)
+	}
+	return users, nil
+}
+
+// CreateLogPartitions creates the monthly partitions from the month of from through the month of to
+func CreateLogPartitions(ctx context.Context, db bun.IDB, from, to types.LogPartition) ([]types.LogPartition, error) {
+	repo := NewBunPartitionRepository(db)
+	var created []types.LogPartition
+	for partition := from; !partition.From.After(to.From); partition = types.NewLogPartition(partition.To) {
+		ok, err := repo.CreatePartition(ctx, partition)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created = append(created, partition)
+		}
+	}
+	return created, nil
+}
+
+// lockLogPartitions serializes changes to the partitions until the transaction ends
+func lockLogPartitions(ctx context.Context, tx bun.Tx) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('log_entries_partitions'))"); err != nil {
+		return fmt.Errorf("failed to lock log partitions: %w", err)
+	}
+	return nil
+}
+
+// logPartitionExists reports whether the table of the partition exists, attached or not
+func logPartitionExists(ctx context.Context, tx bun.Tx, partition types.LogPartition) (bool, error) {
+	var exists bool
+	if err := tx.NewSelect().ColumnExpr("to_regclass(?) IS NOT NULL", partition.Name).Scan(ctx, &exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// attachLogPartition moves the rows of the month out of the default partition and attaches it
+func attachLogPartition(ctx context.Context, tx bun.Tx, partition types.LogPartition) error {
+	from, to := partition.From.Format(partitionTimeLayout), partition.To.Format(partitionTimeLayout)
+	if _, err := tx.ExecContext(ctx,
+		`WITH moved AS (DELETE FROM ? WHERE created_at >= ? AND created_at < ? RETURNING *) INSERT INTO ? SELECT * FROM moved`,
+		bun.Ident(types.LogDefaultPartition), from, to, bun.Ident(partition.Name),
+	); err != nil {
+		return fmt.Errorf("failed to move entries out of the default log partition: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"ALTER TABLE log_entries ATTACH PARTITION ? FOR VALUES FROM (?) TO (?)",
+		bun.Ident(partition.Name), from, to,
+	); err != nil {
+		return fmt.Errorf("failed to attach log partition: %w", err)
+	}
+	return nil
+}
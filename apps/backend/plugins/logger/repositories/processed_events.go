@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/feature"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// createProcessedEvents remembers the event ids of the stored entries
+func createProcessedEvents(ctx context.Context, tx bun.Tx, entries []*types.LogEntry) error {
+	now := time.Now().UTC()
+	processed := make([]*types.ProcessedEvent, 0, len(entries))
+	for _, entry := range entries {
+		if entry.EventID != nil {
+			processed = append(processed, &types.ProcessedEvent{EventID: *entry.EventID, ProcessedAt: now})
+		}
+	}
+	if len(processed) == 0 {
+		return nil
+	}
+	if _, err := tx.NewInsert().Model(&processed).Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create processed events: %w", err)
+	}
+	return nil
+}
+
+// DeleteProcessedEventsBefore deletes up to limit processed events older than before
+func (r *BunLoggerRepository) DeleteProcessedEventsBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var (
+		result sql.Result
+		err    error
+	)
+	if r.db.Dialect().Features().Has(feature.DeleteOrderLimit) {
+		result, err = r.db.NewDelete().
+			Model((*types.ProcessedEvent)(nil)).
+			Where("processed_at < ?", before).
+			OrderExpr("processed_at ASC").
+			Limit(limit).
+			Exec(ctx)
+	} else {
+		subquery := r.db.NewSelect().
+			Model((*types.ProcessedEvent)(nil)).
+			Column("event_id").
+			Where("processed_at < ?", before).
+			OrderExpr("processed_at ASC").
+			Limit(limit)
+		result, err = r.db.NewDelete().
+			Model((*types.ProcessedEvent)(nil)).
+			Where("event_id IN (?)", subquery).
+			Exec(ctx)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted processed events: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// likeEscapeChar is not a backslash because the dialects parse backslashes in literals differently
+const likeEscapeChar = "!"
+
+// applyLogEntryFilter returns a query builder func that restricts a query to the entries matched by filter
+func applyLogEntryFilter(filter types.LogEntryFilter) func(bun.QueryBuilder) bun.QueryBuilder {
+	return func(qb bun.QueryBuilder) bun.QueryBuilder {
+		if len(filter.EventTypes) > 0 {
+			qb = qb.WhereGroup(" AND ", func(qb bun.QueryBuilder) bun.QueryBuilder {
+				for _, eventType := range filter.EventTypes {
+					qb = qb.WhereOr(eventTypeCondition(eventType))
+				}
+				return qb
+			})
+		}
+		for _, eventType := range filter.ExcludeEventTypes {
+			condition, arg := eventTypeCondition(eventType)
+			qb = qb.Where("NOT ("+condition+")", arg)
+		}
+		if filter.UserID != nil {
+			qb = qb.Where("user_id = ?", *filter.UserID)
+		}
+		if filter.SessionID != nil {
+			qb = qb.Where("session_id = ?", *filter.SessionID)
+		}
+		if filter.IPAddress != nil {
+			qb = qb.Where("ip_address = ?", *filter.IPAddress)
+		}
+		if filter.RequestID != nil {
+			qb = qb.Where("request_id = ?", *filter.RequestID)
+		}
+		if filter.From != nil {
+			qb = qb.Where("created_at >= ?", filter.From.UTC())
+		}
+		if filter.To != nil {
+			qb = qb.Where("created_at < ?", filter.To.UTC())
+		}
+		if filter.AfterID != nil {
+			qb = qb.Where("id > ?", *filter.AfterID)
+		}
+		return qb
+	}
+}
+
+// eventTypeCondition returns the condition matching a single event type or glob pattern
+func eventTypeCondition(eventType string) (string, any) {
+	if types.IsEventTypePattern(eventType) {
+		return "event_type LIKE ? ESCAPE '" + likeEscapeChar + "'", globToLike(eventType)
+	}
+	return "event_type = ?", eventType
+}
+
+// globToLike converts a glob pattern into an escaped LIKE pattern
+func globToLike(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteByte('%')
+		case '?':
+			b.WriteByte('_')
+		case '%', '_', '!':
+			b.WriteString(likeEscapeChar)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// encodeCursor builds the opaque keyset cursor that points right after entry
+func encodeCursor(entry types.LogEntry) string {
+	raw := fmt.Sprintf("%d:%d", entry.CreatedAt.UnixNano(), entry.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, constants.ErrInvalidCursor
+	}
+
+	createdAtPart, idPart, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, constants.ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(createdAtPart, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, constants.ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, constants.ErrInvalidCursor
+	}
+
+	return time.Unix(0, createdAt).UTC(), id, nil
+}
@@ -2,13 +2,23 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/feature"
 
+	"github.com/Authula/authula-playground/plugins/logger/constants"
 	"github.com/Authula/authula-playground/plugins/logger/types"
 )
 
+// chainHeadID is the primary key of the single log_chain_head row
+const chainHeadID = 1
+
 // BunLoggerRepository implements Repository
 type BunLoggerRepository struct {
 	db bun.IDB
@@ -19,18 +29,136 @@ func NewBunLoggerRepository(db bun.IDB) *BunLoggerRepository {
 	return &BunLoggerRepository{db: db}
 }
 
-// Create saves a new log entry to the database
+// Create saves a new log entry, it returns ErrDuplicateLogEntry for an event already stored
 func (r *BunLoggerRepository) Create(ctx context.Context, entry *types.LogEntry) error {
-	if _, err := r.db.NewInsert().Model(entry).Exec(ctx); err != nil {
-		return fmt.Errorf("failed to create log entry: %w", err)
+	created, err := r.CreateBatch(ctx, []*types.LogEntry{entry})
+	if err != nil {
+		return err
+	}
+	if len(created) == 0 {
+		return constants.ErrDuplicateLogEntry
 	}
 	return nil
 }
 
+// CreateBatch seals the entries into the hash chain and inserts those of new events at once
+func (r *BunLoggerRepository) CreateBatch(ctx context.Context, entries []*types.LogEntry) ([]*types.LogEntry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var created []*types.LogEntry
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		head := new(types.ChainHead)
+		query := tx.NewSelect().Model(head).Where("id = ?", chainHeadID)
+		// SQLite has no row locks, its transactions already serialize writers
+		if tx.Dialect().Name() != dialect.SQLite {
+			query = query.For("UPDATE")
+		}
+		if err := query.Scan(ctx); err != nil {
+			return fmt.Errorf("failed to lock log chain head: %w", err)
+		}
+
+		// Filtered under the chain head lock, a row skipped by the insert would leave a gap in the chain
+		unique, err := withoutStoredEvents(ctx, tx, entries)
+		if err != nil {
+			return err
+		}
+		if len(unique) == 0 {
+			return nil
+		}
+
+		prevHash := head.Hash
+		for _, entry := range unique {
+			// A retried entry may carry the ID returned by an attempt that was rolled back
+			entry.ID = 0
+			entry.Seal(prevHash)
+			prevHash = *entry.Hash
+		}
+
+		if _, err := tx.NewInsert().Model(&unique).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+		if err := incrementStatsRollup(ctx, tx, unique); err != nil {
+			return err
+		}
+		if err := createProcessedEvents(ctx, tx, unique); err != nil {
+			return err
+		}
+
+		last := unique[len(unique)-1]
+		if _, err := tx.NewUpdate().
+			Model(head).
+			Set("entry_id = ?", last.ID).
+			Set("hash = ?", *last.Hash).
+			Set("updated_at = ?", time.Now().UTC()).
+			WherePK().
+			Exec(ctx); err != nil {
+			return fmt.Errorf("failed to advance log chain head: %w", err)
+		}
+		created = unique
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log entries: %w", err)
+	}
+	return created, nil
+}
+
+// withoutStoredEvents returns the entries whose event was not stored yet
+func withoutStoredEvents(ctx context.Context, tx bun.Tx, entries []*types.LogEntry) ([]*types.LogEntry, error) {
+	eventIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.EventID != nil {
+			eventIDs = append(eventIDs, *entry.EventID)
+		}
+	}
+	if len(eventIDs) == 0 {
+		return entries, nil
+	}
+
+	// The entries are checked as well, the ids of pruned processed events may still be stored there
+	var processed, stored []string
+	if err := tx.NewSelect().
+		Model((*types.ProcessedEvent)(nil)).
+		Column("event_id").
+		Where("event_id IN (?)", bun.In(eventIDs)).
+		Scan(ctx, &processed); err != nil {
+		return nil, fmt.Errorf("failed to look up processed events: %w", err)
+	}
+	if err := tx.NewSelect().
+		Table("log_entries").
+		Column("dedup_event_id").
+		Where("dedup_event_id IN (?)", bun.In(eventIDs)).
+		Scan(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("failed to look up stored events: %w", err)
+	}
+
+	seen := make(map[string]bool, len(eventIDs))
+	for _, eventID := range append(processed, stored...) {
+		seen[eventID] = true
+	}
+	unique := make([]*types.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.EventID != nil {
+			if seen[*entry.EventID] {
+				continue
+			}
+			seen[*entry.EventID] = true
+			entry.DedupEventID = entry.EventID
+		}
+		unique = append(unique, entry)
+	}
+	return unique, nil
+}
+
 // GetByID retrieves a log entry by ID
 func (r *BunLoggerRepository) GetByID(ctx context.Context, id int64) (*types.LogEntry, error) {
 	var entry types.LogEntry
 	if err := r.db.NewSelect().Model(&entry).Where("id = ?", id).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, constants.ErrLogEntryNotFound
+		}
 		return nil, fmt.Errorf("failed to get log entry: %w", err)
 	}
 	return &entry, nil
@@ -45,14 +173,232 @@ func (r *BunLoggerRepository) GetAll(ctx context.Context) ([]types.LogEntry, err
 	return entries, nil
 }
 
-// Delete removes a log entry by ID
-func (r *BunLoggerRepository) Delete(ctx context.Context, id int64) error {
-	if _, err := r.db.NewDelete().Model(&types.LogEntry{}).Where("id = ?", id).Exec(ctx); err != nil {
+// List retrieves a single page of log entries matching the query, ordered by creation time
+func (r *BunLoggerRepository) List(ctx context.Context, query types.LogEntryQuery) ([]types.LogEntry, *string, error) {
+	return r.list(ctx, query, nil)
+}
+
+// list retrieves a single page of log entries matching the query and the extra conditions of where
+func (r *BunLoggerRepository) list(ctx context.Context, query types.LogEntryQuery, where func(*bun.SelectQuery) *bun.SelectQuery) ([]types.LogEntry, *string, error) {
+	direction, comparator := "DESC", "<"
+	if query.Order == types.SortOrderAsc {
+		direction, comparator = "ASC", ">"
+	}
+
+	selectQuery := r.db.NewSelect().
+		Model((*types.LogEntry)(nil)).
+		ApplyQueryBuilder(applyLogEntryFilter(query.Filter)).
+		OrderExpr("created_at " + direction).
+		OrderExpr("id " + direction).
+		Limit(query.Limit + 1)
+	if where != nil {
+		selectQuery = where(selectQuery)
+	}
+
+	if query.Cursor != nil && strings.TrimSpace(*query.Cursor) != "" {
+		createdAt, id, err := decodeCursor(strings.TrimSpace(*query.Cursor))
+		if err != nil {
+			return nil, nil, err
+		}
+		selectQuery = selectQuery.Where(
+			"(created_at "+comparator+" ? OR (created_at = ? AND id "+comparator+" ?))",
+			createdAt, createdAt, id,
+		)
+	}
+
+	var entries []types.LogEntry
+	if err := selectQuery.Scan(ctx, &entries); err != nil {
+		return nil, nil, fmt.Errorf("failed to list log entries: %w", err)
+	}
+
+	if entries == nil {
+		entries = []types.LogEntry{}
+	}
+
+	if len(entries) <= query.Limit {
+		return entries, nil, nil
+	}
+
+	next := encodeCursor(entries[query.Limit-1])
+	return entries[:query.Limit], &next, nil
+}
+
+// Stream calls fn for every log entry matching the filter through a database cursor
+func (r *BunLoggerRepository) Stream(ctx context.Context, filter types.LogEntryFilter, order types.SortOrder, fn func(entry *types.LogEntry) error) error {
+	direction := "ASC"
+	if order == types.SortOrderDesc {
+		direction = "DESC"
+	}
+
+	selectQuery := r.db.NewSelect().
+		Model((*types.LogEntry)(nil)).
+		ApplyQueryBuilder(applyLogEntryFilter(filter)).
+		OrderExpr("created_at " + direction).
+		OrderExpr("id " + direction)
+
+	rows, err := selectQuery.Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to stream log entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entry types.LogEntry
+	for rows.Next() {
+		entry = types.LogEntry{}
+		if err := selectQuery.DB().ScanRow(ctx, rows, &entry); err != nil {
+			return fmt.Errorf("failed to scan log entry: %w", err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream log entries: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a log entry by ID and keeps its hashes as a tombstone
+func (r *BunLoggerRepository) Delete(ctx context.Context, id int64, at time.Time) error {
+	deleted, err := r.eraseEntries(ctx, func(qb bun.QueryBuilder) bun.QueryBuilder {
+		return qb.Where("id = ?", id)
+	}, types.ErasureModeDelete, "", at, 1)
+	if err != nil {
 		return fmt.Errorf("failed to delete log entry: %w", err)
 	}
+	if deleted == 0 {
+		return constants.ErrLogEntryNotFound
+	}
+	return nil
+}
+
+// CountMatching counts the entries matching the filter
+func (r *BunLoggerRepository) CountMatching(ctx context.Context, filter types.LogEntryFilter) (int64, error) {
+	count, err := r.db.NewSelect().
+		Model((*types.LogEntry)(nil)).
+		ApplyQueryBuilder(applyLogEntryFilter(filter)).
+		Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count log entries: %w", err)
+	}
+	return int64(count), nil
+}
+
+// GetPruneCutoffID returns the ID of the newest entry outside the newest keep entries
+func (r *BunLoggerRepository) GetPruneCutoffID(ctx context.Context, keep int) (*int64, error) {
+	var ids []int64
+	if err := r.db.NewSelect().
+		Model((*types.LogEntry)(nil)).
+		Column("id").
+		OrderExpr("id DESC").
+		Offset(keep).
+		Limit(1).
+		Scan(ctx, &ids); err != nil {
+		return nil, fmt.Errorf("failed to get prune cutoff: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+// DeleteOldestUpTo deletes at most limit of the oldest entries whose ID is at most maxID
+func (r *BunLoggerRepository) DeleteOldestUpTo(ctx context.Context, maxID int64, limit int) (int64, error) {
+	return r.deleteBatch(ctx, func(qb bun.QueryBuilder) bun.QueryBuilder {
+		return qb.Where("id <= ?", maxID)
+	}, limit)
+}
+
+// deleteBatch deletes at most limit of the oldest entries selected by where
+func (r *BunLoggerRepository) deleteBatch(ctx context.Context, where func(bun.QueryBuilder) bun.QueryBuilder, limit int) (int64, error) {
+	var (
+		result sql.Result
+		err    error
+	)
+	if r.db.Dialect().Features().Has(feature.DeleteOrderLimit) {
+		// MySQL does not allow LIMIT in IN subqueries but supports it on DELETE directly
+		result, err = r.db.NewDelete().
+			Model((*types.LogEntry)(nil)).
+			ApplyQueryBuilder(where).
+			OrderExpr("id ASC").
+			Limit(limit).
+			Exec(ctx)
+	} else {
+		subquery := r.db.NewSelect().
+			Model((*types.LogEntry)(nil)).
+			Column("id").
+			ApplyQueryBuilder(where).
+			OrderExpr("id ASC").
+			Limit(limit)
+		result, err = r.db.NewDelete().
+			Model((*types.LogEntry)(nil)).
+			Where("id IN (?)", subquery).
+			Exec(ctx)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete log entries: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted log entries: %w", err)
+	}
+	return deleted, nil
+}
+
+// ListChain retrieves up to limit entries with an ID greater than afterID in insertion order
+func (r *BunLoggerRepository) ListChain(ctx context.Context, afterID int64, limit int) ([]types.LogEntry, error) {
+	var entries []types.LogEntry
+	if err := r.db.NewSelect().
+		Model(&entries).
+		Where("id > ?", afterID).
+		OrderExpr("id ASC").
+		Limit(limit).
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list log chain: %w", err)
+	}
+	return entries, nil
+}
+
+// GetChainHead retrieves the last sealed entry of the hash chain
+func (r *BunLoggerRepository) GetChainHead(ctx context.Context) (*types.ChainHead, error) {
+	var head types.ChainHead
+	if err := r.db.NewSelect().Model(&head).Where("id = ?", chainHeadID).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get log chain head: %w", err)
+	}
+	return &head, nil
+}
+
+// CreateChainCheckpoint saves a signed checkpoint of the hash chain
+func (r *BunLoggerRepository) CreateChainCheckpoint(ctx context.Context, checkpoint *types.ChainCheckpoint) error {
+	if _, err := r.db.NewInsert().Model(checkpoint).Returning("id").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create log chain checkpoint: %w", err)
+	}
 	return nil
 }
 
+// GetLatestChainCheckpoint retrieves the most recent checkpoint, nil when there is none
+func (r *BunLoggerRepository) GetLatestChainCheckpoint(ctx context.Context) (*types.ChainCheckpoint, error) {
+	var checkpoint types.ChainCheckpoint
+	err := r.db.NewSelect().Model(&checkpoint).OrderExpr("id DESC").Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest log chain checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// ListChainCheckpoints retrieves every checkpoint, oldest first
+func (r *BunLoggerRepository) ListChainCheckpoints(ctx context.Context) ([]types.ChainCheckpoint, error) {
+	var checkpoints []types.ChainCheckpoint
+	if err := r.db.NewSelect().Model(&checkpoints).OrderExpr("id ASC").Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list log chain checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
 // Count returns the total number of log entries
 func (r *BunLoggerRepository) Count(ctx context.Context) (int, error) {
 	count, err := r.db.NewSelect().Model(&types.LogEntry{}).Count(ctx)
@@ -0,0 +1,531 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+
+	"github.com/Authula/authula/migrations"
+
+	"github.com/Authula/authula-playground/plugins/logger"
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+var loggerTables = []string{
+	"log_entries",
+	"log_chain_head",
+	"log_chain_checkpoints",
+	"log_stats_hourly",
+	"log_security_alerts",
+	"log_dead_letters",
+	"log_erased_entries",
+	"log_processed_events",
+	"log_erased_users",
+}
+
+// newSQLiteDB opens an in-memory SQLite database with the migrations of the plugin applied
+func newSQLiteDB(t *testing.T) (*bun.DB, *migrations.Migrator, []migrations.MigrationSet) {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Every connection would open its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	db := bun.NewDB(sqlDB, sqlitedialect.New())
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := migrations.NewMigrator(db, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	sets := []migrations.MigrationSet{{
+		PluginID:   "logger",
+		Migrations: logger.New(types.LoggerPluginConfig{}).Migrations("sqlite"),
+	}}
+	require.NoError(t, migrator.Migrate(context.Background(), sets))
+	return db, migrator, sets
+}
+
+// newRepositories returns an empty repository of every implementation
+func newRepositories(t *testing.T) map[string]repositories.LoggerRepository {
+	t.Helper()
+
+	db, _, _ := newSQLiteDB(t)
+	return map[string]repositories.LoggerRepository{
+		"memory": repositories.NewMemoryLoggerRepository(),
+		"sqlite": repositories.NewBunLoggerRepository(db),
+	}
+}
+
+func newEntry(eventID string, eventType string, userID string, createdAt time.Time) *types.LogEntry {
+	entry := &types.LogEntry{
+		EventType: eventType,
+		Details:   json.RawMessage(`{}`),
+		CreatedAt: createdAt.UTC().Truncate(time.Microsecond),
+	}
+	if eventID != "" {
+		entry.EventID = &eventID
+	}
+	if userID != "" {
+		entry.UserID = &userID
+	}
+	return entry
+}
+
+func tableExists(t *testing.T, db *bun.DB, table string) bool {
+	t.Helper()
+
+	count, err := db.NewSelect().
+		Table("sqlite_master").
+		Where("type = 'table' AND name = ?", table).
+		Count(context.Background())
+	require.NoError(t, err)
+	return count > 0
+}
+
+func TestMigrations_SQLite(t *testing.T) {
+	db, migrator, sets := newSQLiteDB(t)
+	ctx := context.Background()
+
+	for _, table := range loggerTables {
+		assert.True(t, tableExists(t, db, table), "table %s after migrating up", table)
+	}
+	head, err := repositories.NewBunLoggerRepository(db).GetChainHead(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, head.EntryID)
+
+	require.NoError(t, migrator.RollbackAll(ctx, sets))
+	for _, table := range loggerTables {
+		assert.False(t, tableExists(t, db, table), "table %s after rolling back", table)
+	}
+
+	require.NoError(t, migrator.Migrate(ctx, sets))
+	for _, table := range loggerTables {
+		assert.True(t, tableExists(t, db, table), "table %s after migrating up again", table)
+	}
+}
+
+func TestLoggerRepository_CreateBatch(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		stored      []*types.LogEntry
+		batch       []*types.LogEntry
+		wantCreated []string
+		wantCount   int
+	}{
+		{
+			name:        "stores every entry",
+			batch:       []*types.LogEntry{newEntry("e1", "user.signed_in", "u1", now), newEntry("e2", "user.signed_out", "u1", now)},
+			wantCreated: []string{"e1", "e2"},
+			wantCount:   2,
+		},
+		{
+			name:        "drops events that are already stored",
+			stored:      []*types.LogEntry{newEntry("e1", "user.signed_in", "u1", now)},
+			batch:       []*types.LogEntry{newEntry("e1", "user.signed_in", "u1", now), newEntry("e2", "user.signed_out", "u1", now)},
+			wantCreated: []string{"e2"},
+			wantCount:   2,
+		},
+		{
+			name:        "drops events repeated within the batch",
+			batch:       []*types.LogEntry{newEntry("e1", "user.signed_in", "u1", now), newEntry("e1", "user.signed_in", "u1", now)},
+			wantCreated: []string{"e1"},
+			wantCount:   1,
+		},
+		{
+			name:        "stores entries without an event ID every time",
+			batch:       []*types.LogEntry{newEntry("", "user.signed_in", "u1", now), newEntry("", "user.signed_in", "u1", now)},
+			wantCreated: []string{"", ""},
+			wantCount:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		for name, repo := range newRepositories(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				if len(tt.stored) > 0 {
+					_, err := repo.CreateBatch(ctx, tt.stored)
+					require.NoError(t, err)
+				}
+
+				created, err := repo.CreateBatch(ctx, tt.batch)
+				require.NoError(t, err)
+				require.Len(t, created, len(tt.wantCreated))
+				for i, entry := range created {
+					assert.NotZero(t, entry.ID)
+					if tt.wantCreated[i] == "" {
+						assert.Nil(t, entry.EventID)
+					} else if assert.NotNil(t, entry.EventID) {
+						assert.Equal(t, tt.wantCreated[i], *entry.EventID)
+					}
+				}
+
+				count, err := repo.Count(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantCount, count)
+
+				// Every stored entry links to the one before it and the head points at the last one
+				chain, err := repo.ListChain(ctx, 0, 100)
+				require.NoError(t, err)
+				require.Len(t, chain, tt.wantCount)
+				prevHash := ""
+				for _, entry := range chain {
+					require.NotNil(t, entry.Hash)
+					assert.Equal(t, prevHash, *entry.PrevHash)
+					assert.Equal(t, entry.ComputeContentHash(), *entry.ContentHash)
+					prevHash = *entry.Hash
+				}
+				head, err := repo.GetChainHead(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, chain[len(chain)-1].ID, head.EntryID)
+				assert.Equal(t, prevHash, head.Hash)
+			})
+		}
+	}
+}
+
+func TestLoggerRepository_List(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	userID := "u1"
+	from := start.Add(2 * time.Minute)
+
+	tests := []struct {
+		name  string
+		query types.LogEntryQuery
+		// want are the event IDs of every page
+		want [][]string
+	}{
+		{
+			name:  "newest first in pages",
+			query: types.LogEntryQuery{Limit: 2, Order: types.SortOrderDesc},
+			want:  [][]string{{"e5", "e4"}, {"e3", "e2"}, {"e1"}},
+		},
+		{
+			name:  "oldest first in pages",
+			query: types.LogEntryQuery{Limit: 3, Order: types.SortOrderAsc},
+			want:  [][]string{{"e1", "e2", "e3"}, {"e4", "e5"}},
+		},
+		{
+			name:  "event type pattern",
+			query: types.LogEntryQuery{Limit: 10, Order: types.SortOrderAsc, Filter: types.LogEntryFilter{EventTypes: []string{"session.*"}}},
+			want:  [][]string{{"e2", "e4"}},
+		},
+		{
+			name:  "excluded event type",
+			query: types.LogEntryQuery{Limit: 10, Order: types.SortOrderAsc, Filter: types.LogEntryFilter{ExcludeEventTypes: []string{"session.*"}}},
+			want:  [][]string{{"e1", "e3", "e5"}},
+		},
+		{
+			name:  "user and time range",
+			query: types.LogEntryQuery{Limit: 10, Order: types.SortOrderAsc, Filter: types.LogEntryFilter{UserID: &userID, From: &from}},
+			want:  [][]string{{"e3", "e5"}},
+		},
+	}
+
+	for _, tt := range tests {
+		for name, repo := range newRepositories(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				_, err := repo.CreateBatch(ctx, []*types.LogEntry{
+					newEntry("e1", "user.signed_in", "u1", start),
+					newEntry("e2", "session.created", "u2", start.Add(time.Minute)),
+					newEntry("e3", "user.signed_in", "u1", start.Add(2*time.Minute)),
+					newEntry("e4", "session.revoked", "u2", start.Add(3*time.Minute)),
+					newEntry("e5", "user.updated", "u1", start.Add(4*time.Minute)),
+				})
+				require.NoError(t, err)
+
+				query := tt.query
+				for i, want := range tt.want {
+					entries, next, err := repo.List(ctx, query)
+					require.NoError(t, err)
+					got := make([]string, 0, len(entries))
+					for _, entry := range entries {
+						got = append(got, *entry.EventID)
+					}
+					assert.Equal(t, want, got, "page %d", i)
+					if i == len(tt.want)-1 {
+						assert.Nil(t, next)
+					} else {
+						require.NotNil(t, next)
+					}
+					query.Cursor = next
+				}
+			})
+		}
+	}
+
+	for name, repo := range newRepositories(t) {
+		t.Run("invalid cursor/"+name, func(t *testing.T) {
+			cursor := "not a cursor"
+			_, _, err := repo.List(context.Background(), types.LogEntryQuery{Limit: 10, Cursor: &cursor})
+			assert.ErrorIs(t, err, constants.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestLoggerRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name string
+		// anonymize erases the entries of u1 before the delete
+		anonymize bool
+		id        int64
+		wantErr   error
+		wantCount int
+	}{
+		{name: "deletes the entry", id: 1, wantCount: 1},
+		{name: "deletes an anonymized entry", anonymize: true, id: 1, wantCount: 1},
+		{name: "unknown entry", id: 99, wantErr: constants.ErrLogEntryNotFound, wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		for name, repo := range newRepositories(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				now := time.Now()
+				_, err := repo.CreateBatch(ctx, []*types.LogEntry{
+					newEntry("e1", "user.signed_in", "u1", now),
+					newEntry("e2", "user.signed_in", "u2", now),
+				})
+				require.NoError(t, err)
+				if tt.anonymize {
+					erased, err := repo.EraseUserEntries(ctx, "u1", types.ErasureModeAnonymize, "anonymous", now, 10)
+					require.NoError(t, err)
+					require.EqualValues(t, 1, erased)
+				}
+				original, err := repo.GetByID(ctx, 1)
+				require.NoError(t, err)
+
+				err = repo.Delete(ctx, tt.id, now)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					require.NoError(t, err)
+					_, err = repo.GetByID(ctx, tt.id)
+					assert.ErrorIs(t, err, constants.ErrLogEntryNotFound)
+
+					// The tombstone keeps the hashes of the deleted entry
+					tombstones, err := repo.ListErasedEntries(ctx, 0, nil)
+					require.NoError(t, err)
+					require.Len(t, tombstones, 1)
+					assert.Equal(t, tt.id, tombstones[0].EntryID)
+					assert.Equal(t, types.ErasureModeDelete, tombstones[0].Mode)
+					assert.True(t, tombstones[0].Matches(original))
+				}
+
+				count, err := repo.Count(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantCount, count)
+			})
+		}
+	}
+}
+
+func TestLoggerRepository_Prune(t *testing.T) {
+	tests := []struct {
+		name       string
+		keep       int
+		batchSize  int
+		wantCutoff *int64
+		wantCount  int
+	}{
+		{name: "keeps the newest entries", keep: 2, batchSize: 10, wantCutoff: new(int64(3)), wantCount: 2},
+		{name: "deletes a single batch", keep: 1, batchSize: 2, wantCutoff: new(int64(4)), wantCount: 3},
+		{name: "nothing to prune", keep: 5, batchSize: 10, wantCount: 5},
+	}
+
+	for _, tt := range tests {
+		for name, repo := range newRepositories(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				now := time.Now()
+				for i := range 5 {
+					require.NoError(t, repo.Create(ctx, newEntry("", "user.signed_in", "u1", now.Add(time.Duration(i)*time.Second))))
+				}
+
+				cutoff, err := repo.GetPruneCutoffID(ctx, tt.keep)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantCutoff, cutoff)
+				if cutoff != nil {
+					_, err := repo.DeleteOldestUpTo(ctx, *cutoff, tt.batchSize)
+					require.NoError(t, err)
+				}
+
+				count, err := repo.Count(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantCount, count)
+			})
+		}
+	}
+}
+
+func TestLoggerRepository_ProcessedEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		// forget deletes the processed events before the event is redelivered
+		forget      bool
+		wantDeleted int64
+		wantCreated int
+	}{
+		{name: "redelivery of a deleted entry is dropped", wantCreated: 0},
+		{name: "redelivery after the processed event expired is stored", forget: true, wantDeleted: 2, wantCreated: 1},
+	}
+
+	for _, tt := range tests {
+		for name, repo := range newRepositories(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				now := time.Now()
+				_, err := repo.CreateBatch(ctx, []*types.LogEntry{
+					newEntry("e1", "user.signed_in", "u1", now),
+					newEntry("e2", "user.signed_in", "u1", now),
+					newEntry("", "user.signed_in", "u1", now),
+				})
+				require.NoError(t, err)
+				require.NoError(t, repo.Delete(ctx, 1, now))
+
+				if tt.forget {
+					deleted, err := repo.DeleteProcessedEventsBefore(ctx, now.Add(time.Hour), 10)
+					require.NoError(t, err)
+					assert.Equal(t, tt.wantDeleted, deleted)
+				} else {
+					deleted, err := repo.DeleteProcessedEventsBefore(ctx, now.Add(-time.Hour), 10)
+					require.NoError(t, err)
+					assert.Zero(t, deleted)
+				}
+
+				created, err := repo.CreateBatch(ctx, []*types.LogEntry{newEntry("e1", "user.signed_in", "u1", now)})
+				require.NoError(t, err)
+				assert.Len(t, created, tt.wantCreated)
+
+				// The stored entry of e2 still drops its redeliveries once its processed event expired
+				created, err = repo.CreateBatch(ctx, []*types.LogEntry{newEntry("e2", "user.signed_in", "u1", now)})
+				require.NoError(t, err)
+				assert.Empty(t, created)
+			})
+		}
+	}
+}
+
+func TestLoggerRepository_CountByBucket(t *testing.T) {
+	day := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		rollup bool
+		query  types.StatsQuery
+		want   []types.StatsCount
+	}{
+		{
+			name:  "hourly entries",
+			query: types.StatsQuery{From: day, To: day.Add(24 * time.Hour), Bucket: types.StatsBucketHour},
+			want: []types.StatsCount{
+				{BucketStart: day.Add(9 * time.Hour), EventType: "user.signed_in", Count: 2},
+				{BucketStart: day.Add(9 * time.Hour), EventType: "user.signed_out", Count: 1},
+				{BucketStart: day.Add(14 * time.Hour), EventType: "user.signed_in", Count: 1},
+			},
+		},
+		{
+			name:   "daily rollup of a single event type",
+			rollup: true,
+			query:  types.StatsQuery{EventTypes: []string{"user.signed_in"}, From: day, To: day.Add(48 * time.Hour), Bucket: types.StatsBucketDay},
+			want: []types.StatsCount{
+				{BucketStart: day, EventType: "user.signed_in", Count: 3},
+				{BucketStart: day.Add(24 * time.Hour), EventType: "user.signed_in", Count: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for name, repo := range newRepositories(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				_, err := repo.CreateBatch(ctx, []*types.LogEntry{
+					newEntry("", "user.signed_in", "u1", day.Add(9*time.Hour)),
+					newEntry("", "user.signed_in", "u2", day.Add(9*time.Hour+30*time.Minute)),
+					newEntry("", "user.signed_out", "u1", day.Add(9*time.Hour+45*time.Minute)),
+					newEntry("", "user.signed_in", "u1", day.Add(14*time.Hour)),
+					newEntry("", "user.signed_in", "u1", day.Add(30*time.Hour)),
+				})
+				require.NoError(t, err)
+
+				countByBucket := repo.CountByBucket
+				if tt.rollup {
+					countByBucket = repo.CountRollupByBucket
+				}
+				counts, err := countByBucket(ctx, tt.query)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, counts)
+			})
+		}
+	}
+}
+
+// newSQLiteLikeRepository returns a SQLite repository without the FTS5 table, like a build without -tags sqlite_fts5
+func newSQLiteLikeRepository(t *testing.T) *repositories.BunLoggerRepository {
+	t.Helper()
+
+	db, _, _ := newSQLiteDB(t)
+	_, err := db.ExecContext(context.Background(), `DROP TRIGGER IF EXISTS log_entries_fts_insert;
+DROP TRIGGER IF EXISTS log_entries_fts_delete;
+DROP TRIGGER IF EXISTS log_entries_fts_update;
+DROP TABLE IF EXISTS log_entries_fts;`)
+	require.NoError(t, err)
+
+	repo := repositories.NewBunLoggerRepository(db)
+	backend, err := repo.SearchBackend(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, repositories.SearchBackendSQLiteLike, backend)
+	return repo
+}
+
+func TestLoggerRepository_Search(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		want  []string
+	}{
+		{name: "event type", terms: []string{"signed_in"}, want: []string{"e1"}},
+		{name: "details case insensitive", terms: []string{"ALICE@example"}, want: []string{"e2", "e1"}},
+		{name: "every term", terms: []string{"alice", "chrome"}, want: []string{"e2"}},
+		{name: "no match", terms: []string{"mallory"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		repos := newRepositories(t)
+		repos["sqlite like"] = newSQLiteLikeRepository(t)
+		for name, repo := range repos {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				now := time.Now()
+				first := newEntry("e1", "user.signed_in", "u1", now)
+				first.Details = json.RawMessage(`{"email":"alice@example.com"}`)
+				second := newEntry("e2", "user.updated", "u1", now.Add(time.Second))
+				second.Details = json.RawMessage(`{"email":"alice@example.com"}`)
+				second.UserAgent = new("Chrome/142")
+				_, err := repo.CreateBatch(ctx, []*types.LogEntry{first, second})
+				require.NoError(t, err)
+
+				entries, _, err := repo.Search(ctx, tt.terms, types.LogEntryQuery{Limit: 10, Order: types.SortOrderDesc})
+				require.NoError(t, err)
+				got := make([]string, 0, len(entries))
+				for _, entry := range entries {
+					got = append(got, *entry.EventID)
+				}
+				assert.Equal(t, tt.want, got)
+			})
+		}
+	}
+}
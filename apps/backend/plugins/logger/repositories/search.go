@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+const (
+	// PostgresSearchVector is the text search vector of an entry. The GIN index is built on this
+	// exact expression, a search only uses the index when it repeats it.
+	PostgresSearchVector = `to_tsvector('simple', coalesce(event_type, '') || ' ' || coalesce(user_id, '') || ' ' || coalesce(ip_address, '') || ' ' || coalesce(user_agent, '') || ' ' || coalesce(details::text, ''))`
+	// SQLiteSearchTable is the FTS5 table indexing the entries on SQLite, it only exists when
+	// SQLite was built with FTS5
+	SQLiteSearchTable = "log_entries_fts"
+	// sqliteSearchDocument is the searched text of an entry on SQLite without the FTS5 table
+	sqliteSearchDocument = `event_type || ' ' || coalesce(user_id, '') || ' ' || coalesce(ip_address, '') || ' ' || coalesce(user_agent, '') || ' ' || details`
+	// sqliteTrigramLength is the shortest term the trigram tokenizer of the FTS5 table can match
+	sqliteTrigramLength = 3
+)
+
+// The search backends reported by SearchBackend
+const (
+	SearchBackendPostgres   = "postgres full text search"
+	SearchBackendMySQL      = "mysql full text search"
+	SearchBackendSQLiteFTS5 = "sqlite fts5"
+	// SearchBackendSQLiteLike scans the table, SQLite was built without -tags sqlite_fts5
+	SearchBackendSQLiteLike = "sqlite like"
+)
+
+// SearchBackend names how Search matches the terms on the database of the repository
+func (r *BunLoggerRepository) SearchBackend(ctx context.Context) (string, error) {
+	switch r.db.Dialect().Name() {
+	case dialect.PG:
+		return SearchBackendPostgres, nil
+	case dialect.MySQL:
+		return SearchBackendMySQL, nil
+	}
+	hasSearchTable, err := r.hasSQLiteSearchTable(ctx)
+	if err != nil {
+		return "", err
+	}
+	if hasSearchTable {
+		return SearchBackendSQLiteFTS5, nil
+	}
+	return SearchBackendSQLiteLike, nil
+}
+
+// Search retrieves a page of the entries matching the query that contain every term
+func (r *BunLoggerRepository) Search(ctx context.Context, terms []string, query types.LogEntryQuery) ([]types.LogEntry, *string, error) {
+	var (
+		condition string
+		args      []any
+		err       error
+	)
+	switch r.db.Dialect().Name() {
+	case dialect.PG:
+		condition, args = PostgresSearchVector+" @@ to_tsquery('simple', ?)", []any{postgresSearchQuery(terms)}
+	case dialect.MySQL:
+		condition, args = "MATCH(search_text) AGAINST (? IN BOOLEAN MODE)", []any{mysqlSearchQuery(terms)}
+	default:
+		condition, args, err = r.sqliteSearchCondition(ctx, terms)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return r.list(ctx, query, func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where(condition, args...)
+	})
+}
+
+// postgresSearchQuery builds a tsquery that matches every quoted term as a prefix
+func postgresSearchQuery(terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.ReplaceAll(term, `\`, `\\`)
+		term = strings.ReplaceAll(term, `'`, `''`)
+		quoted = append(quoted, "'"+term+"':*")
+	}
+	return strings.Join(quoted, " & ")
+}
+
+// mysqlSearchQuery builds a boolean mode search that requires every term as a phrase
+func mysqlSearchQuery(terms []string) string {
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term = strings.ReplaceAll(term, `"`, ""); term != "" {
+			phrases = append(phrases, `+"`+term+`"`)
+		}
+	}
+	return strings.Join(phrases, " ")
+}
+
+// sqliteSearchCondition matches the terms through the FTS5 table, or with LIKE without it
+func (r *BunLoggerRepository) sqliteSearchCondition(ctx context.Context, terms []string) (string, []any, error) {
+	hasSearchTable, err := r.hasSQLiteSearchTable(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		conditions []string
+		args       []any
+		phrases    []string
+	)
+	for _, term := range terms {
+		if hasSearchTable && utf8.RuneCountInString(term) >= sqliteTrigramLength {
+			phrases = append(phrases, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+			continue
+		}
+		conditions = append(conditions, "("+sqliteSearchDocument+") LIKE ? ESCAPE '"+likeEscapeChar+"'")
+		args = append(args, "%"+escapeLike(term)+"%")
+	}
+	if len(phrases) > 0 {
+		conditions = append(conditions, "id IN (SELECT rowid FROM "+SQLiteSearchTable+" WHERE "+SQLiteSearchTable+" MATCH ?)")
+		args = append(args, strings.Join(phrases, " "))
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// hasSQLiteSearchTable reports whether the migrations created the FTS5 table
+func (r *BunLoggerRepository) hasSQLiteSearchTable(ctx context.Context) (bool, error) {
+	count, err := r.db.NewSelect().
+		Table("sqlite_master").
+		Where("type = 'table' AND name = ?", SQLiteSearchTable).
+		Count(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the log search table: %w", err)
+	}
+	return count > 0, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so that the value is matched literally
+func escapeLike(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch r {
+		case '%', '_', '!':
+			b.WriteString(likeEscapeChar)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// statsBucketLayout is the format of the bucket starts built by bucketStartExpr
+const statsBucketLayout = "2006-01-02 15:04:05"
+
+type statsRow struct {
+	BucketStart string `bun:"bucket_start"`
+	EventType   string `bun:"event_type"`
+	EventCount  int64  `bun:"event_count"`
+}
+
+// CountByBucket counts the log entries matching the query by bucket and event type
+func (r *BunLoggerRepository) CountByBucket(ctx context.Context, query types.StatsQuery) ([]types.StatsCount, error) {
+	bucketExpr := bucketStartExpr(r.db.Dialect().Name(), "created_at", query.Bucket)
+	filter := types.LogEntryFilter{EventTypes: query.EventTypes, From: &query.From, To: &query.To}
+
+	var rows []statsRow
+	if err := r.db.NewSelect().
+		Model((*types.LogEntry)(nil)).
+		ColumnExpr(bucketExpr+" AS bucket_start").
+		Column("event_type").
+		ColumnExpr("COUNT(*) AS event_count").
+		ApplyQueryBuilder(applyLogEntryFilter(filter)).
+		GroupExpr(bucketExpr).
+		Group("event_type").
+		OrderExpr("bucket_start ASC").
+		OrderExpr("event_type ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to count log entries: %w", err)
+	}
+	return statsCounts(rows)
+}
+
+// CountRollupByBucket sums the hourly rollup rows matching the query by bucket and event type
+func (r *BunLoggerRepository) CountRollupByBucket(ctx context.Context, query types.StatsQuery) ([]types.StatsCount, error) {
+	bucketExpr := bucketStartExpr(r.db.Dialect().Name(), "bucket_start", query.Bucket)
+
+	var rows []statsRow
+	if err := r.db.NewSelect().
+		Model((*types.StatsRollup)(nil)).
+		ColumnExpr(bucketExpr+" AS bucket_start").
+		Column("event_type").
+		ColumnExpr("SUM(event_count) AS event_count").
+		ApplyQueryBuilder(func(qb bun.QueryBuilder) bun.QueryBuilder {
+			if len(query.EventTypes) > 0 {
+				qb = qb.WhereGroup(" AND ", func(qb bun.QueryBuilder) bun.QueryBuilder {
+					for _, eventType := range query.EventTypes {
+						qb = qb.WhereOr(eventTypeCondition(eventType))
+					}
+					return qb
+				})
+			}
+			return qb.
+				Where("bucket_start >= ?", query.From.UTC()).
+				Where("bucket_start < ?", query.To.UTC())
+		}).
+		GroupExpr(bucketExpr).
+		Group("event_type").
+		OrderExpr("bucket_start ASC").
+		OrderExpr("event_type ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to count log stats rollup: %w", err)
+	}
+	return statsCounts(rows)
+}
+
+func statsCounts(rows []statsRow) ([]types.StatsCount, error) {
+	counts := make([]types.StatsCount, 0, len(rows))
+	for _, row := range rows {
+		bucketStart, err := time.ParseInLocation(statsBucketLayout, row.BucketStart, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse stats bucket %q: %w", row.BucketStart, err)
+		}
+		counts = append(counts, types.StatsCount{
+			BucketStart: bucketStart,
+			EventType:   row.EventType,
+			Count:       row.EventCount,
+		})
+	}
+	return counts, nil
+}
+
+// bucketStartExpr returns an expression formatting the start of the bucket of a UTC timestamp
+func bucketStartExpr(name dialect.Name, column string, bucket types.StatsBucket) string {
+	switch name {
+	case dialect.PG:
+		return "to_char(date_trunc('" + string(bucket) + "', " + column + "), 'YYYY-MM-DD HH24:MI:SS')"
+	case dialect.MySQL:
+		switch bucket {
+		case types.StatsBucketHour:
+			return "DATE_FORMAT(" + column + ", '%Y-%m-%d %H:00:00')"
+		case types.StatsBucketWeek:
+			return "DATE_FORMAT(DATE_SUB(" + column + ", INTERVAL WEEKDAY(" + column + ") DAY), '%Y-%m-%d 00:00:00')"
+		default:
+			return "DATE_FORMAT(" + column + ", '%Y-%m-%d 00:00:00')"
+		}
+	default:
+		switch bucket {
+		case types.StatsBucketHour:
+			return "strftime('%Y-%m-%d %H:00:00', " + column + ")"
+		case types.StatsBucketWeek:
+			// Moves forward to the next Sunday, or stays on a Sunday, then back to its Monday
+			return "strftime('%Y-%m-%d 00:00:00', " + column + ", 'weekday 0', '-6 days')"
+		default:
+			return "strftime('%Y-%m-%d 00:00:00', " + column + ")"
+		}
+	}
+}
+
+// StatsRollupCounts accumulates the entries of the hourly rollup before they are upserted
+type StatsRollupCounts map[statsRollupKey]int64
+
+type statsRollupKey struct {
+	bucketStart time.Time
+	eventType   string
+}
+
+// Add counts one entry of the event type created at the given time
+func (c StatsRollupCounts) Add(eventType string, createdAt time.Time) {
+	c[statsRollupKey{bucketStart: createdAt.UTC().Truncate(time.Hour), eventType: eventType}]++
+}
+
+// incrementStatsRollup adds the entries to the hourly rollup with one upsert statement
+func incrementStatsRollup(ctx context.Context, db bun.IDB, entries []*types.LogEntry) error {
+	counts := make(StatsRollupCounts)
+	for _, entry := range entries {
+		counts.Add(entry.EventType, entry.CreatedAt)
+	}
+	return UpsertStatsRollup(ctx, db, counts)
+}
+
+// UpsertStatsRollup adds the counts to the hourly rollup with one upsert statement
+func UpsertStatsRollup(ctx context.Context, db bun.IDB, counts StatsRollupCounts) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	rows := make([]*types.StatsRollup, 0, len(counts))
+	for key, count := range counts {
+		rows = append(rows, &types.StatsRollup{
+			BucketStart: key.bucketStart,
+			EventType:   key.eventType,
+			EventCount:  count,
+		})
+	}
+	// A stable order takes the row locks of concurrent upserts in the same order
+	slices.SortFunc(rows, func(a, b *types.StatsRollup) int {
+		if c := a.BucketStart.Compare(b.BucketStart); c != 0 {
+			return c
+		}
+		return strings.Compare(a.EventType, b.EventType)
+	})
+
+	query := db.NewInsert().Model(&rows)
+	if db.Dialect().Name() == dialect.MySQL {
+		query = query.On("DUPLICATE KEY UPDATE").Set("event_count = event_count + VALUES(event_count)")
+	} else {
+		query = query.On("CONFLICT (bucket_start, event_type) DO UPDATE").Set("event_count = ?TableAlias.event_count + EXCLUDED.event_count")
+	}
+	if _, err := query.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update log stats rollup: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+	"github.com/Authula/authula-playground/requestid"
+)
+
+func TestRequestIDCorrelation(t *testing.T) {
+	const requestID = "req-1"
+
+	tests := []struct {
+		name string
+		// publish publishes an event while the request with requestID is handled
+		publish       func(t *testing.T, plugin *LoggerPlugin, bus models.EventBus, req *http.Request)
+		wantRequestID bool
+	}{
+		{
+			name: "event published by the logger plugin",
+			publish: func(t *testing.T, plugin *LoggerPlugin, bus models.EventBus, req *http.Request) {
+				next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					reqCtx, _ := models.GetRequestContext(r.Context())
+					reqCtx.SetJSONResponse(http.StatusUnauthorized, map[string]any{})
+				})
+				plugin.monitorSignIns(next).ServeHTTP(httptest.NewRecorder(), req)
+			},
+			wantRequestID: true,
+		},
+		{
+			name: "event published by Authula or another plugin on its own bus",
+			publish: func(t *testing.T, plugin *LoggerPlugin, bus models.EventBus, req *http.Request) {
+				require.NoError(t, bus.Publish(req.Context(), models.Event{Type: "user.signed_in", Payload: []byte(`{}`)}))
+			},
+		},
+		{
+			name: "event published by the logger plugin outside a request",
+			publish: func(t *testing.T, plugin *LoggerPlugin, bus models.EventBus, req *http.Request) {
+				require.NoError(t, plugin.ctx.EventBus.Publish(context.Background(), models.Event{Type: "logger.max_log_count_reached", Payload: []byte(`{}`)}))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := &recordingEventBus{}
+			plugin := &LoggerPlugin{
+				// Like Init, only the bus of the logger plugin is wrapped
+				ctx:    &models.PluginContext{EventBus: requestid.WrapEventBus(bus)},
+				logger: slog.New(slog.DiscardHandler),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/email-password/sign-in", strings.NewReader(`{"email":"user@example.com"}`))
+			reqCtx := &models.RequestContext{
+				Request:         req,
+				Values:          map[string]any{requestid.MetadataKey: requestID},
+				ResponseHeaders: make(http.Header),
+			}
+			req = req.WithContext(models.NewContextWithRequestContext(req.Context(), reqCtx))
+			tt.publish(t, plugin, bus, req)
+
+			require.Len(t, bus.events, 1)
+			var entry types.LogEntry
+			entry.ApplyEventFields(bus.events[0].Payload, bus.events[0].Metadata)
+			if !tt.wantRequestID {
+				assert.Nil(t, entry.RequestID)
+				return
+			}
+			require.NotNil(t, entry.RequestID)
+			assert.Equal(t, requestID, *entry.RequestID)
+
+			// Handlers of a correlated event, such as the threat detector, run with its request ID
+			var handled string
+			handler := requestid.HandleEvents(func(ctx context.Context, event models.Event) error {
+				handled = requestid.FromContext(ctx)
+				return nil
+			})
+			require.NoError(t, handler(context.Background(), bus.events[0]))
+			assert.Equal(t, requestID, handled)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Authula/authula/models"
+)
+
+type connectionWriterKey struct{}
+
+// StreamingHandler wraps the Authula handler so that the export and stream routes can stream their body
+func StreamingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), connectionWriterKey{}, w)))
+	})
+}
+
+// connectionWriter returns the response writer of the connection stored by StreamingHandler
+func connectionWriter(r *http.Request) (http.ResponseWriter, bool) {
+	w, ok := r.Context().Value(connectionWriterKey{}).(http.ResponseWriter)
+	return w, ok
+}
+
+// writeStreamingHeader sends the status line with the CORS and hook headers the router would add
+func writeStreamingHeader(w http.ResponseWriter, r *http.Request, cors models.CORSConfig, status int) {
+	header := w.Header()
+	if reqCtx, ok := models.GetRequestContext(r.Context()); ok {
+		for key, values := range reqCtx.ResponseHeaders {
+			header[key] = slices.Clone(values)
+		}
+	}
+	applyCORS(header, r, cors)
+	w.WriteHeader(status)
+}
+
+// applyCORS sets the CORS headers of a simple request the way the Authula router does
+func applyCORS(header http.Header, r *http.Request, cors models.CORSConfig) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	header.Add("Vary", "Origin")
+	if cors.AllowCredentials && slices.Contains(cors.AllowedOrigins, "*") {
+		return
+	}
+	if !isOriginAllowed(origin, cors.AllowedOrigins) {
+		return
+	}
+
+	header.Set("Access-Control-Allow-Origin", origin)
+	if cors.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(cors.ExposedHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(cors.ExposedHeaders, ", "))
+	}
+}
+
+func isOriginAllowed(origin string, allowedOrigins []string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if strings.HasPrefix(allowed, "*.") && strings.HasSuffix(origin, strings.TrimPrefix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// RetentionPruner periodically deletes the expired logs, the oldest logs above MaxLogCount, stale tombstones and expired processed events
+type RetentionPruner struct {
+	logger   models.Logger
+	service  services.LoggerService
+	config   types.LoggerPluginConfig
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewRetentionPruner(logger models.Logger, service services.LoggerService, config types.LoggerPluginConfig) *RetentionPruner {
+	return &RetentionPruner{
+		logger:   logger,
+		service:  service,
+		config:   config,
+		interval: config.RetentionPruneInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs a first prune right away and then one every interval until Close is called
+func (p *RetentionPruner) Start() {
+	go p.runPruneLoop()
+}
+
+// Close stops the prune loop and waits for a running prune to finish
+func (p *RetentionPruner) Close() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *RetentionPruner) runPruneLoop() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	defer close(p.done)
+
+	p.prune()
+
+	for {
+		select {
+		case <-p.stop:
+			p.logger.Debug("log retention pruner stopped")
+			return
+		case <-ticker.C:
+			p.prune()
+		}
+	}
+}
+
+func (p *RetentionPruner) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+	defer cancel()
+
+	// Close must not wait for a long prune, abort it instead
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if p.config.HasTimeRetention() {
+		result, err := p.service.PruneExpiredLogs(ctx)
+		if err != nil {
+			p.logger.Error("failed to prune expired log entries", "error", err)
+		}
+		if result != nil {
+			p.logger.Info("pruned expired log entries", "deleted", result.TotalDeleted, "rules", result.Rules)
+		}
+	}
+
+	if p.config.RetentionMode == types.RetentionModePrune {
+		if _, err := p.service.PruneToMaxLogCount(ctx); err != nil {
+			p.logger.Error("failed to prune log entries", "error", err)
+		}
+	}
+
+	// Expired and archived entries leave tombstones behind that no longer link to a remaining entry
+	if deleted, err := p.service.PruneErasedEntries(ctx); err != nil {
+		p.logger.Error("failed to prune log entry tombstones", "error", err)
+	} else if deleted > 0 {
+		p.logger.Debug("pruned log entry tombstones", "deleted", deleted)
+	}
+
+	if deleted, err := p.service.PruneProcessedEvents(ctx); err != nil {
+		p.logger.Error("failed to prune processed events", "error", err)
+	} else if deleted > 0 {
+		p.logger.Debug("pruned processed events", "deleted", deleted)
+	}
+}
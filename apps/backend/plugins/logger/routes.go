@@ -5,23 +5,179 @@ import (
 
 	"github.com/Authula/authula/models"
 
+	"github.com/Authula/authula-playground/adminauth"
 	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
 )
 
 const rateLimitKey = "plugin:logger:count"
 
 // Routes creates and returns the plugin routes
-func Routes(logger models.Logger, service services.LoggerService) []models.Route {
+func Routes(logger models.Logger, service services.LoggerService, eventFilter *EventFilter, stream *LogStream, getConfig func() *models.Config, config types.LoggerPluginConfig) []models.Route {
 	logCountHandler := &LogCountHandler{
 		service: service,
 		logger:  logger,
 	}
+	listLogEntriesHandler := &ListLogEntriesHandler{
+		service: service,
+		logger:  logger,
+	}
+	getLogEntryHandler := &GetLogEntryHandler{
+		service: service,
+		logger:  logger,
+	}
+	deleteLogEntryHandler := &DeleteLogEntryHandler{
+		service: service,
+		logger:  logger,
+	}
+	deleteLogEntriesHandler := &DeleteLogEntriesHandler{
+		service: service,
+		logger:  logger,
+	}
+	exportLogEntriesHandler := &ExportLogEntriesHandler{
+		service:   service,
+		logger:    logger,
+		getConfig: getConfig,
+	}
+	logStatsHandler := &LogStatsHandler{
+		service: service,
+		logger:  logger,
+	}
+	verifyChainHandler := &VerifyChainHandler{
+		service: service,
+		logger:  logger,
+	}
+	myActivityHandler := &MyActivityHandler{
+		service: service,
+		logger:  logger,
+	}
+	streamLogEntriesHandler := &StreamLogEntriesHandler{
+		service:           service,
+		stream:            stream,
+		logger:            logger,
+		getConfig:         getConfig,
+		heartbeatInterval: config.StreamHeartbeatInterval,
+		maxResumeEntries:  config.StreamMaxResumeEntries,
+	}
+	listSecurityAlertsHandler := &ListSecurityAlertsHandler{
+		service: service,
+		logger:  logger,
+	}
+	acknowledgeSecurityAlertHandler := &AcknowledgeSecurityAlertHandler{
+		service: service,
+		logger:  logger,
+	}
+	listDeadLettersHandler := &ListDeadLettersHandler{
+		service: service,
+		logger:  logger,
+	}
+	replayDeadLetterHandler := &ReplayDeadLetterHandler{
+		service: service,
+		logger:  logger,
+	}
+	eraseUserLogsHandler := &EraseUserLogsHandler{
+		service: service,
+		logger:  logger,
+	}
+	eventFilterConfigHandler := &EventFilterConfigHandler{
+		eventFilter: eventFilter,
+	}
 
 	return []models.Route{
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/count",
+			Handler:  logCountHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/entries",
+			Handler:  listLogEntriesHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodDelete,
+			Path:     "/logger/entries",
+			Handler:  deleteLogEntriesHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/entries/{id}",
+			Handler:  getLogEntryHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodDelete,
+			Path:     "/logger/entries/{id}",
+			Handler:  deleteLogEntryHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/export",
+			Handler:  exportLogEntriesHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/stats",
+			Handler:  logStatsHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/verify",
+			Handler:  verifyChainHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/stream",
+			Handler:  streamLogEntriesHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/alerts",
+			Handler:  listSecurityAlertsHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/logger/alerts/{id}/acknowledge",
+			Handler:  acknowledgeSecurityAlertHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/dead-letters",
+			Handler:  listDeadLettersHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/logger/dead-letters/{id}/replay",
+			Handler:  replayDeadLetterHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/logger/users/{user_id}/erase",
+			Handler:  eraseUserLogsHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
+		},
 		{
 			Method:  http.MethodGet,
-			Path:    "/logger/count",
-			Handler: logCountHandler.Handler(),
+			Path:    "/logger/me",
+			Handler: myActivityHandler.Handler(),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/logger/config",
+			Handler:  eventFilterConfigHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDLoggerAdmin.String()),
 		},
 	}
 }
@@ -53,8 +209,18 @@ func (h *LogCountHandler) Handler() http.HandlerFunc {
 			return
 		}
 
+		duplicatesDropped, err := h.service.DuplicatesDropped(r.Context())
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to get the number of dropped duplicates",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
 		reqCtx.SetJSONResponse(http.StatusOK, map[string]any{
-			"logCount": logCount,
+			"logCount":          logCount,
+			"duplicatesDropped": duplicatesDropped,
 		})
 	}
 }
@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+
+	"github.com/Authula/authula"
+	authulaconfig "github.com/Authula/authula/config"
+	authulaevents "github.com/Authula/authula/events"
+	"github.com/Authula/authula/models"
+	coreservices "github.com/Authula/authula/services"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// testUserHeader stands in for the session auth hook, it carries the ID of the signed in user
+const testUserHeader = "X-Test-User-ID"
+
+// testRoleHeader stands in for a hook of the app storing the roles of the user in the session values
+const testRoleHeader = "X-Test-Role"
+
+// testOrigin is the only origin allowed by the CORS config of the test instance
+const testOrigin = "https://app.example.com"
+
+// newTestAuth creates an Authula instance with the logger plugin on an in-memory SQLite database
+// and the in-memory event bus
+func newTestAuth(t *testing.T) (*authula.Auth, *LoggerPlugin) {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Every connection would open its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	db := bun.NewDB(sqlDB, sqlitedialect.New())
+
+	config := authulaconfig.NewConfig(
+		authulaconfig.WithBasePath("/api/auth"),
+		authulaconfig.WithDatabase(models.DatabaseConfig{
+			Provider: "sqlite",
+		}),
+		authulaconfig.WithEventBus(models.EventBusConfig{
+			Provider: authulaevents.ProviderGoChannel,
+		}),
+		authulaconfig.WithSecurity(models.SecurityConfig{
+			CORS: models.CORSConfig{AllowedOrigins: []string{testOrigin}},
+		}),
+	)
+	plugin := New(types.LoggerPluginConfig{
+		Enabled:            true,
+		AdminUserIDs:       []string{"admin"},
+		AdminEmails:        []string{"owner@example.com"},
+		AdminRoles:         []string{"admin"},
+		IncludeEventTypes:  []string{"user.signed_in"},
+		WriteFlushInterval: 10 * time.Millisecond,
+	})
+	auth := authula.New(&authula.AuthConfig{
+		Config:  config,
+		Plugins: []models.Plugin{plugin},
+		DB:      db,
+	})
+	auth.RegisterHook(models.Hook{
+		Stage: models.HookBefore,
+		Handler: func(reqCtx *models.RequestContext) error {
+			if userID := reqCtx.Request.Header.Get(testUserHeader); userID != "" {
+				reqCtx.UserID = &userID
+			}
+			if role := reqCtx.Request.Header.Get(testRoleHeader); role != "" {
+				reqCtx.Values["role"] = role
+			}
+			return nil
+		},
+		// Runs before the admin hook of the plugin
+		Order: 10,
+	})
+	t.Cleanup(func() {
+		_ = auth.ClosePlugins()
+		_ = auth.CloseSystems()
+		_ = db.Close()
+	})
+	return auth, plugin
+}
+
+// storeTestEvents publishes sign-in events of u1 and waits until the plugin has stored them. The
+// bus drops events published before the subscription of the plugin is running, so the first event
+// is published until it is stored, redeliveries are dropped as duplicates. The other events follow
+// once, the bus crashes when the plugin unsubscribes while a message is still being consumed.
+func storeTestEvents(t *testing.T, plugin *LoggerPlugin, events int) {
+	t.Helper()
+
+	ctx := context.Background()
+	publish := func(i int) error {
+		return plugin.ctx.EventBus.Publish(ctx, models.Event{
+			ID:      fmt.Sprintf("e%d", i),
+			Type:    "user.signed_in",
+			Payload: json.RawMessage(`{"user_id":"u1"}`),
+		})
+	}
+	stored := func(want int) func() bool {
+		return func() bool {
+			count, err := plugin.loggerService.GetLogCount(ctx)
+			return err == nil && count == int64(want)
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return publish(0) == nil && stored(1)()
+	}, 5*time.Second, 50*time.Millisecond)
+	for i := 1; i < events; i++ {
+		require.NoError(t, publish(i))
+	}
+	require.Eventually(t, stored(events), 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLogCountHandler(t *testing.T) {
+	const events = 3
+
+	auth, plugin := newTestAuth(t)
+	handler := auth.Handler()
+
+	storeTestEvents(t, plugin, events)
+
+	tests := []struct {
+		name       string
+		userID     string
+		wantStatus int
+		wantCount  int64
+	}{
+		{name: "signed out", wantStatus: http.StatusUnauthorized},
+		{name: "not an admin", userID: "u1", wantStatus: http.StatusForbidden},
+		{name: "admin", userID: "admin", wantStatus: http.StatusOK, wantCount: events},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/logger/count", nil)
+			if tt.userID != "" {
+				req.Header.Set(testUserHeader, tt.userID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				LogCount int64 `json:"logCount"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCount, body.LogCount)
+		})
+	}
+}
+
+func TestExportLogEntriesHandler(t *testing.T) {
+	const events = 3
+
+	auth, plugin := newTestAuth(t)
+	storeTestEvents(t, plugin, events)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+	}{
+		{name: "streamed", handler: StreamingHandler(auth.Handler())},
+		{name: "buffered by the router", handler: auth.Handler()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/logger/export", nil)
+			req.Header.Set(testUserHeader, "admin")
+			req.Header.Set("Origin", testOrigin)
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, testOrigin, rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+			lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+			require.Len(t, lines, events)
+			for _, line := range lines {
+				var entry types.LogEntry
+				require.NoError(t, json.Unmarshal([]byte(line), &entry))
+				assert.Equal(t, "user.signed_in", entry.EventType)
+			}
+		})
+	}
+}
+
+func TestLoggerAdminHook(t *testing.T) {
+	auth, plugin := newTestAuth(t)
+	handler := auth.Handler()
+
+	userService := plugin.ctx.ServiceRegistry.Get(models.ServiceUser.String()).(coreservices.UserService)
+	ctx := context.Background()
+	owner, err := userService.Create(ctx, "Owner", "Owner@Example.com", true, nil, nil)
+	require.NoError(t, err)
+	// The metadata is sent by the user at sign-up, a role stored in it must not grant access
+	selfAssigned, err := userService.Create(ctx, "Mallory", "mallory@example.com", true, nil, json.RawMessage(`{"role":"admin"}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		userID     string
+		role       string
+		wantStatus int
+	}{
+		{name: "admin user id", userID: "admin", wantStatus: http.StatusOK},
+		{name: "admin email", userID: owner.ID, wantStatus: http.StatusOK},
+		{name: "admin role of the session", userID: "u1", role: "admin", wantStatus: http.StatusOK},
+		{name: "other role of the session", userID: "u1", role: "member", wantStatus: http.StatusForbidden},
+		{name: "admin role in the user metadata", userID: selfAssigned.ID, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/logger/count", nil)
+			req.Header.Set(testUserHeader, tt.userID)
+			if tt.role != "" {
+				req.Header.Set(testRoleHeader, tt.role)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	emailpasswordconstants "github.com/Authula/authula/plugins/email-password/constants"
+	totpconstants "github.com/Authula/authula/plugins/totp/constants"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// activityTitles are the event types shown to users as their account activity
+var activityTitles = map[string]string{
+	emailpasswordconstants.EventUserSignedUp:        "Created your account",
+	emailpasswordconstants.EventUserSignedIn:        "Signed in",
+	emailpasswordconstants.EventUserEmailVerified:   "Verified your email address",
+	emailpasswordconstants.EventUserChangedPassword: "Changed your password",
+	emailpasswordconstants.EventUserEmailChanged:    "Changed your email address",
+	totpconstants.EventTOTPEnabled:                  "Turned on two-factor authentication",
+	totpconstants.EventTOTPDisabled:                 "Turned off two-factor authentication",
+	totpconstants.EventTOTPBackupUsed:               "Used a backup code",
+	totpconstants.EventTOTPDeviceTrusted:            "Trusted a new device",
+}
+
+// activityEventTypes returns the event types of activityTitles in a stable order
+func activityEventTypes() []string {
+	eventTypes := make([]string, 0, len(activityTitles))
+	for eventType := range activityTitles {
+		eventTypes = append(eventTypes, eventType)
+	}
+	slices.Sort(eventTypes)
+	return eventTypes
+}
+
+func newActivityItem(entry types.LogEntry, now time.Time) types.ActivityItem {
+	title, ok := activityTitles[entry.EventType]
+	if !ok {
+		title = entry.EventType
+	}
+
+	return types.ActivityItem{
+		ID:              entry.ID,
+		EventType:       entry.EventType,
+		Title:           title,
+		Device:          describeDevice(entry.UserAgent),
+		IPAddress:       entry.IPAddress,
+		ApproximateTime: approximateTime(entry.CreatedAt, now),
+		OccurredAt:      entry.CreatedAt,
+	}
+}
+
+// browserMarkers and osMarkers are checked in order, e.g. Edge also claims to be Chrome and Safari
+var (
+	browserMarkers = []struct{ marker, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	osMarkers = []struct{ marker, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"CrOS", "ChromeOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Macintosh", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// describeDevice turns a user agent into a short description such as "Firefox on Windows"
+func describeDevice(userAgent *string) string {
+	if userAgent == nil || strings.TrimSpace(*userAgent) == "" {
+		return "Unknown device"
+	}
+
+	var browser, platform string
+	for _, candidate := range browserMarkers {
+		if strings.Contains(*userAgent, candidate.marker) {
+			browser = candidate.name
+			break
+		}
+	}
+	for _, candidate := range osMarkers {
+		if strings.Contains(*userAgent, candidate.marker) {
+			platform = candidate.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform + " device"
+	default:
+		return "Unknown device"
+	}
+}
+
+// approximateTime describes how long ago t was, falling back to the date after a week
+func approximateTime(t time.Time, now time.Time) string {
+	elapsed := now.Sub(t)
+	plural := func(count int, unit string) string {
+		if count == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", count, unit)
+	}
+
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return plural(int(elapsed/time.Minute), "minute")
+	case elapsed < 24*time.Hour:
+		return plural(int(elapsed/time.Hour), "hour")
+	case elapsed < 48*time.Hour:
+		return "yesterday"
+	case elapsed < 7*24*time.Hour:
+		return plural(int(elapsed/(24*time.Hour)), "day")
+	default:
+		return t.UTC().Format("Jan 2, 2006")
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+)
+
+const (
+	logCountAddedStorageKey     = "plugin:logger:count:added"
+	logCountRemovedStorageKey   = "plugin:logger:count:removed"
+	duplicatesDroppedStorageKey = "plugin:logger:duplicates_dropped"
+)
+
+var errLogCountNotSeeded = errors.New("log count has not been seeded")
+
+// LogCounter tracks the number of stored log entries
+type LogCounter interface {
+	// Get returns the current number of log entries
+	Get(ctx context.Context) (int64, error)
+	// Add adds delta to the count, it returns errLogCountNotSeeded when the counter has to be
+	// reseeded from the database
+	Add(ctx context.Context, delta int64) error
+	// Set overwrites the count, it seeds and reconciles the counter from the database
+	Set(ctx context.Context, count int64) error
+	// AddDuplicatesDropped adds delta to the number of redelivered events that were not stored again
+	AddDuplicatesDropped(ctx context.Context, delta int64) error
+	// DuplicatesDropped returns the number of redelivered events that were not stored again
+	DuplicatesDropped(ctx context.Context) (int64, error)
+}
+
+// secondaryStorageLogCounter shares the count between replicas as the difference of an added and a removed key
+type secondaryStorageLogCounter struct {
+	storage models.SecondaryStorage
+}
+
+// NewSecondaryStorageLogCounter creates a counter stored under two keys of the secondary storage
+func NewSecondaryStorageLogCounter(storage models.SecondaryStorage) LogCounter {
+	return &secondaryStorageLogCounter{storage: storage}
+}
+
+func (c *secondaryStorageLogCounter) Get(ctx context.Context) (int64, error) {
+	added, err := c.getKey(ctx, logCountAddedStorageKey)
+	if err != nil {
+		return 0, err
+	}
+	if added == nil {
+		return 0, errLogCountNotSeeded
+	}
+	removed, err := c.getKey(ctx, logCountRemovedStorageKey)
+	if err != nil {
+		return 0, err
+	}
+	if removed == nil {
+		return *added - 1, nil
+	}
+	return *added - 1 - *removed, nil
+}
+
+func (c *secondaryStorageLogCounter) getKey(ctx context.Context, key string) (*int64, error) {
+	value, err := c.storage.Get(ctx, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	raw, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected log count value type %T", value)
+	}
+	count, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log count value: %w", err)
+	}
+	return &count, nil
+}
+
+func (c *secondaryStorageLogCounter) Add(ctx context.Context, delta int64) error {
+	key := logCountAddedStorageKey
+	if delta < 0 {
+		key, delta = logCountRemovedStorageKey, -delta
+	}
+	for range delta {
+		count, err := c.storage.Incr(ctx, key, nil)
+		if err != nil {
+			return err
+		}
+		if count == 1 && key == logCountAddedStorageKey {
+			return errLogCountNotSeeded
+		}
+	}
+	return nil
+}
+
+func (c *secondaryStorageLogCounter) Set(ctx context.Context, count int64) error {
+	if err := c.storage.Set(ctx, logCountRemovedStorageKey, "0", nil); err != nil {
+		return err
+	}
+	return c.storage.Set(ctx, logCountAddedStorageKey, strconv.FormatInt(count+1, 10), nil)
+}
+
+func (c *secondaryStorageLogCounter) AddDuplicatesDropped(ctx context.Context, delta int64) error {
+	for range delta {
+		if _, err := c.storage.Incr(ctx, duplicatesDroppedStorageKey, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *secondaryStorageLogCounter) DuplicatesDropped(ctx context.Context) (int64, error) {
+	count, err := c.getKey(ctx, duplicatesDroppedStorageKey)
+	if err != nil || count == nil {
+		return 0, err
+	}
+	return *count, nil
+}
+
+// databaseLogCounter counts the rows of log_entries on every read
+type databaseLogCounter struct {
+	repo repositories.LoggerRepository
+	// duplicatesDropped is per process, without a secondary storage there is nothing to share it through
+	duplicatesDropped atomic.Int64
+}
+
+// NewDatabaseLogCounter creates a counter that always reads the count from the database
+func NewDatabaseLogCounter(repo repositories.LoggerRepository) LogCounter {
+	return &databaseLogCounter{repo: repo}
+}
+
+func (c *databaseLogCounter) Get(ctx context.Context) (int64, error) {
+	count, err := c.repo.Count(ctx)
+	return int64(count), err
+}
+
+func (c *databaseLogCounter) Add(ctx context.Context, delta int64) error {
+	return nil
+}
+
+func (c *databaseLogCounter) Set(ctx context.Context, count int64) error {
+	return nil
+}
+
+func (c *databaseLogCounter) AddDuplicatesDropped(ctx context.Context, delta int64) error {
+	c.duplicatesDropped.Add(delta)
+	return nil
+}
+
+func (c *databaseLogCounter) DuplicatesDropped(ctx context.Context) (int64, error) {
+	return c.duplicatesDropped.Load(), nil
+}
@@ -0,0 +1,96 @@
+package services_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	secondarystorageplugin "github.com/Authula/authula/plugins/secondary-storage"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+)
+
+func TestSecondaryStorageLogCounter(t *testing.T) {
+	tests := []struct {
+		name   string
+		seed   *int64
+		deltas []int64
+		// every delta is applied concurrently by each replica
+		replicas  int
+		wantCount int64
+		wantErr   bool
+	}{
+		{name: "never seeded", wantErr: true},
+		{name: "seeded", seed: new(int64(7)), wantCount: 7},
+		{name: "adds and removes", seed: new(int64(10)), deltas: []int64{5, -3, 1}, replicas: 1, wantCount: 13},
+		{name: "replicas do not lose updates", seed: new(int64(0)), deltas: []int64{25, -10, 1, 1, -2}, replicas: 8, wantCount: 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := secondarystorageplugin.NewMemorySecondaryStorage(secondarystorageplugin.MemoryStorageConfig{})
+			t.Cleanup(func() { _ = storage.Close() })
+			if tt.seed != nil {
+				require.NoError(t, services.NewSecondaryStorageLogCounter(storage).Set(ctx, *tt.seed))
+			}
+
+			var wg sync.WaitGroup
+			for range tt.replicas {
+				counter := services.NewSecondaryStorageLogCounter(storage)
+				for _, delta := range tt.deltas {
+					wg.Go(func() {
+						assert.NoError(t, counter.Add(ctx, delta))
+					})
+				}
+			}
+			wg.Wait()
+
+			count, err := services.NewSecondaryStorageLogCounter(storage).Get(ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestSecondaryStorageLogCounter_DuplicatesDropped(t *testing.T) {
+	tests := []struct {
+		name      string
+		deltas    []int64
+		replicas  int
+		wantCount int64
+	}{
+		{name: "nothing dropped", wantCount: 0},
+		{name: "replicas share the count", deltas: []int64{3, 1, 2}, replicas: 4, wantCount: 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := secondarystorageplugin.NewMemorySecondaryStorage(secondarystorageplugin.MemoryStorageConfig{})
+			t.Cleanup(func() { _ = storage.Close() })
+
+			var wg sync.WaitGroup
+			for range tt.replicas {
+				counter := services.NewSecondaryStorageLogCounter(storage)
+				for _, delta := range tt.deltas {
+					wg.Go(func() {
+						assert.NoError(t, counter.AddDuplicatesDropped(ctx, delta))
+					})
+				}
+			}
+			wg.Wait()
+
+			count, err := services.NewSecondaryStorageLogCounter(storage).DuplicatesDropped(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+	"github.com/Authula/authula-playground/requestid"
+)
+
+// DeleteLogEntry deletes a log entry, keeping its hashes as a tombstone, and writes an audit entry
+func (s *service) DeleteLogEntry(ctx context.Context, id int64, requestedBy string) (*types.DeletionResult, error) {
+	if err := s.repo.Delete(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	s.recordRemoved(ctx, 1)
+
+	result := &types.DeletionResult{EntriesMatched: 1, EntriesDeleted: 1}
+	auditEntryID, err := s.storeDeletionAuditEntry(ctx, map[string]any{
+		"requested_by":    requestedBy,
+		"entry_id":        id,
+		"entries_deleted": result.EntriesDeleted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.AuditEntryID = auditEntryID
+
+	s.logger.Info("deleted log entry", "entry_id", id, "requested_by", requestedBy)
+	return result, nil
+}
+
+// DeleteLogEntries deletes the log entries matching a non-empty filter and writes an audit entry
+func (s *service) DeleteLogEntries(ctx context.Context, request types.DeletionRequest) (*types.DeletionResult, error) {
+	if request.Filter.IsEmpty() {
+		return nil, constants.ErrDeletionFilterRequired
+	}
+
+	if request.DryRun {
+		matched, err := s.repo.CountMatching(ctx, request.Filter)
+		if err != nil {
+			return nil, err
+		}
+		return &types.DeletionResult{DryRun: true, EntriesMatched: matched}, nil
+	}
+
+	result := &types.DeletionResult{}
+	now := time.Now()
+	var err error
+	for {
+		var deleted int64
+		deleted, err = s.repo.EraseMatching(ctx, request.Filter, now, logEntryEraseBatchSize)
+		result.EntriesDeleted += deleted
+		if err != nil || deleted < logEntryEraseBatchSize {
+			break
+		}
+	}
+	result.EntriesMatched = result.EntriesDeleted
+	s.recordRemoved(ctx, result.EntriesDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	auditEntryID, err := s.storeDeletionAuditEntry(ctx, map[string]any{
+		"requested_by":    request.RequestedBy,
+		"filter":          deletionFilterDetails(request.Filter),
+		"entries_deleted": result.EntriesDeleted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.AuditEntryID = auditEntryID
+
+	s.logger.Info("deleted log entries", "entries", result.EntriesDeleted, "requested_by", request.RequestedBy)
+	return result, nil
+}
+
+// storeDeletionAuditEntry appends the audit entry of a deletion to the hash chain
+func (s *service) storeDeletionAuditEntry(ctx context.Context, details map[string]any) (*int64, error) {
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode deletion audit entry: %w", err)
+	}
+	auditEntryID, err := s.storeAuditEntry(ctx, &types.LogEntry{
+		EventType: constants.EventLoggerEntriesDeleted,
+		Details:   raw,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write deletion audit entry: %w", err)
+	}
+	return auditEntryID, nil
+}
+
+// storeAuditEntry stores an entry about a change of the log, even past the max log count
+func (s *service) storeAuditEntry(ctx context.Context, entry *types.LogEntry) (*int64, error) {
+	if id := requestid.FromContext(ctx); id != "" && entry.RequestID == nil {
+		entry.RequestID = &id
+	}
+	created, err := s.repo.CreateBatch(ctx, []*types.LogEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	if len(created) == 0 {
+		return nil, nil
+	}
+	s.recordCreated(ctx, 1)
+	return &created[0].ID, nil
+}
+
+// deletionFilterDetails describes the set fields of a deletion filter for its audit entry
+func deletionFilterDetails(filter types.LogEntryFilter) map[string]any {
+	details := make(map[string]any)
+	if len(filter.EventTypes) > 0 {
+		details["event_types"] = filter.EventTypes
+	}
+	if len(filter.ExcludeEventTypes) > 0 {
+		details["exclude_event_types"] = filter.ExcludeEventTypes
+	}
+	if filter.UserID != nil {
+		details["user_id"] = *filter.UserID
+	}
+	if filter.SessionID != nil {
+		details["session_id"] = *filter.SessionID
+	}
+	if filter.IPAddress != nil {
+		details["ip_address"] = *filter.IPAddress
+	}
+	if filter.RequestID != nil {
+		details["request_id"] = *filter.RequestID
+	}
+	if filter.From != nil {
+		details["from"] = filter.From.UTC()
+	}
+	if filter.To != nil {
+		details["to"] = filter.To.UTC()
+	}
+	if filter.AfterID != nil {
+		details["after_id"] = *filter.AfterID
+	}
+	return details
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Authula/authula/models"
+)
+
+const detectionStorageKeyPrefix = "plugin:logger:detection:"
+
+// DetectionStore keeps the sliding windows of the threat detector
+type DetectionStore interface {
+	// CountInWindow records member under key and returns how many distinct members were recorded
+	// under key during the last window. An empty member is never deduplicated.
+	CountInWindow(ctx context.Context, key string, member string, window time.Duration, now time.Time) (int, error)
+	// StartCooldown reports whether key was not cooling down and, if so, starts a cooldown
+	StartCooldown(ctx context.Context, key string, cooldown time.Duration) (bool, error)
+}
+
+// secondaryStorageDetectionStore approximates the sliding windows with two fixed window counters
+type secondaryStorageDetectionStore struct {
+	storage models.SecondaryStorage
+}
+
+// NewSecondaryStorageDetectionStore creates a detection store on top of the secondary storage
+func NewSecondaryStorageDetectionStore(storage models.SecondaryStorage) DetectionStore {
+	return &secondaryStorageDetectionStore{storage: storage}
+}
+
+func (s *secondaryStorageDetectionStore) CountInWindow(ctx context.Context, key string, member string, window time.Duration, now time.Time) (int, error) {
+	index := now.UnixNano() / int64(window)
+	elapsed := float64(now.UnixNano()%int64(window)) / float64(window)
+	ttl := 2 * window
+
+	currentKey := detectionStorageKeyPrefix + key + ":" + strconv.FormatInt(index, 10)
+	previousKey := detectionStorageKeyPrefix + key + ":" + strconv.FormatInt(index-1, 10)
+
+	current, err := s.getCount(ctx, currentKey)
+	if err != nil {
+		return 0, err
+	}
+
+	isNew := true
+	if member != "" {
+		memberKey := currentKey + ":member:" + member
+		seen, err := s.storage.Get(ctx, memberKey)
+		if err != nil {
+			return 0, err
+		}
+		isNew = seen == nil
+		if isNew {
+			if err := s.storage.Set(ctx, memberKey, "1", &ttl); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if isNew {
+		if current, err = s.storage.Incr(ctx, currentKey, &ttl); err != nil {
+			return 0, err
+		}
+	}
+
+	previous, err := s.getCount(ctx, previousKey)
+	if err != nil {
+		return 0, err
+	}
+	return current + int(float64(previous)*(1-elapsed)), nil
+}
+
+func (s *secondaryStorageDetectionStore) StartCooldown(ctx context.Context, key string, cooldown time.Duration) (bool, error) {
+	cooldownKey := detectionStorageKeyPrefix + "cooldown:" + key
+	// Incr is atomic, only the first replica sees the key being created
+	count, err := s.storage.Incr(ctx, cooldownKey, &cooldown)
+	if err != nil {
+		return false, err
+	}
+	return count == 1, nil
+}
+
+func (s *secondaryStorageDetectionStore) getCount(ctx context.Context, key string) (int, error) {
+	value, err := s.storage.Get(ctx, key)
+	if err != nil || value == nil {
+		return 0, err
+	}
+
+	switch value := value.(type) {
+	case string:
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid detection counter value: %w", err)
+		}
+		return count, nil
+	case int:
+		return value, nil
+	default:
+		return 0, fmt.Errorf("unexpected detection counter value type %T", value)
+	}
+}
+
+// memoryDetectionStore keeps exact sliding windows in the memory of a single process
+type memoryDetectionStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	cooldowns map[string]time.Time
+	sequence  uint64
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	window  time.Duration
+	members map[string]time.Time
+}
+
+// NewMemoryDetectionStore creates a detection store local to this process
+func NewMemoryDetectionStore() DetectionStore {
+	return &memoryDetectionStore{
+		windows:   make(map[string]*memoryWindow),
+		cooldowns: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryDetectionStore) CountInWindow(ctx context.Context, key string, member string, window time.Duration, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, window)
+
+	entries, ok := s.windows[key]
+	if !ok {
+		entries = &memoryWindow{window: window, members: make(map[string]time.Time)}
+		s.windows[key] = entries
+	}
+	if member == "" {
+		s.sequence++
+		member = "\x00" + strconv.FormatUint(s.sequence, 10)
+	}
+	entries.members[member] = now
+
+	for existing, seenAt := range entries.members {
+		if now.Sub(seenAt) >= window {
+			delete(entries.members, existing)
+		}
+	}
+	return len(entries.members), nil
+}
+
+func (s *memoryDetectionStore) StartCooldown(ctx context.Context, key string, cooldown time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if until, ok := s.cooldowns[key]; ok && now.Before(until) {
+		return false, nil
+	}
+	s.cooldowns[key] = now.Add(cooldown)
+	return true, nil
+}
+
+// sweep drops the windows and cooldowns that expired, at most once per window
+func (s *memoryDetectionStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entries := range s.windows {
+		for member, seenAt := range entries.members {
+			if now.Sub(seenAt) >= entries.window {
+				delete(entries.members, member)
+			}
+		}
+		if len(entries.members) == 0 {
+			delete(s.windows, key)
+		}
+	}
+	for key, until := range s.cooldowns {
+		if !now.Before(until) {
+			delete(s.cooldowns, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	secondarystorageplugin "github.com/Authula/authula/plugins/secondary-storage"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+)
+
+// detectionStores creates every detection store, each test gets its own
+func detectionStores(t *testing.T) map[string]func() services.DetectionStore {
+	return map[string]func() services.DetectionStore{
+		"memory": services.NewMemoryDetectionStore,
+		"secondary storage": func() services.DetectionStore {
+			storage := secondarystorageplugin.NewMemorySecondaryStorage(secondarystorageplugin.MemoryStorageConfig{})
+			t.Cleanup(func() { _ = storage.Close() })
+			return services.NewSecondaryStorageDetectionStore(storage)
+		},
+	}
+}
+
+func TestDetectionStore_CountInWindow(t *testing.T) {
+	const window = time.Minute
+	// Aligned to the fixed windows of the secondary storage store, its count is exact there
+	start := time.Unix(0, 0).Add(1000 * window)
+
+	type record struct {
+		member string
+		offset time.Duration
+	}
+	tests := []struct {
+		name      string
+		records   []record
+		wantCount int
+	}{
+		{name: "every record without a member counts", records: []record{{}, {}, {}}, wantCount: 3},
+		{name: "distinct members", records: []record{{member: "a"}, {member: "b"}, {member: "c"}}, wantCount: 3},
+		{name: "repeated members count once", records: []record{{member: "a"}, {member: "a"}, {member: "b"}}, wantCount: 2},
+		{name: "records older than the window expire", records: []record{{member: "a"}, {member: "b"}, {member: "c", offset: 2 * window}}, wantCount: 1},
+	}
+
+	for storeName, newStore := range detectionStores(t) {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				store := newStore()
+
+				var count int
+				for _, record := range tt.records {
+					var err error
+					count, err = store.CountInWindow(ctx, "brute_force:user@example.com", record.member, window, start.Add(record.offset))
+					require.NoError(t, err)
+				}
+				assert.Equal(t, tt.wantCount, count)
+
+				other, err := store.CountInWindow(ctx, "brute_force:other@example.com", "", window, start)
+				require.NoError(t, err)
+				assert.Equal(t, 1, other, "keys have separate windows")
+			})
+		}
+	}
+}
+
+func TestDetectionStore_StartCooldown(t *testing.T) {
+	for storeName, newStore := range detectionStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore()
+
+			first, err := store.StartCooldown(ctx, "brute_force:user@example.com", time.Hour)
+			require.NoError(t, err)
+			assert.True(t, first)
+
+			again, err := store.StartCooldown(ctx, "brute_force:user@example.com", time.Hour)
+			require.NoError(t, err)
+			assert.False(t, again, "the key is cooling down")
+
+			other, err := store.StartCooldown(ctx, "credential_stuffing:203.0.113.7", time.Hour)
+			require.NoError(t, err)
+			assert.True(t, other, "keys cool down separately")
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// ThreatDetector raises security alerts from sliding windows over failed sign-in events
+type ThreatDetector struct {
+	store    DetectionStore
+	repo     repositories.LoggerRepository
+	eventBus models.EventBus
+	logger   models.Logger
+	config   types.DetectionConfig
+}
+
+func NewThreatDetector(store DetectionStore, repo repositories.LoggerRepository, eventBus models.EventBus, logger models.Logger, config types.DetectionConfig) *ThreatDetector {
+	return &ThreatDetector{
+		store:    store,
+		repo:     repo,
+		eventBus: eventBus,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// failedSignIn is the payload of constants.EventUserSignInFailed
+type failedSignIn struct {
+	Email     string `json:"email"`
+	IPAddress string `json:"ip_address"`
+}
+
+// HandleEvent counts a failed sign-in event, errors are logged so that the event is not counted twice
+func (d *ThreatDetector) HandleEvent(ctx context.Context, event models.Event) error {
+	var attempt failedSignIn
+	if err := json.Unmarshal(event.Payload, &attempt); err != nil {
+		d.logger.Warn("ignoring malformed failed sign-in event", "event_id", event.ID, "error", err)
+		return nil
+	}
+	email := strings.ToLower(strings.TrimSpace(attempt.Email))
+	ipAddress := strings.TrimSpace(attempt.IPAddress)
+	now := time.Now().UTC()
+
+	if email != "" {
+		window := d.config.BruteForceWindow
+		count, err := d.store.CountInWindow(ctx, string(types.SecurityAlertBruteForce)+":"+email, "", window, now)
+		if err != nil {
+			d.logger.Error("failed to count failed sign-ins for account", "error", err)
+		} else if count >= d.config.BruteForceThreshold {
+			d.raise(ctx, email, &types.SecurityAlert{
+				AlertType:     types.SecurityAlertBruteForce,
+				Email:         &email,
+				EventCount:    count,
+				Threshold:     d.config.BruteForceThreshold,
+				WindowSeconds: int64(window / time.Second),
+				CreatedAt:     now,
+			})
+		}
+	}
+
+	if email != "" && ipAddress != "" {
+		window := d.config.CredentialStuffingWindow
+		count, err := d.store.CountInWindow(ctx, string(types.SecurityAlertCredentialStuffing)+":"+ipAddress, email, window, now)
+		if err != nil {
+			d.logger.Error("failed to count failed sign-in accounts for ip address", "error", err)
+		} else if count >= d.config.CredentialStuffingThreshold {
+			d.raise(ctx, ipAddress, &types.SecurityAlert{
+				AlertType:     types.SecurityAlertCredentialStuffing,
+				IPAddress:     &ipAddress,
+				EventCount:    count,
+				Threshold:     d.config.CredentialStuffingThreshold,
+				WindowSeconds: int64(window / time.Second),
+				CreatedAt:     now,
+			})
+		}
+	}
+
+	return nil
+}
+
+// raise stores and publishes the alert unless the same alert was raised for subject during the cooldown
+func (d *ThreatDetector) raise(ctx context.Context, subject string, alert *types.SecurityAlert) {
+	cooldown := d.config.Cooldown(time.Duration(alert.WindowSeconds) * time.Second)
+	first, err := d.store.StartCooldown(ctx, string(alert.AlertType)+":"+subject, cooldown)
+	if err != nil {
+		d.logger.Error("failed to check security alert cooldown", "alert_type", alert.AlertType, "error", err)
+		return
+	}
+	if !first {
+		return
+	}
+
+	d.logger.Warn("security alert raised", "alert_type", alert.AlertType, "subject", subject, "count", alert.EventCount)
+	if err := d.repo.CreateSecurityAlert(ctx, alert); err != nil {
+		d.logger.Error("failed to record security alert", "alert_type", alert.AlertType, "error", err)
+	}
+
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		d.logger.Error("failed to encode security alert", "alert_type", alert.AlertType, "error", err)
+		return
+	}
+	if err := d.eventBus.Publish(ctx, models.Event{
+		Type:      constants.EventSecurityAlertPrefix + string(alert.AlertType),
+		Timestamp: alert.CreatedAt,
+		Payload:   payload,
+	}); err != nil {
+		d.logger.Error("failed to publish security alert", "alert_type", alert.AlertType, "error", err)
+	}
+}
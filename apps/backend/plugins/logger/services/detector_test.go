@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// recordingEventBus keeps the published events instead of delivering them
+type recordingEventBus struct {
+	mu     sync.Mutex
+	events []models.Event
+}
+
+func (b *recordingEventBus) Publish(ctx context.Context, event models.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+	return nil
+}
+
+func (b *recordingEventBus) Subscribe(eventType string, handler models.EventHandler) (models.SubscriptionID, error) {
+	return 0, nil
+}
+
+func (b *recordingEventBus) Unsubscribe(eventType string, id models.SubscriptionID) {}
+
+func (b *recordingEventBus) Close() error {
+	return nil
+}
+
+func (b *recordingEventBus) published() []models.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]models.Event(nil), b.events...)
+}
+
+func failedSignInEvent(email string, ipAddress string) models.Event {
+	return models.Event{
+		Type:    constants.EventUserSignInFailed,
+		Payload: json.RawMessage(fmt.Sprintf(`{"email":%q,"ip_address":%q}`, email, ipAddress)),
+	}
+}
+
+func TestThreatDetector_HandleEvent(t *testing.T) {
+	config := types.DetectionConfig{
+		Enabled:                     true,
+		BruteForceThreshold:         3,
+		BruteForceWindow:            time.Minute,
+		CredentialStuffingThreshold: 3,
+		CredentialStuffingWindow:    time.Minute,
+	}
+
+	tests := []struct {
+		name           string
+		events         []models.Event
+		wantAlertTypes []types.SecurityAlertType
+	}{
+		{
+			name: "below the thresholds",
+			events: []models.Event{
+				failedSignInEvent("user@example.com", "203.0.113.7"),
+				failedSignInEvent("user@example.com", "203.0.113.7"),
+			},
+		},
+		{
+			name: "brute force on one account is raised once per cooldown",
+			events: []models.Event{
+				failedSignInEvent("user@example.com", "203.0.113.7"),
+				failedSignInEvent("User@Example.com ", "198.51.100.1"),
+				failedSignInEvent("user@example.com", "198.51.100.2"),
+				failedSignInEvent("user@example.com", "198.51.100.3"),
+			},
+			wantAlertTypes: []types.SecurityAlertType{types.SecurityAlertBruteForce},
+		},
+		{
+			name: "credential stuffing from one ip address",
+			events: []models.Event{
+				failedSignInEvent("a@example.com", "203.0.113.7"),
+				failedSignInEvent("b@example.com", "203.0.113.7"),
+				failedSignInEvent("b@example.com", "203.0.113.7"),
+				failedSignInEvent("c@example.com", "203.0.113.7"),
+			},
+			wantAlertTypes: []types.SecurityAlertType{types.SecurityAlertCredentialStuffing},
+		},
+		{
+			name: "malformed events are ignored",
+			events: []models.Event{
+				{Type: constants.EventUserSignInFailed, Payload: json.RawMessage(`not json`)},
+				{Type: constants.EventUserSignInFailed, Payload: json.RawMessage(`not json`)},
+				{Type: constants.EventUserSignInFailed, Payload: json.RawMessage(`not json`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repositories.NewMemoryLoggerRepository()
+			bus := &recordingEventBus{}
+			detector := services.NewThreatDetector(services.NewMemoryDetectionStore(), repo, bus, slog.New(slog.DiscardHandler), config)
+
+			for _, event := range tt.events {
+				require.NoError(t, detector.HandleEvent(ctx, event))
+			}
+
+			alerts, _, err := repo.ListSecurityAlerts(ctx, types.SecurityAlertQuery{Limit: 10})
+			require.NoError(t, err)
+			published := bus.published()
+			require.Len(t, alerts, len(tt.wantAlertTypes))
+			require.Len(t, published, len(tt.wantAlertTypes))
+			for i, alertType := range tt.wantAlertTypes {
+				assert.Equal(t, alertType, alerts[i].AlertType)
+				assert.Equal(t, config.BruteForceThreshold, alerts[i].Threshold)
+				assert.Equal(t, constants.EventSecurityAlertPrefix+string(alertType), published[i].Type)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// logEntryEraseBatchSize is the number of entries erased per transaction
+const logEntryEraseBatchSize = 500
+
+// EraseUserLogs erases the log entries and other user data of a user and writes a receipt entry
+func (s *service) EraseUserLogs(ctx context.Context, request types.ErasureRequest) (*types.ErasureResult, error) {
+	mode := s.config.ErasureMode
+	pseudonym, err := newErasurePseudonym()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.ErasureResult{Mode: mode}
+	now := time.Now()
+	// Recorded first, the entries of the user in archives restored later are erased with it
+	erasedUser := &types.ErasedUser{
+		Subject:  types.ErasedUserSubject(request.UserID),
+		Mode:     mode,
+		ErasedAt: now.UTC(),
+	}
+	if mode == types.ErasureModeAnonymize {
+		erasedUser.Pseudonym = &pseudonym
+	}
+	if err := s.repo.CreateErasedUser(ctx, erasedUser); err != nil {
+		return nil, err
+	}
+	for {
+		var erased int64
+		erased, err = s.repo.EraseUserEntries(ctx, request.UserID, mode, pseudonym, now, logEntryEraseBatchSize)
+		result.EntriesErased += erased
+		if err != nil || erased < logEntryEraseBatchSize {
+			break
+		}
+	}
+	if mode == types.ErasureModeDelete {
+		s.recordRemoved(ctx, result.EntriesErased)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.erasers != nil {
+		if result.RecordsErased, err = s.erasers.EraseUserData(ctx, request.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	receipt, err := s.newErasureReceipt(request, result, pseudonym)
+	if err != nil {
+		return nil, err
+	}
+	// The receipt is stored even when the max log count was reached, it proves that the erasure ran
+	result.ReceiptID, err = s.storeAuditEntry(ctx, receipt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write erasure receipt: %w", err)
+	}
+
+	s.logger.Info("erased user log entries", "mode", mode, "entries", result.EntriesErased, "records", result.RecordsErased, "requested_by", request.RequestedBy)
+	return result, nil
+}
+
+// newErasureReceipt builds the receipt entry of an erasure, it holds no personal data
+func (s *service) newErasureReceipt(request types.ErasureRequest, result *types.ErasureResult, pseudonym string) (*types.LogEntry, error) {
+	details := map[string]any{
+		"mode":           result.Mode,
+		"entries_erased": result.EntriesErased,
+		"records_erased": result.RecordsErased,
+		"requested_by":   request.RequestedBy,
+	}
+	if result.Mode == types.ErasureModeAnonymize {
+		details["pseudonym"] = pseudonym
+	}
+	if s.config.RedactionHMACKey != "" {
+		details["subject_hmac"] = s.redactor.hmacValue(request.UserID)
+	}
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode erasure receipt: %w", err)
+	}
+
+	receipt := &types.LogEntry{
+		EventType: constants.EventLoggerUserErased,
+		Details:   raw,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if request.EventID != "" {
+		eventID := request.EventID
+		receipt.EventID = &eventID
+	}
+	return receipt, nil
+}
+
+// newErasurePseudonym returns a random user ID for anonymized entries that cannot be linked back
+func newErasurePseudonym() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate erasure pseudonym: %w", err)
+	}
+	return "erased:" + hex.EncodeToString(raw), nil
+}
@@ -3,15 +3,46 @@ package services
 import (
 	"context"
 
+	"github.com/Authula/authula/models"
+
 	"github.com/Authula/authula-playground/plugins/logger/types"
 )
 
 // UseCase defines the interface for logger operations
 type LoggerService interface {
-	CreateLogEntry(ctx context.Context, eventType string, details string) (*types.LogEntry, error)
+	CreateLogEntry(ctx context.Context, event models.Event) (*types.LogEntry, error)
+	CreateLogEntries(ctx context.Context, events []models.Event) ([]*types.LogEntry, error)
+	NewLogEntries(events []models.Event) []*types.LogEntry
+	StoreLogEntries(ctx context.Context, entries []*types.LogEntry) ([]*types.LogEntry, error)
 	GetLogEntry(ctx context.Context, id int64) (*types.LogEntry, error)
 	GetAllLogs(ctx context.Context) ([]types.LogEntry, error)
-	DeleteLogEntry(ctx context.Context, id int64) error
+	ListLogEntries(ctx context.Context, query types.LogEntryQuery) (*types.LogEntriesPage, error)
+	SearchLogEntries(ctx context.Context, terms []string, query types.LogEntryQuery) (*types.LogEntriesPage, error)
+	ListUserActivity(ctx context.Context, userID string, cursor *string, limit int) (*types.ActivityPage, error)
+	ExportLogEntries(ctx context.Context, filter types.LogEntryFilter, order types.SortOrder, fn func(entry *types.LogEntry) error) error
+	GetLogStats(ctx context.Context, query types.StatsQuery) (*types.LogStats, error)
+	ListSecurityAlerts(ctx context.Context, query types.SecurityAlertQuery) (*types.SecurityAlertsPage, error)
+	AcknowledgeSecurityAlert(ctx context.Context, id int64, userID string) (*types.SecurityAlert, error)
+	CreateDeadLetters(ctx context.Context, deadLetters []*types.DeadLetter) error
+	ListDeadLetters(ctx context.Context, query types.DeadLetterQuery) (*types.DeadLettersPage, error)
+	ReplayDeadLetter(ctx context.Context, id int64) (*types.DeadLetterReplay, error)
+	EraseUserLogs(ctx context.Context, request types.ErasureRequest) (*types.ErasureResult, error)
+	DeleteLogEntry(ctx context.Context, id int64, requestedBy string) (*types.DeletionResult, error)
+	DeleteLogEntries(ctx context.Context, request types.DeletionRequest) (*types.DeletionResult, error)
 	GetLogCount(ctx context.Context) (int64, error)
+	DuplicatesDropped(ctx context.Context) (int64, error)
+	SyncLogCount(ctx context.Context) (int64, error)
 	HasReachedMaxLogs(ctx context.Context) (bool, error)
+	PruneToMaxLogCount(ctx context.Context) (int64, error)
+	PruneExpiredLogs(ctx context.Context) (*types.RetentionPruneResult, error)
+	PruneProcessedEvents(ctx context.Context) (int64, error)
+	PruneErasedEntries(ctx context.Context) (int64, error)
+	VerifyChain(ctx context.Context) (*types.ChainVerification, error)
+	CreateChainCheckpoint(ctx context.Context) (*types.ChainCheckpoint, error)
+}
+
+// PartitionService defines the interface for the monthly partitions of log_entries on Postgres
+type PartitionService interface {
+	MaintainPartitions(ctx context.Context) (*types.PartitionMaintenance, error)
+	RestoreArchive(ctx context.Context, path string) (*types.PartitionRestore, error)
 }
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// partitionRestoreBatchSize is the number of archived entries inserted per statement on restore
+const partitionRestoreBatchSize = 500
+
+type partitionService struct {
+	repo   repositories.PartitionRepository
+	config types.PartitionConfig
+}
+
+// NewPartitionService creates the service for the monthly partitions of log_entries on Postgres
+func NewPartitionService(repo repositories.PartitionRepository, config types.PartitionConfig) PartitionService {
+	return &partitionService{repo: repo, config: config}
+}
+
+// MaintainPartitions creates the upcoming monthly partitions and archives the expired ones
+func (s *partitionService) MaintainPartitions(ctx context.Context) (*types.PartitionMaintenance, error) {
+	result := &types.PartitionMaintenance{
+		Created:  []types.LogPartition{},
+		Archived: []types.ArchivedPartition{},
+	}
+
+	current := types.NewLogPartition(time.Now())
+	partition := current
+	for range s.config.PremakeMonths + 1 {
+		created, err := s.repo.CreatePartition(ctx, partition)
+		if err != nil {
+			return result, err
+		}
+		if created {
+			result.Created = append(result.Created, partition)
+		}
+		partition = types.NewLogPartition(partition.To)
+	}
+
+	if !s.config.HasArchival() {
+		return result, nil
+	}
+
+	partitions, err := s.repo.ListPartitions(ctx)
+	if err != nil {
+		return result, err
+	}
+	cutoff := current.From.AddDate(0, -s.config.ArchiveAfterMonths, 0)
+	for _, partition := range partitions {
+		if partition.To.After(cutoff) {
+			break
+		}
+		if partition.Restored {
+			continue
+		}
+		archived, err := s.archivePartition(ctx, partition)
+		if err != nil {
+			return result, err
+		}
+		result.Archived = append(result.Archived, *archived)
+	}
+	return result, nil
+}
+
+// archivePartition writes the entries of the partition to its archive and drops it
+func (s *partitionService) archivePartition(ctx context.Context, partition types.LogPartition) (*types.ArchivedPartition, error) {
+	if err := os.MkdirAll(s.config.ArchiveDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create log archive directory: %w", err)
+	}
+	file, err := os.CreateTemp(s.config.ArchiveDir, partition.ArchiveFileName()+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log archive: %w", err)
+	}
+	tmpPath := file.Name()
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	buffered := bufio.NewWriter(file)
+	compressed := gzip.NewWriter(buffered)
+	encoder := json.NewEncoder(compressed)
+	var entries int64
+	if err := s.repo.StreamPartition(ctx, partition, func(entry *types.LogEntry) error {
+		entries++
+		// Encode terminates every entry with a newline
+		return encoder.Encode(types.NewArchivedLogEntry(entry))
+	}); err != nil {
+		return nil, err
+	}
+	if err := compressed.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write log archive: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write log archive: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to write log archive: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write log archive: %w", err)
+	}
+
+	archived := &types.ArchivedPartition{Partition: partition, Entries: entries}
+	if entries > 0 {
+		archived.File = filepath.Join(s.config.ArchiveDir, partition.ArchiveFileName())
+		if err := os.Rename(tmpPath, archived.File); err != nil {
+			return nil, fmt.Errorf("failed to write log archive: %w", err)
+		}
+	}
+
+	if err := s.repo.DropPartition(ctx, partition, entries); err != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
+// RestoreArchive attaches the partition of an archive again
+func (s *partitionService) RestoreArchive(ctx context.Context, path string) (*types.PartitionRestore, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log archive: %w", err)
+	}
+	defer file.Close()
+
+	decompressed, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", constants.ErrInvalidArchive, err)
+	}
+	defer decompressed.Close()
+
+	decoder := json.NewDecoder(decompressed)
+	decode := func() (*types.LogEntry, error) {
+		var archived types.ArchivedLogEntry
+		if err := decoder.Decode(&archived); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%w: %w", constants.ErrInvalidArchive, err)
+		}
+		entry := archived.Entry()
+		return &entry, nil
+	}
+
+	first, err := decode()
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		return nil, fmt.Errorf("%w: the archive has no entries", constants.ErrInvalidArchive)
+	}
+	partition := types.NewLogPartition(first.CreatedAt)
+
+	var erased int64
+	restored, err := s.repo.RestorePartition(ctx, partition, func(insert func(entries []types.LogEntry, tombstones []types.ErasedEntry) error) error {
+		flush := func(batch []types.LogEntry) error {
+			kept, tombstones, err := s.applyErasures(ctx, batch)
+			if err != nil {
+				return err
+			}
+			erased += int64(len(tombstones))
+			return insert(kept, tombstones)
+		}
+
+		batch := make([]types.LogEntry, 0, partitionRestoreBatchSize)
+		for entry := first; entry != nil; {
+			if !partition.Contains(entry.CreatedAt.UTC()) {
+				return fmt.Errorf("%w: entry %d does not belong to %s", constants.ErrInvalidArchive, entry.ID, partition.Name)
+			}
+			batch = append(batch, *entry)
+			if len(batch) == partitionRestoreBatchSize {
+				if err := flush(batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+
+			next, err := decode()
+			if err != nil {
+				return err
+			}
+			entry = next
+		}
+		return flush(batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.PartitionRestore{Partition: partition, Entries: restored, Erased: erased}, nil
+}
+
+// applyErasures erases the archived entries of the users and entries erased since they were archived
+func (s *partitionService) applyErasures(ctx context.Context, entries []types.LogEntry) ([]types.LogEntry, []types.ErasedEntry, error) {
+	entryIDs := make([]int64, 0, len(entries))
+	subjects := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entryIDs = append(entryIDs, entry.ID)
+		if entry.UserID != nil {
+			subjects = append(subjects, types.ErasedUserSubject(*entry.UserID))
+		}
+	}
+
+	tombstones, err := s.repo.ListTombstones(ctx, entryIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	users, err := s.repo.ListErasedUsers(ctx, subjects)
+	if err != nil {
+		return nil, nil, err
+	}
+	kept, erased := types.ApplyErasures(entries, tombstones, users, time.Now())
+	return kept, erased, nil
+}
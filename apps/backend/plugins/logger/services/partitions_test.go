@@ -0,0 +1,151 @@
+package services_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// restoringPartitionRepository keeps what a restore inserts and the erasures it is given
+type restoringPartitionRepository struct {
+	tombstones  []types.ErasedEntry
+	erasedUsers []types.ErasedUser
+	restored    []types.LogEntry
+	inserted    []types.ErasedEntry
+}
+
+func (r *restoringPartitionRepository) ListPartitions(ctx context.Context) ([]types.LogPartition, error) {
+	return nil, nil
+}
+
+func (r *restoringPartitionRepository) CreatePartition(ctx context.Context, partition types.LogPartition) (bool, error) {
+	return false, nil
+}
+
+func (r *restoringPartitionRepository) StreamPartition(ctx context.Context, partition types.LogPartition, fn func(entry *types.LogEntry) error) error {
+	return nil
+}
+
+func (r *restoringPartitionRepository) DropPartition(ctx context.Context, partition types.LogPartition, entries int64) error {
+	return nil
+}
+
+func (r *restoringPartitionRepository) RestorePartition(ctx context.Context, partition types.LogPartition, read func(insert func(entries []types.LogEntry, tombstones []types.ErasedEntry) error) error) (int64, error) {
+	err := read(func(entries []types.LogEntry, tombstones []types.ErasedEntry) error {
+		r.restored = append(r.restored, entries...)
+		r.inserted = append(r.inserted, tombstones...)
+		return nil
+	})
+	return int64(len(r.restored)), err
+}
+
+func (r *restoringPartitionRepository) ListTombstones(ctx context.Context, entryIDs []int64) ([]types.ErasedEntry, error) {
+	return r.tombstones, nil
+}
+
+func (r *restoringPartitionRepository) ListErasedUsers(ctx context.Context, subjects []string) ([]types.ErasedUser, error) {
+	return r.erasedUsers, nil
+}
+
+// writeArchive writes the entries as a partition archive and returns its path
+func writeArchive(t *testing.T, entries []types.LogEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "log_entries_p202601.ndjson.gz")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	compressed := gzip.NewWriter(file)
+	encoder := json.NewEncoder(compressed)
+	for i := range entries {
+		require.NoError(t, encoder.Encode(types.NewArchivedLogEntry(&entries[i])))
+	}
+	require.NoError(t, compressed.Close())
+	return path
+}
+
+func TestPartitionService_RestoreArchive(t *testing.T) {
+	createdAt := time.Date(2026, time.January, 10, 12, 0, 0, 0, time.UTC)
+	archivedEntry := func(id int64, userID string) types.LogEntry {
+		hash := "hash"
+		ip := "10.0.0.1"
+		return types.LogEntry{
+			ID:        id,
+			EventType: "user.signed_in",
+			UserID:    &userID,
+			IPAddress: &ip,
+			Details:   json.RawMessage(`{"email":"user@example.com"}`),
+			CreatedAt: createdAt,
+			Hash:      &hash,
+		}
+	}
+	pseudonym := "erased:p1"
+
+	tests := []struct {
+		name        string
+		tombstones  []types.ErasedEntry
+		erasedUsers []types.ErasedUser
+		wantUsers   map[int64]string
+		wantErased  int64
+	}{
+		{
+			name:      "restores the entries as archived",
+			wantUsers: map[int64]string{1: "u1", 2: "u2", 3: "u3"},
+		},
+		{
+			name:        "anonymizes the entries of a user erased after archiving",
+			erasedUsers: []types.ErasedUser{{Subject: types.ErasedUserSubject("u1"), Mode: types.ErasureModeAnonymize, Pseudonym: &pseudonym}},
+			wantUsers:   map[int64]string{1: pseudonym, 2: "u2", 3: "u3"},
+			wantErased:  1,
+		},
+		{
+			name:        "leaves out the entries of a deleted user and deleted entries",
+			tombstones:  []types.ErasedEntry{{EntryID: 3, Mode: types.ErasureModeDelete}},
+			erasedUsers: []types.ErasedUser{{Subject: types.ErasedUserSubject("u2"), Mode: types.ErasureModeDelete}},
+			wantUsers:   map[int64]string{1: "u1"},
+			wantErased:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &restoringPartitionRepository{tombstones: tt.tombstones, erasedUsers: tt.erasedUsers}
+			path := writeArchive(t, []types.LogEntry{archivedEntry(1, "u1"), archivedEntry(2, "u2"), archivedEntry(3, "u3")})
+
+			result, err := services.NewPartitionService(repo, types.PartitionConfig{}).RestoreArchive(context.Background(), path)
+			require.NoError(t, err)
+			assert.EqualValues(t, len(tt.wantUsers), result.Entries)
+			assert.Equal(t, tt.wantErased, result.Erased)
+
+			got := make(map[int64]string, len(repo.restored))
+			for _, entry := range repo.restored {
+				got[entry.ID] = *entry.UserID
+				if *entry.UserID == pseudonym {
+					assert.Nil(t, entry.IPAddress)
+					assert.JSONEq(t, `{}`, string(entry.Details))
+				}
+			}
+			assert.Equal(t, tt.wantUsers, got)
+
+			// Every erased entry gets a tombstone with its hashes so the chain still verifies
+			require.Len(t, repo.inserted, int(tt.wantErased))
+			for _, tombstone := range repo.inserted {
+				require.NotNil(t, tombstone.Hash)
+				assert.Equal(t, "hash", *tombstone.Hash)
+			}
+		})
+	}
+}
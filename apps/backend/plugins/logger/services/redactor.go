@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// maskedValue replaces values that cannot keep a hint of what they were
+const maskedValue = "***"
+
+// Redactor rewrites the sensitive fields of event payloads and metadata before they are stored
+type Redactor struct {
+	rules []redactionRule
+	key   []byte
+}
+
+type redactionRule struct {
+	path   types.JSONPath
+	action types.RedactionAction
+}
+
+// NewRedactor compiles the rules, it fails on the first invalid rule
+func NewRedactor(rules []types.RedactionRule, hmacKey string) (*Redactor, error) {
+	redactor := &Redactor{key: []byte(hmacKey)}
+	for i, rule := range rules {
+		path, err := rule.Parse(hmacKey)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %d: %w", i, err)
+		}
+		redactor.rules = append(redactor.rules, redactionRule{path: path, action: rule.Action})
+	}
+	return redactor, nil
+}
+
+// Redact returns copies of the payload and metadata with the selected fields rewritten
+func (r *Redactor) Redact(payload []byte, metadata map[string]string) ([]byte, map[string]string) {
+	if len(r.rules) == 0 {
+		return payload, metadata
+	}
+	return r.redactPayload(payload), r.redactMetadata(metadata)
+}
+
+func (r *Redactor) redactPayload(payload []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	// Numbers keep their exact representation when the payload is re-encoded
+	decoder.UseNumber()
+
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return payload
+	}
+
+	redacted, changed := r.redactValue(document, nil)
+	if !changed {
+		return payload
+	}
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redacted); err != nil {
+		return payload
+	}
+	return bytes.TrimSuffix(encoded.Bytes(), []byte("\n"))
+}
+
+func (r *Redactor) redactMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return metadata
+	}
+
+	document := make(map[string]any, len(metadata))
+	for key, value := range metadata {
+		document[key] = value
+	}
+	if _, changed := r.redactValue(document, nil); !changed {
+		return metadata
+	}
+
+	redacted := make(map[string]string, len(document))
+	for key, value := range document {
+		if str, ok := value.(string); ok {
+			redacted[key] = str
+		}
+	}
+	return redacted
+}
+
+// redactValue applies the rules to the children of value, path is the location of value
+func (r *Redactor) redactValue(value any, path []any) (any, bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		changed := false
+		for key, child := range value {
+			childPath := append(path[:len(path):len(path)], key)
+			if rule := r.match(childPath); rule != nil {
+				changed = true
+				if rule.action == types.RedactionActionDrop {
+					delete(value, key)
+				} else {
+					value[key] = r.apply(rule.action, child)
+				}
+				continue
+			}
+			if redacted, childChanged := r.redactValue(child, childPath); childChanged {
+				value[key] = redacted
+				changed = true
+			}
+		}
+		return value, changed
+
+	case []any:
+		changed := false
+		kept := make([]any, 0, len(value))
+		for index, child := range value {
+			childPath := append(path[:len(path):len(path)], index)
+			if rule := r.match(childPath); rule != nil {
+				changed = true
+				if rule.action != types.RedactionActionDrop {
+					kept = append(kept, r.apply(rule.action, child))
+				}
+				continue
+			}
+			redacted, childChanged := r.redactValue(child, childPath)
+			changed = changed || childChanged
+			kept = append(kept, redacted)
+		}
+		return kept, changed
+	}
+
+	return value, false
+}
+
+func (r *Redactor) match(path []any) *redactionRule {
+	for i := range r.rules {
+		if r.rules[i].path.Match(path) {
+			return &r.rules[i]
+		}
+	}
+	return nil
+}
+
+func (r *Redactor) apply(action types.RedactionAction, value any) any {
+	switch action {
+	case types.RedactionActionMask:
+		return maskValue(value)
+	case types.RedactionActionHMAC:
+		return r.hmacValue(value)
+	}
+	return nil
+}
+
+// hmacValue returns the hex HMAC-SHA256 of a string value or of the JSON encoding of any other value
+func (r *Redactor) hmacValue(value any) string {
+	input, ok := value.(string)
+	if !ok {
+		encoded, _ := json.Marshal(value)
+		input = string(encoded)
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(input))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// maskValue keeps the first character of emails and strings and the network part of IPs
+func maskValue(value any) any {
+	str, ok := value.(string)
+	if !ok {
+		return maskedValue
+	}
+
+	if ip := net.ParseIP(str); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			return fmt.Sprintf("%d.%d.%d.%s", v4[0], v4[1], v4[2], maskedValue)
+		}
+		return fmt.Sprintf("%x:%x:%x:%s", uint16(ip[0])<<8|uint16(ip[1]), uint16(ip[2])<<8|uint16(ip[3]), uint16(ip[4])<<8|uint16(ip[5]), maskedValue)
+	}
+
+	if local, domain, found := strings.Cut(str, "@"); found && local != "" && domain != "" {
+		return firstRune(local) + maskedValue + "@" + domain
+	}
+
+	if str == "" {
+		return str
+	}
+	return firstRune(str) + maskedValue
+}
+
+func firstRune(str string) string {
+	_, size := utf8.DecodeRuneInString(str)
+	return str[:size]
+}
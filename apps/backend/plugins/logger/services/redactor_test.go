@@ -0,0 +1,187 @@
+package services_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+const testHMACKey = "0123456789abcdef0123456789abcdef"
+
+// hmacHex returns the value redacted by the hmac action with testHMACKey
+func hmacHex(value string) string {
+	mac := hmac.New(sha256.New, []byte(testHMACKey))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewRedactor(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []types.RedactionRule
+		hmacKey string
+		wantErr string
+	}{
+		{
+			name:  "default rules",
+			rules: types.DefaultRedactionRules,
+		},
+		{
+			name:    "hmac rule with a key",
+			rules:   []types.RedactionRule{{Path: "$.user_id", Action: types.RedactionActionHMAC}},
+			hmacKey: testHMACKey,
+		},
+		{
+			name:    "invalid path",
+			rules:   []types.RedactionRule{{Path: "$..token", Action: types.RedactionActionDrop}, {Path: "user.email", Action: types.RedactionActionDrop}},
+			wantErr: "redaction rule 1",
+		},
+		{
+			name:    "unknown action",
+			rules:   []types.RedactionRule{{Path: "$.email", Action: "hash"}},
+			wantErr: "unknown action",
+		},
+		{
+			name:    "hmac rule without a key",
+			rules:   []types.RedactionRule{{Path: "$.user_id", Action: types.RedactionActionHMAC}},
+			hmacKey: "short",
+			wantErr: "hmac",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			redactor, err := services.NewRedactor(tt.rules, tt.hmacKey)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, redactor)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, redactor)
+		})
+	}
+}
+
+func TestRedactor_Redact(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        []types.RedactionRule
+		payload      string
+		metadata     map[string]string
+		wantPayload  string
+		wantMetadata map[string]string
+	}{
+		{
+			name:        "drop",
+			rules:       []types.RedactionRule{{Path: "$.user.email", Action: types.RedactionActionDrop}},
+			payload:     `{"user":{"id":"u1","email":"jane@example.com"}}`,
+			wantPayload: `{"user":{"id":"u1"}}`,
+		},
+		{
+			name:        "mask",
+			rules:       []types.RedactionRule{{Path: "$.email", Action: types.RedactionActionMask}, {Path: "$.name", Action: types.RedactionActionMask}, {Path: "$.age", Action: types.RedactionActionMask}},
+			payload:     `{"email":"jane@example.com","name":"Jane","age":42}`,
+			wantPayload: `{"email":"j***@example.com","name":"J***","age":"***"}`,
+		},
+		{
+			name:        "hmac",
+			rules:       []types.RedactionRule{{Path: "$.user_id", Action: types.RedactionActionHMAC}},
+			payload:     `{"user_id":"u1"}`,
+			wantPayload: `{"user_id":"` + hmacHex("u1") + `"}`,
+		},
+		{
+			name:        "default rules drop tokens, secrets and passwords at any depth",
+			rules:       types.DefaultRedactionRules,
+			payload:     `{"access_token":"t","user":{"Password":"p","id":"u1"},"client":{"clientSecret":"s"},"sessions":[{"refresh_token":"r","id":"s1"}]}`,
+			wantPayload: `{"user":{"id":"u1"},"client":{},"sessions":[{"id":"s1"}]}`,
+		},
+		{
+			name:        "recursive path",
+			rules:       []types.RedactionRule{{Path: "$..email", Action: types.RedactionActionDrop}},
+			payload:     `{"email":"a@example.com","user":{"profile":{"email":"b@example.com","id":"u1"}}}`,
+			wantPayload: `{"user":{"profile":{"id":"u1"}}}`,
+		},
+		{
+			name:        "field of every array element",
+			rules:       []types.RedactionRule{{Path: "$.items[*].token", Action: types.RedactionActionDrop}},
+			payload:     `{"items":[{"token":"a","id":1},{"token":"b","id":2}]}`,
+			wantPayload: `{"items":[{"id":1},{"id":2}]}`,
+		},
+		{
+			name:        "single array element",
+			rules:       []types.RedactionRule{{Path: "$.items[0]", Action: types.RedactionActionDrop}},
+			payload:     `{"items":["a","b"]}`,
+			wantPayload: `{"items":["b"]}`,
+		},
+		{
+			name:        "ipv4 address",
+			rules:       []types.RedactionRule{{Path: "$.ip_address", Action: types.RedactionActionMask}},
+			payload:     `{"ip_address":"203.0.113.7"}`,
+			wantPayload: `{"ip_address":"203.0.113.***"}`,
+		},
+		{
+			name:        "ipv6 address",
+			rules:       []types.RedactionRule{{Path: "$.ip_address", Action: types.RedactionActionMask}},
+			payload:     `{"ip_address":"2001:db8:85a3::8a2e:370:7334"}`,
+			wantPayload: `{"ip_address":"2001:db8:85a3:***"}`,
+		},
+		{
+			name:        "ipv4-mapped ipv6 address",
+			rules:       []types.RedactionRule{{Path: "$.ip_address", Action: types.RedactionActionMask}},
+			payload:     `{"ip_address":"::ffff:203.0.113.7"}`,
+			wantPayload: `{"ip_address":"203.0.113.***"}`,
+		},
+		{
+			name:        "metadata",
+			rules:       []types.RedactionRule{{Path: "$..client_ip", Action: types.RedactionActionMask}},
+			payload:     `{"id":"u1"}`,
+			metadata:    map[string]string{"client_ip": "203.0.113.7", "request_id": "req-1"},
+			wantPayload: `{"id":"u1"}`,
+			wantMetadata: map[string]string{
+				"client_ip":  "203.0.113.***",
+				"request_id": "req-1",
+			},
+		},
+		{
+			name:        "non-JSON payload",
+			rules:       types.DefaultRedactionRules,
+			payload:     `password=secret`,
+			wantPayload: `password=secret`,
+		},
+		{
+			name:        "no matching field",
+			rules:       types.DefaultRedactionRules,
+			payload:     `{"id": 1.50}`,
+			wantPayload: `{"id": 1.50}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			redactor, err := services.NewRedactor(tt.rules, testHMACKey)
+			require.NoError(t, err)
+
+			payload, metadata := redactor.Redact([]byte(tt.payload), tt.metadata)
+			if tt.wantPayload == tt.payload {
+				// Unchanged payloads are returned as they are
+				assert.Equal(t, tt.wantPayload, string(payload))
+			} else {
+				assert.JSONEq(t, tt.wantPayload, string(payload))
+			}
+			assert.Equal(t, tt.wantMetadata, metadata)
+		})
+	}
+}
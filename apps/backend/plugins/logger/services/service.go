@@ -2,12 +2,16 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"sync/atomic"
 	"time"
 
 	"github.com/Authula/authula/models"
 
+	"github.com/Authula/authula-playground/plugins/logger/constants"
 	"github.com/Authula/authula-playground/plugins/logger/repositories"
 	"github.com/Authula/authula-playground/plugins/logger/types"
 )
@@ -15,46 +19,322 @@ import (
 // service implements the UseCase interface for logger operations
 type service struct {
 	repo     repositories.LoggerRepository
+	eventBus models.EventBus
 	logger   models.Logger
 	config   types.LoggerPluginConfig
-	logCount atomic.Int64
+	counter  LogCounter
+	redactor *Redactor
+	erasers  *UserDataErasers
+	// pruning makes sure a single prune runs at a time within this process
+	pruning atomic.Bool
+	// maxLogCountWarned makes sure the max log count warning is published once per cap hit
+	maxLogCountWarned atomic.Bool
 }
 
 // NewService creates a new logger usecase implementation
-func NewService(repo repositories.LoggerRepository, logger models.Logger, config types.LoggerPluginConfig) LoggerService {
+func NewService(repo repositories.LoggerRepository, counter LogCounter, redactor *Redactor, erasers *UserDataErasers, eventBus models.EventBus, logger models.Logger, config types.LoggerPluginConfig) LoggerService {
 	return &service{
-		repo:   repo,
-		logger: logger,
-		config: config,
+		repo:     repo,
+		counter:  counter,
+		redactor: redactor,
+		erasers:  erasers,
+		eventBus: eventBus,
+		logger:   logger,
+		config:   config,
 	}
 }
 
-// CreateLogEntry creates a new log entry with hooks
-func (s *service) CreateLogEntry(ctx context.Context, eventType string, details string) (*types.LogEntry, error) {
+// CreateLogEntry creates a new log entry for the event
+func (s *service) CreateLogEntry(ctx context.Context, event models.Event) (*types.LogEntry, error) {
+	entries, err := s.CreateLogEntries(ctx, []models.Event{event})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, constants.ErrDuplicateLogEntry
+	}
+	return entries[0], nil
+}
+
+// CreateLogEntries stores a log entry for each event with a single insert statement, see StoreLogEntries
+func (s *service) CreateLogEntries(ctx context.Context, events []models.Event) ([]*types.LogEntry, error) {
+	return s.StoreLogEntries(ctx, s.NewLogEntries(events))
+}
+
+// NewLogEntries builds the log entries of the events without storing them
+func (s *service) NewLogEntries(events []models.Event) []*types.LogEntry {
+	entries := make([]*types.LogEntry, 0, len(events))
+	for _, event := range events {
+		entries = append(entries, s.newLogEntry(event))
+	}
+	return entries
+}
+
+// StoreLogEntries stores the entries in a single insert and returns the ones that were stored
+func (s *service) StoreLogEntries(ctx context.Context, entries []*types.LogEntry) ([]*types.LogEntry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var dropped bool
+	if s.config.RetentionMode == types.RetentionModeStop {
+		count, err := s.GetLogCount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		remaining := int64(s.config.MaxLogCount) - count
+		if remaining <= 0 {
+			s.publishMaxLogCountReached(ctx)
+			return nil, constants.ErrMaxLogCountReached
+		}
+		if int64(len(entries)) > remaining {
+			entries = entries[:remaining]
+			dropped = true
+		} else {
+			s.maxLogCountWarned.Store(false)
+		}
+	}
+
+	created, err := s.repo.CreateBatch(ctx, entries)
+	if err != nil {
+		s.logger.Error("failed to create log entries", "count", len(entries), "error", err)
+		return nil, err
+	}
+	if duplicates := len(entries) - len(created); duplicates > 0 {
+		s.logger.Debug("dropped duplicate log entries", "count", duplicates)
+		if err := s.counter.AddDuplicatesDropped(ctx, int64(duplicates)); err != nil {
+			s.logger.Warn("failed to count dropped duplicate log entries", "error", err)
+		}
+	}
+	entries = created
+	if len(entries) > 0 {
+		s.recordCreated(ctx, len(entries))
+	}
+
+	if s.config.RetentionMode == types.RetentionModePrune {
+		s.pruneAboveMargin(ctx)
+	}
+
+	if dropped {
+		s.publishMaxLogCountReached(ctx)
+		return entries, constants.ErrMaxLogCountReached
+	}
+	return entries, nil
+}
+
+// newLogEntry builds the log entry of an event from its redacted payload and metadata
+func (s *service) newLogEntry(event models.Event) *types.LogEntry {
+	payload, metadata := s.redactor.Redact(event.Payload, event.Metadata)
+
 	entry := &types.LogEntry{
-		EventType: eventType,
-		Details:   details,
-		CreatedAt: time.Now().UTC(),
+		EventType: event.Type,
+		Details:   types.NormalizePayload(payload),
+		// Truncated to what every dialect stores so that the hash chain can be recomputed from the database
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if event.ID != "" {
+		entry.EventID = &event.ID
 	}
+	entry.ApplyEventFields(payload, metadata)
+	return entry
+}
+
+// recordCreated adds the created entries to the log count
+func (s *service) recordCreated(ctx context.Context, created int) {
+	s.addToLogCount(ctx, int64(created))
+}
 
-	// Create in database
-	if err := s.repo.Create(ctx, entry); err != nil {
-		s.logger.Error("failed to create log entry", "error", err)
-		return entry, err
+// recordRemoved subtracts the removed entries from the log count
+func (s *service) recordRemoved(ctx context.Context, removed int64) {
+	s.addToLogCount(ctx, -removed)
+}
+
+func (s *service) addToLogCount(ctx context.Context, delta int64) {
+	if delta == 0 {
+		return
+	}
+	err := s.counter.Add(ctx, delta)
+	if errors.Is(err, errLogCountNotSeeded) {
+		_, err = s.SyncLogCount(ctx)
 	}
+	if err != nil {
+		s.logger.Warn("failed to update log count", "error", err)
+	}
+}
 
-	// Refresh from database
-	retrievedEntry, err := s.repo.GetByID(ctx, entry.ID)
+// pruneAboveMargin prunes to MaxLogCount once the count is pruneMargin entries above it
+func (s *service) pruneAboveMargin(ctx context.Context) {
+	count, err := s.GetLogCount(ctx)
 	if err != nil {
-		s.logger.Error("failed to retrieve created log entry", "error", err)
-		return entry, err
+		s.logger.Warn("failed to read log count", "error", err)
+		return
+	}
+	if count <= int64(s.config.MaxLogCount+pruneMargin(s.config.MaxLogCount)) {
+		return
 	}
-	if retrievedEntry == nil {
-		return entry, fmt.Errorf("created entry not found in database")
+	if _, err := s.PruneToMaxLogCount(ctx); err != nil {
+		s.logger.Error("failed to prune log entries", "error", err)
 	}
+}
 
-	s.logCount.Add(1)
-	return retrievedEntry, nil
+// pruneMargin is how many entries above MaxLogCount are kept until the writer prunes, a tenth of it
+func pruneMargin(maxLogCount int) int {
+	return max(maxLogCount/10, 1)
+}
+
+// PruneToMaxLogCount deletes the oldest log entries until at most MaxLogCount remain
+func (s *service) PruneToMaxLogCount(ctx context.Context) (int64, error) {
+	if !s.pruning.CompareAndSwap(false, true) {
+		return 0, nil
+	}
+	defer s.pruning.Store(false)
+
+	cutoffID, err := s.repo.GetPruneCutoffID(ctx, s.config.MaxLogCount)
+	if err != nil || cutoffID == nil {
+		return 0, err
+	}
+
+	var total int64
+	for {
+		deleted, err := s.repo.DeleteOldestUpTo(ctx, *cutoffID, s.config.PruneBatchSize)
+		total += deleted
+		if err != nil {
+			s.recordRemoved(ctx, total)
+			return total, err
+		}
+		if deleted < int64(s.config.PruneBatchSize) {
+			break
+		}
+	}
+
+	s.recordRemoved(ctx, total)
+	s.logger.Debug("pruned log entries", "deleted", total, "max_log_count", s.config.MaxLogCount)
+	if _, err := s.deleteErasedEntriesUpTo(ctx, *cutoffID); err != nil {
+		return total, err
+	}
+	return total, nil
+}
+
+// PruneErasedEntries deletes the tombstones before the oldest remaining entry, the chain is verified from there
+func (s *service) PruneErasedEntries(ctx context.Context) (int64, error) {
+	oldest, err := s.repo.ListChain(ctx, 0, 1)
+	if err != nil || len(oldest) == 0 {
+		return 0, err
+	}
+	return s.deleteErasedEntriesUpTo(ctx, oldest[0].ID-1)
+}
+
+// deleteErasedEntriesUpTo deletes the tombstones at or below maxEntryID in batches
+func (s *service) deleteErasedEntriesUpTo(ctx context.Context, maxEntryID int64) (int64, error) {
+	var total int64
+	for {
+		deleted, err := s.repo.DeleteErasedEntriesUpTo(ctx, maxEntryID, s.config.PruneBatchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < int64(s.config.PruneBatchSize) {
+			return total, nil
+		}
+	}
+}
+
+// PruneExpiredLogs deletes the logs that outlived their retention rule
+func (s *service) PruneExpiredLogs(ctx context.Context) (*types.RetentionPruneResult, error) {
+	now := time.Now().UTC()
+	result := &types.RetentionPruneResult{
+		Rules: []types.RetentionRuleResult{},
+	}
+
+	var matchedEventTypes []string
+	prune := func(eventTypes []string, days int) error {
+		cutoff := now.AddDate(0, 0, -days)
+		deleted, err := s.deleteAllMatching(ctx, types.LogEntryFilter{
+			EventTypes:        eventTypes,
+			ExcludeEventTypes: slices.Clone(matchedEventTypes),
+			To:                &cutoff,
+		})
+		result.Rules = append(result.Rules, types.RetentionRuleResult{
+			EventTypes: eventTypes,
+			Days:       days,
+			Deleted:    deleted,
+		})
+		result.TotalDeleted += deleted
+		return err
+	}
+	defer func() {
+		s.recordRemoved(ctx, result.TotalDeleted)
+	}()
+
+	for _, rule := range s.config.RetentionRules {
+		if err := prune(rule.EventTypes, rule.Days); err != nil {
+			return result, err
+		}
+		matchedEventTypes = append(matchedEventTypes, rule.EventTypes...)
+	}
+
+	if s.config.RetentionDays > 0 {
+		if err := prune(nil, s.config.RetentionDays); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// PruneProcessedEvents forgets the processed events older than ProcessedEventTTL
+func (s *service) PruneProcessedEvents(ctx context.Context) (int64, error) {
+	before := time.Now().UTC().Add(-s.config.ProcessedEventTTL)
+	var total int64
+	for {
+		deleted, err := s.repo.DeleteProcessedEventsBefore(ctx, before, s.config.PruneBatchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < int64(s.config.PruneBatchSize) {
+			return total, nil
+		}
+	}
+}
+
+// deleteAllMatching deletes every entry matching the filter in batches, keeping tombstones
+func (s *service) deleteAllMatching(ctx context.Context, filter types.LogEntryFilter) (int64, error) {
+	now := time.Now()
+	var total int64
+	for {
+		deleted, err := s.repo.EraseMatching(ctx, filter, now, s.config.PruneBatchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < int64(s.config.PruneBatchSize) {
+			return total, nil
+		}
+	}
+}
+
+func (s *service) publishMaxLogCountReached(ctx context.Context) {
+	if s.eventBus == nil || !s.maxLogCountWarned.CompareAndSwap(false, true) {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"max_log_count": s.config.MaxLogCount,
+	})
+	if err != nil {
+		s.logger.Error("failed to marshal max log count warning", "error", err)
+		return
+	}
+
+	s.logger.Warn("max log count reached, new logs are dropped", "max_log_count", s.config.MaxLogCount)
+	if err := s.eventBus.Publish(ctx, models.Event{
+		Type:      constants.EventLoggerMaxLogCountReached,
+		Timestamp: time.Now().UTC(),
+		Payload:   payload,
+	}); err != nil {
+		s.logger.Error("failed to publish max log count warning", "error", err)
+	}
 }
 
 // GetLogEntry retrieves a log entry by ID
@@ -67,17 +347,418 @@ func (s *service) GetAllLogs(ctx context.Context) ([]types.LogEntry, error) {
 	return s.repo.GetAll(ctx)
 }
 
-// DeleteLogEntry deletes a log entry
-func (s *service) DeleteLogEntry(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+// ListLogEntries retrieves a page of log entries matching the query
+func (s *service) ListLogEntries(ctx context.Context, query types.LogEntryQuery) (*types.LogEntriesPage, error) {
+	query = normalizeLogEntryQuery(query)
+
+	entries, nextCursor, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.LogEntriesPage{
+		Entries:    entries,
+		NextCursor: nextCursor,
+	}, nil
 }
 
-// GetLogCount returns the current number of logs
+// SearchLogEntries retrieves a page of the log entries containing every search term
+func (s *service) SearchLogEntries(ctx context.Context, terms []string, query types.LogEntryQuery) (*types.LogEntriesPage, error) {
+	query = normalizeLogEntryQuery(query)
+
+	entries, nextCursor, err := s.repo.Search(ctx, terms, query)
+	if err != nil {
+		return nil, err
+	}
+
+	highlights := make(map[int64]map[string]string, len(entries))
+	for i := range entries {
+		highlights[entries[i].ID] = types.HighlightLogEntry(&entries[i], terms)
+	}
+
+	return &types.LogEntriesPage{
+		Entries:    entries,
+		NextCursor: nextCursor,
+		Highlights: highlights,
+	}, nil
+}
+
+// normalizeLogEntryQuery applies the default and maximum page size and the default order
+func normalizeLogEntryQuery(query types.LogEntryQuery) types.LogEntryQuery {
+	if query.Limit <= 0 {
+		query.Limit = types.DefaultLogEntriesLimit
+	}
+	if query.Limit > types.MaxLogEntriesLimit {
+		query.Limit = types.MaxLogEntriesLimit
+	}
+	if query.Order != types.SortOrderAsc {
+		query.Order = types.SortOrderDesc
+	}
+	return query
+}
+
+// ListUserActivity retrieves a page of the account activity of a single user, newest first
+func (s *service) ListUserActivity(ctx context.Context, userID string, cursor *string, limit int) (*types.ActivityPage, error) {
+	if limit <= 0 {
+		limit = types.DefaultActivityLimit
+	}
+	if limit > types.MaxActivityLimit {
+		limit = types.MaxActivityLimit
+	}
+
+	entries, nextCursor, err := s.repo.List(ctx, types.LogEntryQuery{
+		Filter: types.LogEntryFilter{
+			EventTypes: activityEventTypes(),
+			UserID:     &userID,
+		},
+		Cursor: cursor,
+		Limit:  limit,
+		Order:  types.SortOrderDesc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	items := make([]types.ActivityItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, newActivityItem(entry, now))
+	}
+
+	return &types.ActivityPage{
+		Items:      items,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// ExportLogEntries calls fn for every log entry matching the filter without loading them all in memory
+func (s *service) ExportLogEntries(ctx context.Context, filter types.LogEntryFilter, order types.SortOrder, fn func(entry *types.LogEntry) error) error {
+	if order != types.SortOrderDesc {
+		order = types.SortOrderAsc
+	}
+	return s.repo.Stream(ctx, filter, order, fn)
+}
+
+// GetLogStats counts the entries by bucket and event type
+func (s *service) GetLogStats(ctx context.Context, query types.StatsQuery) (*types.LogStats, error) {
+	query.From = query.Bucket.Start(query.From)
+	query.To = query.To.UTC()
+
+	source := "entries"
+	countByBucket := s.repo.CountByBucket
+	if s.config.StatsFromRollup {
+		source = "rollup"
+		countByBucket = s.repo.CountRollupByBucket
+	}
+
+	counts, err := countByBucket(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64)
+	for _, count := range counts {
+		totals[count.EventType] += count.Count
+	}
+
+	return &types.LogStats{
+		Bucket: query.Bucket,
+		From:   query.From,
+		To:     query.To,
+		Source: source,
+		Counts: counts,
+		Totals: totals,
+	}, nil
+}
+
+// ListSecurityAlerts retrieves a page of the alerts raised by the threat detector, newest first
+func (s *service) ListSecurityAlerts(ctx context.Context, query types.SecurityAlertQuery) (*types.SecurityAlertsPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = types.DefaultSecurityAlertsLimit
+	}
+	if query.Limit > types.MaxSecurityAlertsLimit {
+		query.Limit = types.MaxSecurityAlertsLimit
+	}
+
+	alerts, nextCursor, err := s.repo.ListSecurityAlerts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.SecurityAlertsPage{
+		Alerts:     alerts,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// AcknowledgeSecurityAlert records that the admin reviewed the alert
+func (s *service) AcknowledgeSecurityAlert(ctx context.Context, id int64, userID string) (*types.SecurityAlert, error) {
+	return s.repo.AcknowledgeSecurityAlert(ctx, id, userID, time.Now())
+}
+
+// CreateDeadLetters keeps the entries that could not be stored so that an admin can replay them
+func (s *service) CreateDeadLetters(ctx context.Context, deadLetters []*types.DeadLetter) error {
+	return s.repo.CreateDeadLetters(ctx, deadLetters)
+}
+
+// ListDeadLetters retrieves a page of the entries that could not be stored, newest first
+func (s *service) ListDeadLetters(ctx context.Context, query types.DeadLetterQuery) (*types.DeadLettersPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = types.DefaultDeadLettersLimit
+	}
+	if query.Limit > types.MaxDeadLettersLimit {
+		query.Limit = types.MaxDeadLettersLimit
+	}
+
+	deadLetters, nextCursor, err := s.repo.ListDeadLetters(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.DeadLettersPage{
+		DeadLetters: deadLetters,
+		NextCursor:  nextCursor,
+	}, nil
+}
+
+// ReplayDeadLetter stores the entry of a dead letter again and removes the dead letter
+func (s *service) ReplayDeadLetter(ctx context.Context, id int64) (*types.DeadLetterReplay, error) {
+	deadLetter, err := s.repo.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := deadLetter.LogEntry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter %d: %w", id, err)
+	}
+
+	stored, err := s.StoreLogEntries(ctx, []*types.LogEntry{entry})
+	if err != nil {
+		if errors.Is(err, constants.ErrMaxLogCountReached) {
+			return nil, err
+		}
+		if recordErr := s.repo.RecordDeadLetterAttempt(ctx, id, err.Error(), time.Now()); recordErr != nil {
+			s.logger.Warn("failed to record dead letter replay", "dead_letter_id", id, "error", recordErr)
+		}
+		return nil, err
+	}
+
+	if err := s.repo.DeleteDeadLetter(ctx, id); err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return &types.DeadLetterReplay{Duplicate: true}, nil
+	}
+	return &types.DeadLetterReplay{Entry: stored[0]}, nil
+}
+
+// GetLogCount returns the current number of logs across all replicas
 func (s *service) GetLogCount(ctx context.Context) (int64, error) {
-	return s.logCount.Load(), nil
+	count, err := s.counter.Get(ctx)
+	if err == nil {
+		return count, nil
+	}
+	if !errors.Is(err, errLogCountNotSeeded) {
+		s.logger.Warn("failed to read log count, falling back to the database", "error", err)
+	}
+	return s.SyncLogCount(ctx)
+}
+
+// DuplicatesDropped returns the number of redelivered events that were not stored again
+func (s *service) DuplicatesDropped(ctx context.Context) (int64, error) {
+	return s.counter.DuplicatesDropped(ctx)
+}
+
+// SyncLogCount counts the rows in the database and stores the result in the counter
+func (s *service) SyncLogCount(ctx context.Context) (int64, error) {
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.counter.Set(ctx, int64(count)); err != nil {
+		s.logger.Warn("failed to store log count", "error", err)
+	}
+	return int64(count), nil
 }
 
 // HasReachedMaxLogs checks if the maximum log count has been reached
 func (s *service) HasReachedMaxLogs(ctx context.Context) (bool, error) {
-	return int(s.logCount.Load()) >= s.config.MaxLogCount, nil
+	count, err := s.GetLogCount(ctx)
+	if err != nil {
+		return false, err
+	}
+	return count >= int64(s.config.MaxLogCount), nil
+}
+
+// chainVerifyBatchSize is the number of entries read per round while walking the hash chain
+const chainVerifyBatchSize = 500
+
+// VerifyChain checks the hash chain from the oldest remaining entry and the checkpoints
+func (s *service) VerifyChain(ctx context.Context) (*types.ChainVerification, error) {
+	result := &types.ChainVerification{Valid: true}
+
+	var prevHash *string
+	var afterID int64
+	for {
+		entries, err := s.repo.ListChain(ctx, afterID, chainVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		// The tombstones of erased entries fill the gaps of deleted entries, the last round also
+		// reads the tombstones of deleted entries after the newest remaining one
+		var upToID *int64
+		if len(entries) == chainVerifyBatchSize {
+			upToID = &entries[len(entries)-1].ID
+		}
+		tombstones, err := s.repo.ListErasedEntries(ctx, afterID, upToID)
+		if err != nil {
+			return nil, err
+		}
+		if afterID == 0 && len(entries) > 0 {
+			tombstones = slices.DeleteFunc(tombstones, func(tombstone types.ErasedEntry) bool {
+				return tombstone.EntryID < entries[0].ID
+			})
+		}
+
+		broken := func(entryID int64, reason types.ChainBreakReason) {
+			result.Valid = false
+			result.FirstBrokenLink = &types.ChainBreak{EntryID: entryID, Reason: reason}
+		}
+		verify := func(entry *types.LogEntry, erased bool) bool {
+			if reason := verifyChainLink(entry, prevHash, erased); reason != "" {
+				broken(entry.ID, reason)
+				return false
+			}
+			result.EntriesChecked++
+			prevHash = entry.Hash
+			afterID = entry.ID
+			return true
+		}
+
+		next := 0
+		for i := range entries {
+			entry := &entries[i]
+			for ; next < len(tombstones) && tombstones[next].EntryID < entry.ID; next++ {
+				if !verify(tombstones[next].LogEntry(), true) {
+					return result, nil
+				}
+			}
+			erased := false
+			if next < len(tombstones) && tombstones[next].EntryID == entry.ID {
+				// An anonymized entry must still carry the content hash it had when it was erased
+				if !tombstones[next].Matches(entry) {
+					broken(entry.ID, types.ChainBreakHashModified)
+					return result, nil
+				}
+				erased = true
+				next++
+			}
+			if !verify(entry, erased) {
+				return result, nil
+			}
+		}
+		for ; next < len(tombstones); next++ {
+			if !verify(tombstones[next].LogEntry(), true) {
+				return result, nil
+			}
+		}
+
+		if len(entries) < chainVerifyBatchSize {
+			break
+		}
+	}
+
+	checkpoints, err := s.repo.ListChainCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, checkpoint := range checkpoints {
+		reason, err := s.verifyChainCheckpoint(ctx, &checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			result.Valid = false
+			result.FirstBrokenLink = &types.ChainBreak{
+				EntryID:      checkpoint.EntryID,
+				CheckpointID: &checkpoint.ID,
+				Reason:       reason,
+			}
+			return result, nil
+		}
+		result.CheckpointsChecked++
+	}
+
+	return result, nil
+}
+
+// verifyChainLink checks the hashes of a single entry, only the links of erased entries
+func verifyChainLink(entry *types.LogEntry, prevHash *string, erased bool) types.ChainBreakReason {
+	if entry.ContentHash == nil || entry.PrevHash == nil || entry.Hash == nil {
+		return types.ChainBreakUnsealed
+	}
+	if !erased && entry.ComputeContentHash() != *entry.ContentHash {
+		return types.ChainBreakContentModified
+	}
+	if types.ChainHash(*entry.PrevHash, *entry.ContentHash) != *entry.Hash {
+		return types.ChainBreakHashModified
+	}
+	if prevHash != nil && *entry.PrevHash != *prevHash {
+		return types.ChainBreakLinkBroken
+	}
+	return ""
+}
+
+// verifyChainCheckpoint checks the signature of a checkpoint and the hash of its entry
+func (s *service) verifyChainCheckpoint(ctx context.Context, checkpoint *types.ChainCheckpoint) (types.ChainBreakReason, error) {
+	if s.config.HasChainCheckpoints() && !checkpoint.VerifySignature([]byte(s.config.ChainCheckpointKey)) {
+		return types.ChainBreakCheckpointSignature, nil
+	}
+
+	entry, err := s.repo.GetByID(ctx, checkpoint.EntryID)
+	if errors.Is(err, constants.ErrLogEntryNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if entry.Hash == nil || *entry.Hash != checkpoint.Hash {
+		return types.ChainBreakCheckpointMismatch, nil
+	}
+	return "", nil
+}
+
+// CreateChainCheckpoint signs the current head of the hash chain, nil when it did not move
+func (s *service) CreateChainCheckpoint(ctx context.Context) (*types.ChainCheckpoint, error) {
+	if !s.config.HasChainCheckpoints() {
+		return nil, constants.ErrChainCheckpointsDisabled
+	}
+
+	head, err := s.repo.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if head.EntryID == 0 {
+		return nil, nil
+	}
+
+	latest, err := s.repo.GetLatestChainCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.EntryID == head.EntryID {
+		return nil, nil
+	}
+
+	checkpoint := &types.ChainCheckpoint{
+		EntryID:   head.EntryID,
+		Hash:      head.Hash,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	checkpoint.Sign([]byte(s.config.ChainCheckpointKey))
+
+	if err := s.repo.CreateChainCheckpoint(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
 }
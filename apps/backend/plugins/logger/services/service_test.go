@@ -0,0 +1,543 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula/models"
+	secondarystorageplugin "github.com/Authula/authula/plugins/secondary-storage"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+	"github.com/Authula/authula-playground/plugins/logger/repositories"
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// newTestService creates a service on an in-memory repository, the counter defaults to the database counter
+func newTestService(t *testing.T, config types.LoggerPluginConfig, counter func(repo repositories.LoggerRepository) services.LogCounter) (services.LoggerService, repositories.LoggerRepository) {
+	t.Helper()
+
+	require.NoError(t, config.Validate())
+	if counter == nil {
+		counter = services.NewDatabaseLogCounter
+	}
+	repo := repositories.NewMemoryLoggerRepository()
+	service := services.NewService(repo, counter(repo), newTestRedactor(t, config), services.NewUserDataErasers(), nil, slog.New(slog.DiscardHandler), config)
+	return service, repo
+}
+
+// newTestRedactor creates the redactor of the configured rules
+func newTestRedactor(t *testing.T, config types.LoggerPluginConfig) *services.Redactor {
+	t.Helper()
+
+	redactor, err := services.NewRedactor(config.EffectiveRedactionRules(), config.RedactionHMACKey)
+	require.NoError(t, err)
+	return redactor
+}
+
+func newEvent(id string, eventType string, payload string) models.Event {
+	return models.Event{ID: id, Type: eventType, Payload: json.RawMessage(payload)}
+}
+
+func TestService_CreateLogEntry(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    types.LoggerPluginConfig
+		stored    []models.Event
+		event     models.Event
+		wantErr   error
+		wantEntry *types.LogEntry
+		wantCount int64
+	}{
+		{
+			name:  "extracts the event fields",
+			event: newEvent("e1", "user.signed_in", `{"user_id":"u1","session":{"id":"s1","ip_address":"10.0.0.1","user_agent":"curl/8"}}`),
+			wantEntry: &types.LogEntry{
+				EventType: "user.signed_in",
+				UserID:    new("u1"),
+				IPAddress: new("10.0.0.1"),
+				UserAgent: new("curl/8"),
+			},
+			wantCount: 1,
+		},
+		{
+			name:  "takes the request ID from the metadata",
+			event: models.Event{ID: "e1", Type: "user.signed_in", Metadata: map[string]string{"request_id": "req-1", "user_id": "u2"}},
+			wantEntry: &types.LogEntry{
+				EventType: "user.signed_in",
+				UserID:    new("u2"),
+				RequestID: new("req-1"),
+			},
+			wantCount: 1,
+		},
+		{
+			name: "fills the event fields from the redacted payload",
+			config: types.LoggerPluginConfig{
+				RedactionRules: []types.RedactionRule{
+					{Path: "$..ip_address", Action: types.RedactionActionMask},
+					{Path: "$..user_id", Action: types.RedactionActionHMAC},
+				},
+				RedactionHMACKey: testHMACKey,
+			},
+			event: newEvent("e1", "user.signed_in", `{"user_id":"u1","session":{"ip_address":"203.0.113.7"}}`),
+			wantEntry: &types.LogEntry{
+				EventType: "user.signed_in",
+				UserID:    new(hmacHex("u1")),
+				IPAddress: new("203.0.113.***"),
+			},
+			wantCount: 1,
+		},
+		{
+			name:      "duplicate event",
+			stored:    []models.Event{newEvent("e1", "user.signed_in", `{}`)},
+			event:     newEvent("e1", "user.signed_in", `{}`),
+			wantErr:   constants.ErrDuplicateLogEntry,
+			wantCount: 1,
+		},
+		{
+			name:      "max log count reached in stop mode",
+			config:    types.LoggerPluginConfig{MaxLogCount: 1, RetentionMode: types.RetentionModeStop},
+			stored:    []models.Event{newEvent("e1", "user.signed_in", `{}`)},
+			event:     newEvent("e2", "user.signed_in", `{}`),
+			wantErr:   constants.ErrMaxLogCountReached,
+			wantCount: 1,
+		},
+		{
+			name:      "max log count reached in prune mode within the margin",
+			config:    types.LoggerPluginConfig{MaxLogCount: 1, RetentionMode: types.RetentionModePrune},
+			stored:    []models.Event{newEvent("e1", "user.signed_in", `{}`)},
+			event:     newEvent("e2", "user.signed_out", `{}`),
+			wantEntry: &types.LogEntry{EventType: "user.signed_out"},
+			wantCount: 2,
+		},
+		{
+			name:      "max log count exceeded by the margin in prune mode",
+			config:    types.LoggerPluginConfig{MaxLogCount: 1, RetentionMode: types.RetentionModePrune},
+			stored:    []models.Event{newEvent("e1", "user.signed_in", `{}`), newEvent("e2", "user.signed_in", `{}`)},
+			event:     newEvent("e3", "user.signed_out", `{}`),
+			wantEntry: &types.LogEntry{EventType: "user.signed_out"},
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			service, _ := newTestService(t, tt.config, nil)
+			for _, event := range tt.stored {
+				_, err := service.CreateLogEntry(ctx, event)
+				require.NoError(t, err)
+			}
+
+			entry, err := service.CreateLogEntry(ctx, tt.event)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, entry)
+			} else {
+				require.NoError(t, err)
+				assert.NotZero(t, entry.ID)
+				assert.Equal(t, tt.event.ID, *entry.EventID)
+				assert.Equal(t, tt.wantEntry.EventType, entry.EventType)
+				assert.Equal(t, tt.wantEntry.UserID, entry.UserID)
+				assert.Equal(t, tt.wantEntry.IPAddress, entry.IPAddress)
+				assert.Equal(t, tt.wantEntry.UserAgent, entry.UserAgent)
+				assert.Equal(t, tt.wantEntry.RequestID, entry.RequestID)
+
+				stored, err := service.GetLogEntry(ctx, entry.ID)
+				require.NoError(t, err)
+				assert.Equal(t, entry.Hash, stored.Hash)
+			}
+
+			count, err := service.GetLogCount(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestService_GetLogEntry(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService(t, types.LoggerPluginConfig{}, nil)
+	created, err := service.CreateLogEntry(ctx, newEvent("e1", "user.signed_in", `{"user_id":"u1"}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "existing entry", id: created.ID},
+		{name: "unknown entry", id: created.ID + 1, wantErr: constants.ErrLogEntryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := service.GetLogEntry(ctx, tt.id)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, created.ID, entry.ID)
+			assert.Equal(t, "user.signed_in", entry.EventType)
+			assert.JSONEq(t, `{"user_id":"u1"}`, string(entry.Details))
+		})
+	}
+}
+
+func TestService_DeleteLogEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+		// wantCount includes the audit entry of the deletion
+		wantCount int64
+	}{
+		{name: "deletes the entry", id: 2, wantCount: 3},
+		{name: "unknown entry", id: 99, wantErr: constants.ErrLogEntryNotFound, wantCount: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			service, _ := newTestService(t, types.LoggerPluginConfig{}, nil)
+			_, err := service.CreateLogEntries(ctx, []models.Event{
+				newEvent("e1", "user.signed_in", `{}`),
+				newEvent("e2", "user.signed_in", `{}`),
+				newEvent("e3", "user.signed_in", `{}`),
+			})
+			require.NoError(t, err)
+
+			result, err := service.DeleteLogEntry(ctx, tt.id, "admin")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.EqualValues(t, 1, result.EntriesDeleted)
+				require.NotNil(t, result.AuditEntryID)
+
+				_, err = service.GetLogEntry(ctx, tt.id)
+				assert.ErrorIs(t, err, constants.ErrLogEntryNotFound)
+				audit, err := service.GetLogEntry(ctx, *result.AuditEntryID)
+				require.NoError(t, err)
+				assert.Equal(t, constants.EventLoggerEntriesDeleted, audit.EventType)
+			}
+
+			count, err := service.GetLogCount(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+
+			verification, err := service.VerifyChain(ctx)
+			require.NoError(t, err)
+			assert.True(t, verification.Valid)
+		})
+	}
+}
+
+func TestService_PruneErasedEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		// pruneUpTo deletes the oldest entries up to this ID without tombstones, like the max log count
+		pruneUpTo      int64
+		wantDeleted    int64
+		wantTombstones []int64
+	}{
+		{name: "keeps the tombstones after the oldest entry", wantTombstones: []int64{2, 4}},
+		{name: "deletes the tombstones before the oldest entry", pruneUpTo: 1, wantDeleted: 1, wantTombstones: []int64{4}},
+		{name: "deletes every tombstone below the cutoff", pruneUpTo: 3, wantDeleted: 2, wantTombstones: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			service, repo := newTestService(t, types.LoggerPluginConfig{}, nil)
+			_, err := service.CreateLogEntries(ctx, []models.Event{
+				newEvent("e1", "user.signed_in", `{}`),
+				newEvent("e2", "user.signed_in", `{}`),
+				newEvent("e3", "user.signed_in", `{}`),
+				newEvent("e4", "user.signed_in", `{}`),
+				newEvent("e5", "user.signed_in", `{}`),
+			})
+			require.NoError(t, err)
+			for _, id := range []int64{2, 4} {
+				_, err := service.DeleteLogEntry(ctx, id, "admin")
+				require.NoError(t, err)
+			}
+			if tt.pruneUpTo > 0 {
+				_, err := repo.DeleteOldestUpTo(ctx, tt.pruneUpTo, 10)
+				require.NoError(t, err)
+			}
+
+			deleted, err := service.PruneErasedEntries(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDeleted, deleted)
+
+			tombstones, err := repo.ListErasedEntries(ctx, 0, nil)
+			require.NoError(t, err)
+			got := make([]int64, 0, len(tombstones))
+			for _, tombstone := range tombstones {
+				got = append(got, tombstone.EntryID)
+			}
+			assert.Equal(t, tt.wantTombstones, got)
+
+			verification, err := service.VerifyChain(ctx)
+			require.NoError(t, err)
+			assert.True(t, verification.Valid, "%+v", verification.FirstBrokenLink)
+		})
+	}
+}
+
+// testUserDataEraser records the users it was asked to erase
+type testUserDataEraser struct {
+	erased int64
+	err    error
+	users  []string
+}
+
+func (e *testUserDataEraser) EraseUserData(ctx context.Context, userID string) (int64, error) {
+	e.users = append(e.users, userID)
+	return e.erased, e.err
+}
+
+func TestService_EraseUserLogs(t *testing.T) {
+	tests := []struct {
+		name        string
+		eraserErr   error
+		wantErr     bool
+		wantRecords int64
+	}{
+		{name: "runs the registered erasers", wantRecords: 2},
+		{name: "fails with an eraser", eraserErr: fmt.Errorf("database is down"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			config := types.LoggerPluginConfig{ErasureMode: types.ErasureModeDelete}
+			require.NoError(t, config.Validate())
+			erasers := services.NewUserDataErasers()
+			eraser := &testUserDataEraser{erased: 2, err: tt.eraserErr}
+			erasers.Register(eraser)
+			repo := repositories.NewMemoryLoggerRepository()
+			service := services.NewService(repo, services.NewDatabaseLogCounter(repo), newTestRedactor(t, config), erasers, nil, slog.New(slog.DiscardHandler), config)
+
+			_, err := service.CreateLogEntries(ctx, []models.Event{
+				newEvent("e1", "user.signed_in", `{"user_id":"u1"}`),
+				newEvent("e2", "user.signed_in", `{"user_id":"u2"}`),
+			})
+			require.NoError(t, err)
+
+			result, err := service.EraseUserLogs(ctx, types.ErasureRequest{UserID: "u1", RequestedBy: "admin"})
+			assert.Equal(t, []string{"u1"}, eraser.users)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, result.EntriesErased)
+			assert.Equal(t, tt.wantRecords, result.RecordsErased)
+			require.NotNil(t, result.ReceiptID)
+
+			receipt, err := service.GetLogEntry(ctx, *result.ReceiptID)
+			require.NoError(t, err)
+			var details map[string]any
+			require.NoError(t, json.Unmarshal(receipt.Details, &details))
+			assert.EqualValues(t, tt.wantRecords, details["records_erased"])
+		})
+	}
+}
+
+func TestService_PruneExpiredLogs(t *testing.T) {
+	old := time.Now().UTC().AddDate(0, 0, -3)
+
+	tests := []struct {
+		name        string
+		config      types.LoggerPluginConfig
+		wantDeleted int64
+		want        []string
+	}{
+		{
+			name:        "rule deletes entries in the middle of the chain",
+			config:      types.LoggerPluginConfig{RetentionRules: []types.RetentionRule{{EventTypes: []string{"session.*"}, Days: 1}}},
+			wantDeleted: 1,
+			want:        []string{"e1", "e3"},
+		},
+		{
+			name:        "retention days delete the oldest entries",
+			config:      types.LoggerPluginConfig{RetentionDays: 1},
+			wantDeleted: 2,
+			want:        []string{"e3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			service, repo := newTestService(t, tt.config, nil)
+			entries := service.NewLogEntries([]models.Event{
+				newEvent("e1", "user.signed_in", `{}`),
+				newEvent("e2", "session.created", `{}`),
+				newEvent("e3", "user.signed_in", `{}`),
+			})
+			entries[0].CreatedAt = old
+			entries[1].CreatedAt = old
+			_, err := service.StoreLogEntries(ctx, entries)
+			require.NoError(t, err)
+
+			result, err := service.PruneExpiredLogs(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDeleted, result.TotalDeleted)
+
+			remaining, err := repo.GetAll(ctx)
+			require.NoError(t, err)
+			got := make([]string, 0, len(remaining))
+			for _, entry := range remaining {
+				got = append(got, *entry.EventID)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+
+			count, err := service.GetLogCount(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, len(tt.want), count)
+
+			verification, err := service.VerifyChain(ctx)
+			require.NoError(t, err)
+			assert.True(t, verification.Valid, "%+v", verification.FirstBrokenLink)
+		})
+	}
+}
+
+func TestService_GetLogCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		counter func(repo repositories.LoggerRepository) services.LogCounter
+		seed    bool
+	}{
+		{
+			name:    "database counter",
+			counter: services.NewDatabaseLogCounter,
+		},
+		{
+			name: "seeded secondary storage counter",
+			counter: func(repositories.LoggerRepository) services.LogCounter {
+				return newSecondaryStorageCounter(t)
+			},
+			seed: true,
+		},
+		{
+			name: "secondary storage counter that was never seeded",
+			counter: func(repositories.LoggerRepository) services.LogCounter {
+				return newSecondaryStorageCounter(t)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newTestService(t, types.LoggerPluginConfig{}, tt.counter)
+			if tt.seed {
+				_, err := service.SyncLogCount(ctx)
+				require.NoError(t, err)
+			}
+
+			for i := range 3 {
+				_, err := service.CreateLogEntry(ctx, newEvent(fmt.Sprintf("e%d", i), "user.signed_in", `{}`))
+				require.NoError(t, err)
+			}
+
+			count, err := service.GetLogCount(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, 3, count)
+		})
+	}
+}
+
+func TestService_ConcurrentCreates(t *testing.T) {
+	const (
+		workers         = 8
+		eventsPerWorker = 50
+		// every event is published twice, the redelivery must not be counted
+		deliveries = 2
+	)
+
+	tests := []struct {
+		name    string
+		counter func(repo repositories.LoggerRepository) services.LogCounter
+	}{
+		{
+			name:    "database counter",
+			counter: services.NewDatabaseLogCounter,
+		},
+		{
+			name: "secondary storage counter",
+			counter: func(repositories.LoggerRepository) services.LogCounter {
+				return newSecondaryStorageCounter(t)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			// The max log count is checked before duplicates are dropped, so it stays above the number of events
+			service, repo := newTestService(t, types.LoggerPluginConfig{MaxLogCount: 2 * workers * eventsPerWorker}, tt.counter)
+			_, err := service.SyncLogCount(ctx)
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			for worker := range workers {
+				for range deliveries {
+					wg.Go(func() {
+						for i := range eventsPerWorker {
+							_, err := service.CreateLogEntry(ctx, newEvent(fmt.Sprintf("w%d-e%d", worker, i), "user.signed_in", `{}`))
+							if err != nil {
+								assert.ErrorIs(t, err, constants.ErrDuplicateLogEntry)
+							}
+						}
+					})
+				}
+			}
+			wg.Wait()
+
+			stored, err := repo.Count(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, workers*eventsPerWorker, stored)
+			count, err := service.GetLogCount(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, stored, count)
+			duplicatesDropped, err := service.DuplicatesDropped(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, workers*eventsPerWorker*(deliveries-1), duplicatesDropped)
+
+			verification, err := service.VerifyChain(ctx)
+			require.NoError(t, err)
+			assert.True(t, verification.Valid)
+			assert.EqualValues(t, stored, verification.EntriesChecked)
+		})
+	}
+}
+
+func newSecondaryStorageCounter(t *testing.T) services.LogCounter {
+	t.Helper()
+
+	storage := secondarystorageplugin.NewMemorySecondaryStorage(secondarystorageplugin.MemoryStorageConfig{})
+	t.Cleanup(func() { _ = storage.Close() })
+	return services.NewSecondaryStorageLogCounter(storage)
+}
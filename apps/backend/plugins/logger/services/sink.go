@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// LogSink receives the log entries in addition to the database, it must not modify them
+type LogSink interface {
+	Write(ctx context.Context, entries []*types.LogEntry) error
+	Close() error
+}
+
+// NewLogSink creates the sink described by a validated sink configuration
+func NewLogSink(config types.SinkConfig, logger models.Logger) (LogSink, error) {
+	switch config.Type {
+	case types.SinkTypeFile:
+		return NewFileSink(config.File), nil
+	case types.SinkTypeStdout:
+		return NewStdoutSink(logger), nil
+	case types.SinkTypeSyslog:
+		return NewSyslogSink(config.Syslog)
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", config.Type)
+	}
+}
+
+// stdoutSink writes the entries through the logger of the application, which usually writes to stdout
+type stdoutSink struct {
+	logger models.Logger
+}
+
+func NewStdoutSink(logger models.Logger) LogSink {
+	return &stdoutSink{logger: logger}
+}
+
+func (s *stdoutSink) Write(ctx context.Context, entries []*types.LogEntry) error {
+	for _, entry := range entries {
+		s.logger.Info("log entry", "event_type", entry.EventType, "entry", entry)
+	}
+	return nil
+}
+
+func (s *stdoutSink) Close() error {
+	return nil
+}
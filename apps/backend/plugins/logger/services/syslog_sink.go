@@ -0,0 +1,83 @@
+//go:build !windows && !plan9
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// syslogSink sends every entry as a JSON message at the info level
+type syslogSink struct {
+	config   types.SyslogSinkConfig
+	priority syslog.Priority
+	writer   *syslog.Writer
+}
+
+func NewSyslogSink(config types.SyslogSinkConfig) (LogSink, error) {
+	facility, ok := syslogFacilities[config.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", config.Facility)
+	}
+	return &syslogSink{config: config, priority: facility | syslog.LOG_INFO}, nil
+}
+
+func (s *syslogSink) Write(ctx context.Context, entries []*types.LogEntry) error {
+	if s.writer == nil {
+		writer, err := syslog.Dial(s.config.Network, s.config.Address, s.priority, s.config.Tag)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s.writer = writer
+	}
+
+	for _, entry := range entries {
+		message, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode log entry: %w", err)
+		}
+		// The writer reconnects once by itself, give up on the batch when that fails as well
+		if err := s.writer.Info(string(message)); err != nil {
+			s.writer.Close()
+			s.writer = nil
+			return fmt.Errorf("failed to write to syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.writer == nil {
+		return nil
+	}
+	err := s.writer.Close()
+	s.writer = nil
+	return err
+}
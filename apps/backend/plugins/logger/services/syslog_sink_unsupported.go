@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package services
+
+import (
+	"errors"
+
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+func NewSyslogSink(config types.SyslogSinkConfig) (LogSink, error) {
+	return nil, errors.New("the syslog sink is not supported on this platform")
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// UserDataEraser erases the data of a user kept outside of the log entries, e.g. by another plugin
+type UserDataEraser interface {
+	// EraseUserData erases the records of the user and returns how many were erased
+	EraseUserData(ctx context.Context, userID string) (int64, error)
+}
+
+// UserDataErasers holds the erasers run by EraseUserLogs
+type UserDataErasers struct {
+	mu      sync.RWMutex
+	erasers []UserDataEraser
+}
+
+// NewUserDataErasers creates an empty set of erasers
+func NewUserDataErasers() *UserDataErasers {
+	return &UserDataErasers{}
+}
+
+// Register adds an eraser, it runs on every later erasure
+func (e *UserDataErasers) Register(eraser UserDataEraser) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.erasers = append(e.erasers, eraser)
+}
+
+// EraseUserData runs every eraser and returns the total number of erased records
+func (e *UserDataErasers) EraseUserData(ctx context.Context, userID string) (int64, error) {
+	e.mu.RLock()
+	erasers := append([]UserDataEraser(nil), e.erasers...)
+	e.mu.RUnlock()
+
+	var total int64
+	for _, eraser := range erasers {
+		erased, err := eraser.EraseUserData(ctx, userID)
+		total += erased
+		if err != nil {
+			return total, fmt.Errorf("failed to erase user data: %w", err)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+)
+
+const (
+	// signInPathSuffix identifies the email-password sign-in route under any base path
+	signInPathSuffix = "/email-password/sign-in"
+	// maxSignInBodyPeek is how much of the sign-in request body is read to find the email
+	maxSignInBodyPeek = 16 << 10
+)
+
+// monitorSignIns publishes a failed sign-in event for every rejected email-password sign-in
+func (p *LoggerPlugin) monitorSignIns(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), signInPathSuffix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The handler still reads the whole body, the peeked part is put back in front of the rest
+		peeked, _ := io.ReadAll(io.LimitReader(r.Body, maxSignInBodyPeek))
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(peeked), r.Body), r.Body}
+
+		next.ServeHTTP(w, r)
+
+		reqCtx, ok := models.GetRequestContext(r.Context())
+		if !ok || reqCtx.ResponseStatus != http.StatusUnauthorized {
+			return
+		}
+
+		var request struct {
+			Email string `json:"email"`
+		}
+		if err := json.Unmarshal(peeked, &request); err != nil || strings.TrimSpace(request.Email) == "" {
+			return
+		}
+
+		payload, err := json.Marshal(map[string]string{
+			"email":      strings.ToLower(strings.TrimSpace(request.Email)),
+			"ip_address": reqCtx.ClientIP,
+			"user_agent": r.UserAgent(),
+		})
+		if err != nil {
+			return
+		}
+		if err := p.ctx.EventBus.Publish(r.Context(), models.Event{
+			Type:      constants.EventUserSignInFailed,
+			Timestamp: time.Now().UTC(),
+			Payload:   payload,
+		}); err != nil {
+			p.logger.Error("failed to publish failed sign-in event", "error", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+)
+
+// recordingEventBus keeps the published events instead of delivering them
+type recordingEventBus struct {
+	mu     sync.Mutex
+	events []models.Event
+}
+
+func (b *recordingEventBus) Publish(ctx context.Context, event models.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+	return nil
+}
+
+func (b *recordingEventBus) Subscribe(eventType string, handler models.EventHandler) (models.SubscriptionID, error) {
+	return 0, nil
+}
+
+func (b *recordingEventBus) Unsubscribe(eventType string, id models.SubscriptionID) {}
+
+func (b *recordingEventBus) Close() error {
+	return nil
+}
+
+func TestMonitorSignIns(t *testing.T) {
+	const body = `{"email":" User@Example.com ","password":"wrong"}`
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		status      int
+		wantPublish bool
+	}{
+		{name: "rejected sign-in", method: http.MethodPost, path: "/api/auth/email-password/sign-in", status: http.StatusUnauthorized, wantPublish: true},
+		{name: "trailing slash", method: http.MethodPost, path: "/api/auth/email-password/sign-in/", status: http.StatusUnauthorized, wantPublish: true},
+		{name: "successful sign-in", method: http.MethodPost, path: "/api/auth/email-password/sign-in", status: http.StatusOK},
+		{name: "invalid request", method: http.MethodPost, path: "/api/auth/email-password/sign-in", status: http.StatusBadRequest},
+		{name: "other route", method: http.MethodPost, path: "/api/auth/email-password/sign-up", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := &recordingEventBus{}
+			plugin := &LoggerPlugin{
+				ctx:    &models.PluginContext{EventBus: bus},
+				logger: slog.New(slog.DiscardHandler),
+			}
+
+			var handlerBody string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				raw, _ := io.ReadAll(r.Body)
+				handlerBody = string(raw)
+				reqCtx, _ := models.GetRequestContext(r.Context())
+				reqCtx.SetJSONResponse(tt.status, map[string]any{})
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+			reqCtx := &models.RequestContext{
+				Request:         req,
+				ClientIP:        "203.0.113.7",
+				Values:          make(map[string]any),
+				ResponseHeaders: make(http.Header),
+			}
+			req = req.WithContext(models.NewContextWithRequestContext(req.Context(), reqCtx))
+			plugin.monitorSignIns(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, body, handlerBody, "the handler reads the whole body")
+			if !tt.wantPublish {
+				assert.Empty(t, bus.events)
+				return
+			}
+			require.Len(t, bus.events, 1)
+			assert.Equal(t, constants.EventUserSignInFailed, bus.events[0].Type)
+			var payload map[string]string
+			require.NoError(t, json.Unmarshal(bus.events[0].Payload, &payload))
+			assert.Equal(t, "user@example.com", payload["email"])
+			assert.Equal(t, "203.0.113.7", payload["ip_address"])
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// SinkWorker feeds a sink from its own queue and goroutine
+type SinkWorker struct {
+	name    string
+	sink    services.LogSink
+	filter  *EventFilter
+	logger  models.Logger
+	batches chan []*types.LogEntry
+	done    chan struct{}
+	// failing and dropping make sure a broken sink is reported once rather than for every batch
+	failing  atomic.Bool
+	dropping atomic.Bool
+	dropped  atomic.Int64
+}
+
+func NewSinkWorker(logger models.Logger, config types.SinkConfig, sink services.LogSink) *SinkWorker {
+	return &SinkWorker{
+		name: config.Name,
+		sink: sink,
+		filter: &EventFilter{
+			include: config.IncludeEventTypes,
+			exclude: config.ExcludeEventTypes,
+		},
+		logger:  logger,
+		batches: make(chan []*types.LogEntry, config.BufferSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start runs the write loop until Close is called
+func (w *SinkWorker) Start() {
+	go w.runWriteLoop()
+}
+
+// Accepts reports whether the sink receives the events of the given type
+func (w *SinkWorker) Accepts(eventType string) bool {
+	return w.filter.Allows(models.Event{Type: eventType})
+}
+
+// Topics returns the event bus topics the sink needs
+func (w *SinkWorker) Topics() []string {
+	return w.filter.Topics()
+}
+
+// Enqueue queues the entries the sink accepts without blocking, they are dropped when the queue is full
+func (w *SinkWorker) Enqueue(entries []*types.LogEntry) {
+	accepted := make([]*types.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if w.Accepts(entry.EventType) {
+			accepted = append(accepted, entry)
+		}
+	}
+	if len(accepted) == 0 {
+		return
+	}
+
+	select {
+	case w.batches <- accepted:
+		if w.dropping.CompareAndSwap(true, false) {
+			w.logger.Info("log sink caught up", "sink", w.name, "dropped", w.dropped.Load())
+		}
+	default:
+		w.dropped.Add(int64(len(accepted)))
+		if w.dropping.CompareAndSwap(false, true) {
+			w.logger.Warn("log sink is falling behind, dropping entries", "sink", w.name)
+		}
+	}
+}
+
+// Close waits until the queued entries were written and closes the sink
+func (w *SinkWorker) Close() {
+	close(w.batches)
+	<-w.done
+	if err := w.sink.Close(); err != nil {
+		w.logger.Error("failed to close log sink", "sink", w.name, "error", err)
+	}
+}
+
+func (w *SinkWorker) runWriteLoop() {
+	defer close(w.done)
+
+	for batch := range w.batches {
+		if err := w.write(batch); err != nil {
+			w.dropped.Add(int64(len(batch)))
+			if w.failing.CompareAndSwap(false, true) {
+				w.logger.Error("log sink failed, dropping entries until it recovers", "sink", w.name, "error", err)
+			}
+			continue
+		}
+		if w.failing.CompareAndSwap(true, false) {
+			w.logger.Info("log sink recovered", "sink", w.name, "dropped", w.dropped.Load())
+		}
+	}
+	w.logger.Debug("log sink stopped", "sink", w.name)
+}
+
+// write keeps a panicking sink from taking the process down
+func (w *SinkWorker) write(batch []*types.LogEntry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sink panicked: %v", r)
+		}
+	}()
+	return w.sink.Write(context.Background(), batch)
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+type LogStatsHandler struct {
+	service services.LoggerService
+	logger  models.Logger
+}
+
+func (h *LogStatsHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		query, err := parseStatsQuery(r.URL.Query(), time.Now())
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		stats, err := h.service.GetLogStats(ctx, query)
+		if err != nil {
+			h.logger.Error("failed to get log stats", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to get log stats",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, stats)
+	}
+}
+
+// parseStatsQuery reads the event_type, bucket, from and to query parameters
+func parseStatsQuery(values url.Values, now time.Time) (types.StatsQuery, error) {
+	bucket, err := types.ParseStatsBucket(strings.ToLower(strings.TrimSpace(values.Get("bucket"))))
+	if err != nil {
+		return types.StatsQuery{}, err
+	}
+
+	to, err := parseTimeParam(values, "to")
+	if err != nil {
+		return types.StatsQuery{}, err
+	}
+	if to == nil {
+		end := now.UTC()
+		to = &end
+	}
+
+	from, err := parseTimeParam(values, "from")
+	if err != nil {
+		return types.StatsQuery{}, err
+	}
+	if from == nil {
+		start := to.Add(-types.DefaultStatsRange)
+		from = &start
+	}
+
+	if !from.Before(*to) {
+		return types.StatsQuery{}, fmt.Errorf("from must be before to")
+	}
+	if to.Sub(*from) > types.MaxStatsBuckets*bucket.Duration() {
+		return types.StatsQuery{}, fmt.Errorf("the range spans more than %d buckets, use a larger bucket", types.MaxStatsBuckets)
+	}
+
+	return types.StatsQuery{
+		EventTypes: parseEventTypesParam(values),
+		From:       *from,
+		To:         *to,
+		Bucket:     bucket,
+	}, nil
+}
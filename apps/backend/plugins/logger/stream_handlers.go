@@ -0,0 +1,196 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/logger/types"
+)
+
+// streamRetry is the reconnection delay in milliseconds suggested to clients
+const streamRetry = 3000
+
+// errStreamResumeLimit stops the replay of stored entries once the resume limit is reached
+var errStreamResumeLimit = errors.New("stream resume limit reached")
+
+// StreamLogEntriesHandler tails the log over Server-Sent Events, resuming from Last-Event-ID
+type StreamLogEntriesHandler struct {
+	service           services.LoggerService
+	stream            *LogStream
+	logger            models.Logger
+	getConfig         func() *models.Config
+	heartbeatInterval time.Duration
+	maxResumeEntries  int
+}
+
+func (h *StreamLogEntriesHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		values := r.URL.Query()
+		filter := types.LogEntryFilter{
+			EventTypes: parseEventTypesParam(values),
+			UserID:     parseStringParam(values, "user_id"),
+		}
+
+		lastEventID, err := parseLastEventID(r)
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		raw, ok := connectionWriter(r)
+		if !ok {
+			h.logger.Error("the log stream requires the logger.StreamingHandler around the Authula handler")
+			reqCtx.SetJSONResponse(http.StatusNotImplemented, map[string]any{
+				"message": "log stream is not available",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		h.tail(raw, r, filter, lastEventID)
+	}
+}
+
+// parseLastEventID reads the Last-Event-ID header or the last_event_id query parameter
+func parseLastEventID(r *http.Request) (*int64, error) {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.URL.Query().Get("last_event_id"))
+	}
+	if raw == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return nil, fmt.Errorf("invalid last event id")
+	}
+	return &id, nil
+}
+
+// tail subscribes before replaying the missed entries so that nothing stored in between is lost
+func (h *StreamLogEntriesHandler) tail(raw http.ResponseWriter, r *http.Request, filter types.LogEntryFilter, lastEventID *int64) {
+	controller := http.NewResponseController(raw)
+	// The stream outlives any write timeout of the server
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Debug("failed to clear the write deadline of the log stream", "error", err)
+	}
+
+	client := h.stream.Subscribe(filter)
+	defer h.stream.Unsubscribe(client)
+
+	header := raw.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-store")
+	// Keeps reverse proxies such as nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	writeStreamingHeader(raw, r, h.getConfig().Security.CORS, http.StatusOK)
+
+	flush := func() error {
+		if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(raw, "retry: %d\n\n", streamRetry); err != nil {
+		return
+	}
+
+	var lastSentID int64
+	if lastEventID != nil {
+		lastSentID = *lastEventID
+		replayFilter := filter
+		replayFilter.AfterID = lastEventID
+
+		replayed := 0
+		err := h.service.ExportLogEntries(r.Context(), replayFilter, types.SortOrderAsc, func(entry *types.LogEntry) error {
+			if replayed >= h.maxResumeEntries {
+				return errStreamResumeLimit
+			}
+			if err := writeStreamEvent(raw, entry); err != nil {
+				return err
+			}
+			replayed++
+			lastSentID = entry.ID
+			return nil
+		})
+		switch {
+		case errors.Is(err, errStreamResumeLimit):
+			if _, err := fmt.Fprintf(raw, ": resume limited to %d entries\n\n", h.maxResumeEntries); err != nil {
+				return
+			}
+		case err != nil:
+			h.logger.Error("failed to replay log entries", "last_event_id", *lastEventID, "error", err)
+			return
+		}
+	}
+	if err := flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := io.WriteString(raw, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := flush(); err != nil {
+				return
+			}
+
+		case entry, ok := <-client.Entries:
+			if !ok {
+				if h.stream.Dropped(client) {
+					h.logger.Debug("dropped slow log stream client", "last_event_id", lastSentID)
+					// The client reconnects with the ID of the last entry it received and resumes from there
+					_, _ = io.WriteString(raw, ": dropped, the client did not keep up\n\n")
+					_ = flush()
+				}
+				return
+			}
+			if entry.ID <= lastSentID {
+				continue
+			}
+			if err := writeStreamEvent(raw, entry); err != nil {
+				return
+			}
+			lastSentID = entry.ID
+			if err := flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeStreamEvent writes the entry as an event named after its event type
+func writeStreamEvent(w io.Writer, entry *types.LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	eventType := strings.NewReplacer("\r", "", "\n", "").Replace(entry.EventType)
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.ID, eventType, data)
+	return err
+}
@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+const (
+	DefaultActivityLimit = 20
+	MaxActivityLimit     = 100
+)
+
+// ActivityItem is a log entry rendered for the user it belongs to
+type ActivityItem struct {
+	ID        int64  `json:"id"`
+	EventType string `json:"event_type"`
+	// Title describes what happened, e.g. "Signed in"
+	Title string `json:"title"`
+	// Device is a short description of the browser and operating system, e.g. "Chrome on macOS"
+	Device    string  `json:"device"`
+	IPAddress *string `json:"ip_address"`
+	// ApproximateTime is a human readable relative time, e.g. "5 minutes ago"
+	ApproximateTime string    `json:"approximate_time"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+type ActivityPage struct {
+	Items      []ActivityItem `json:"items"`
+	NextCursor *string        `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AckMode decides when the event bus handler acknowledges an event
+type AckMode string
+
+const (
+	// AckModeQueued acknowledges an event once the log writer queued it. Entries that cannot be
+	// stored are retried and dead-lettered in the background, the publisher never waits for them.
+	AckModeQueued AckMode = "queued"
+	// AckModeStored waits until the entry was stored or dead-lettered and returns an error to the
+	// event bus when neither worked, so that an event bus that redelivers can try again
+	AckModeStored AckMode = "stored"
+)
+
+// DeadLetter is a log entry that could not be stored after its retries, kept for admins to replay
+type DeadLetter struct {
+	bun.BaseModel `bun:"table:log_dead_letters"`
+
+	ID        int64   `json:"id" bun:"column:id,pk,autoincrement"`
+	EventID   *string `json:"event_id" bun:"column:event_id"`
+	EventType string  `json:"event_type" bun:"column:event_type"`
+	// Entry is the redacted log entry as JSON, replaying it stores the entry as it was built
+	Entry         json.RawMessage `json:"entry" bun:"column:entry"`
+	LastError     string          `json:"last_error" bun:"column:last_error"`
+	Attempts      int             `json:"attempts" bun:"column:attempts"`
+	CreatedAt     time.Time       `json:"created_at" bun:"column:created_at"`
+	LastAttemptAt time.Time       `json:"last_attempt_at" bun:"column:last_attempt_at"`
+}
+
+// NewDeadLetter keeps an entry that failed to store together with the error of its last attempt
+func NewDeadLetter(entry *LogEntry, cause error, attempts int, at time.Time) (*DeadLetter, error) {
+	stripped := *entry
+	stripped.ID = 0
+	stripped.ContentHash = nil
+	stripped.PrevHash = nil
+	stripped.Hash = nil
+	raw, err := json.Marshal(&stripped)
+	if err != nil {
+		return nil, err
+	}
+	return &DeadLetter{
+		EventID:       entry.EventID,
+		EventType:     entry.EventType,
+		Entry:         raw,
+		LastError:     cause.Error(),
+		Attempts:      attempts,
+		CreatedAt:     at.UTC(),
+		LastAttemptAt: at.UTC(),
+	}, nil
+}
+
+// LogEntry decodes the entry of the dead letter so that it can be stored again
+func (d *DeadLetter) LogEntry() (*LogEntry, error) {
+	entry := new(LogEntry)
+	if err := json.Unmarshal(d.Entry, entry); err != nil {
+		return nil, err
+	}
+	entry.ID = 0
+	entry.ContentHash = nil
+	entry.PrevHash = nil
+	entry.Hash = nil
+	return entry, nil
+}
+
+const (
+	DefaultDeadLettersLimit = 50
+	MaxDeadLettersLimit     = 200
+)
+
+// DeadLetterQuery describes a single page of dead letters, newest first
+type DeadLetterQuery struct {
+	// Cursor is the opaque NextCursor value of the previous page
+	Cursor *string
+	Limit  int
+}
+
+type DeadLettersPage struct {
+	DeadLetters []DeadLetter `json:"dead_letters"`
+	NextCursor  *string      `json:"next_cursor,omitempty"`
+}
+
+// DeadLetterReplay is the outcome of replaying a dead letter, Entry is nil when it was already stored
+type DeadLetterReplay struct {
+	Entry     *LogEntry `json:"entry"`
+	Duplicate bool      `json:"duplicate"`
+}
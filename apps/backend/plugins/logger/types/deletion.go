@@ -0,0 +1,19 @@
+package types
+
+// DeletionRequest asks for the deletion of every log entry matching a filter
+type DeletionRequest struct {
+	// Filter selects the entries to delete, it must not be empty
+	Filter LogEntryFilter
+	// RequestedBy records who asked for the deletion in the audit entry, such as the admin user ID
+	RequestedBy string
+	// DryRun only counts the matching entries
+	DryRun bool
+}
+
+// DeletionResult reports a deletion of log entries. AuditEntryID is nil for a dry run.
+type DeletionResult struct {
+	DryRun         bool   `json:"dry_run"`
+	EntriesMatched int64  `json:"entries_matched"`
+	EntriesDeleted int64  `json:"entries_deleted"`
+	AuditEntryID   *int64 `json:"audit_entry_id"`
+}
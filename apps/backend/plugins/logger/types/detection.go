@@ -0,0 +1,105 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// DetectionConfig configures the brute-force and credential-stuffing detection over failed sign-ins
+type DetectionConfig struct {
+	Enabled bool `json:"enabled" toml:"enabled"`
+	// BruteForceThreshold is how many failed sign-ins for one account raise an alert, defaults to 5
+	BruteForceThreshold int `json:"brute_force_threshold" toml:"brute_force_threshold"`
+	// BruteForceWindow is the sliding window of BruteForceThreshold, defaults to 15 minutes
+	BruteForceWindow time.Duration `json:"brute_force_window" toml:"brute_force_window"`
+	// CredentialStuffingThreshold is how many distinct accounts failing to sign in from one IP
+	// address raise an alert, defaults to 10
+	CredentialStuffingThreshold int `json:"credential_stuffing_threshold" toml:"credential_stuffing_threshold"`
+	// CredentialStuffingWindow is the sliding window of CredentialStuffingThreshold, defaults to 10 minutes
+	CredentialStuffingWindow time.Duration `json:"credential_stuffing_window" toml:"credential_stuffing_window"`
+	// AlertCooldown is how long no new alert of the same type is raised for the same account or
+	// IP address, defaults to the window of the alert
+	AlertCooldown time.Duration `json:"alert_cooldown" toml:"alert_cooldown"`
+}
+
+func (c *DetectionConfig) validate() error {
+	if c.BruteForceThreshold < 0 || c.CredentialStuffingThreshold < 0 {
+		return fmt.Errorf("detection thresholds must not be negative")
+	}
+	if c.BruteForceThreshold == 0 {
+		c.BruteForceThreshold = 5
+	}
+	if c.BruteForceWindow <= 0 {
+		c.BruteForceWindow = 15 * time.Minute
+	}
+	if c.CredentialStuffingThreshold == 0 {
+		c.CredentialStuffingThreshold = 10
+	}
+	if c.CredentialStuffingWindow <= 0 {
+		c.CredentialStuffingWindow = 10 * time.Minute
+	}
+	return nil
+}
+
+// Cooldown returns how long alerts with the given window are suppressed after they were raised
+func (c *DetectionConfig) Cooldown(window time.Duration) time.Duration {
+	if c.AlertCooldown > 0 {
+		return c.AlertCooldown
+	}
+	return window
+}
+
+type SecurityAlertType string
+
+const (
+	// SecurityAlertBruteForce means many failed sign-ins for a single account
+	SecurityAlertBruteForce SecurityAlertType = "brute_force"
+	// SecurityAlertCredentialStuffing means failed sign-ins for many accounts from a single IP address
+	SecurityAlertCredentialStuffing SecurityAlertType = "credential_stuffing"
+)
+
+type SecurityAlertStatus string
+
+const (
+	SecurityAlertStatusOpen         SecurityAlertStatus = "open"
+	SecurityAlertStatusAcknowledged SecurityAlertStatus = "acknowledged"
+)
+
+// SecurityAlert is a detection that crossed its threshold, kept for admins to review
+type SecurityAlert struct {
+	bun.BaseModel `bun:"table:log_security_alerts"`
+
+	ID        int64             `json:"id" bun:"column:id,pk,autoincrement"`
+	AlertType SecurityAlertType `json:"alert_type" bun:"column:alert_type"`
+	// Email is the account of a brute-force alert
+	Email *string `json:"email" bun:"column:email"`
+	// IPAddress is the source of a credential-stuffing alert
+	IPAddress      *string    `json:"ip_address" bun:"column:ip_address"`
+	EventCount     int        `json:"event_count" bun:"column:event_count"`
+	Threshold      int        `json:"threshold" bun:"column:threshold"`
+	WindowSeconds  int64      `json:"window_seconds" bun:"column:window_seconds"`
+	CreatedAt      time.Time  `json:"created_at" bun:"column:created_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at" bun:"column:acknowledged_at"`
+	AcknowledgedBy *string    `json:"acknowledged_by" bun:"column:acknowledged_by"`
+}
+
+const (
+	DefaultSecurityAlertsLimit = 50
+	MaxSecurityAlertsLimit     = 200
+)
+
+// SecurityAlertQuery describes a single page of security alerts, newest first
+type SecurityAlertQuery struct {
+	// Status restricts the alerts to open or acknowledged ones, empty returns both
+	Status SecurityAlertStatus
+	// Cursor is the opaque NextCursor value of the previous page
+	Cursor *string
+	Limit  int
+}
+
+type SecurityAlertsPage struct {
+	Alerts     []SecurityAlert `json:"alerts"`
+	NextCursor *string         `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,145 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ErasureMode decides how the log entries of an erased user are removed
+type ErasureMode string
+
+const (
+	// ErasureModeAnonymize keeps the entries but replaces the user ID with a pseudonym and clears the
+	// session, client and payload fields, so that the entries still count towards stats and limits
+	ErasureModeAnonymize ErasureMode = "anonymize"
+	// ErasureModeDelete deletes the entries
+	ErasureModeDelete ErasureMode = "delete"
+)
+
+// ErasedEntry is the tombstone of an erased log entry, it keeps the hashes of the entry
+type ErasedEntry struct {
+	bun.BaseModel `bun:"table:log_erased_entries"`
+
+	EntryID     int64       `json:"entry_id" bun:"column:entry_id,pk"`
+	ContentHash *string     `json:"content_hash" bun:"column:content_hash"`
+	PrevHash    *string     `json:"prev_hash" bun:"column:prev_hash"`
+	Hash        *string     `json:"hash" bun:"column:hash"`
+	Mode        ErasureMode `json:"mode" bun:"column:mode"`
+	ErasedAt    time.Time   `json:"erased_at" bun:"column:erased_at"`
+}
+
+// LogEntry returns the hashes of the tombstone as an entry without content, to verify its link
+func (e *ErasedEntry) LogEntry() *LogEntry {
+	return &LogEntry{
+		ID:          e.EntryID,
+		ContentHash: e.ContentHash,
+		PrevHash:    e.PrevHash,
+		Hash:        e.Hash,
+	}
+}
+
+// Matches reports whether an anonymized entry still has the hashes it had when it was erased
+func (e *ErasedEntry) Matches(entry *LogEntry) bool {
+	equal := func(a, b *string) bool {
+		return a != nil && b != nil && *a == *b
+	}
+	return equal(e.ContentHash, entry.ContentHash) && equal(e.PrevHash, entry.PrevHash) && equal(e.Hash, entry.Hash)
+}
+
+// ErasedPseudonym anonymizes restored entries whose erasure recorded no pseudonym
+const ErasedPseudonym = "erased"
+
+// ErasedUser records an erased user under the hash of its ID, so that restored archives are erased as well
+type ErasedUser struct {
+	bun.BaseModel `bun:"table:log_erased_users"`
+
+	Subject   string      `json:"subject" bun:"column:subject,pk"`
+	Mode      ErasureMode `json:"mode" bun:"column:mode"`
+	Pseudonym *string     `json:"pseudonym" bun:"column:pseudonym"`
+	ErasedAt  time.Time   `json:"erased_at" bun:"column:erased_at"`
+}
+
+// ErasedUserSubject returns the hash of the user ID an ErasedUser is recorded under
+func ErasedUserSubject(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(sum[:])
+}
+
+// Anonymize replaces the user ID with the pseudonym and clears the fields an anonymizing erasure clears
+func (e *LogEntry) Anonymize(pseudonym string) {
+	e.UserID = &pseudonym
+	e.SessionID = nil
+	e.IPAddress = nil
+	e.UserAgent = nil
+	e.Details = json.RawMessage("{}")
+}
+
+// ApplyErasures erases the restored entries of erased users and entries, returning the kept entries and the new tombstones
+func ApplyErasures(entries []LogEntry, tombstones []ErasedEntry, users []ErasedUser, at time.Time) ([]LogEntry, []ErasedEntry) {
+	modes := make(map[int64]ErasureMode, len(tombstones))
+	for _, tombstone := range tombstones {
+		modes[tombstone.EntryID] = tombstone.Mode
+	}
+	erasedUsers := make(map[string]ErasedUser, len(users))
+	for _, user := range users {
+		erasedUsers[user.Subject] = user
+	}
+
+	kept := make([]LogEntry, 0, len(entries))
+	var erased []ErasedEntry
+	for _, entry := range entries {
+		mode := modes[entry.ID]
+		pseudonym := ErasedPseudonym
+		if entry.UserID != nil {
+			if user, ok := erasedUsers[ErasedUserSubject(*entry.UserID)]; ok {
+				if mode != ErasureModeDelete {
+					mode = user.Mode
+				}
+				if user.Pseudonym != nil {
+					pseudonym = *user.Pseudonym
+				}
+			}
+		}
+		if mode == "" {
+			kept = append(kept, entry)
+			continue
+		}
+
+		erased = append(erased, ErasedEntry{
+			EntryID:     entry.ID,
+			ContentHash: entry.ContentHash,
+			PrevHash:    entry.PrevHash,
+			Hash:        entry.Hash,
+			Mode:        mode,
+			ErasedAt:    at.UTC(),
+		})
+		if mode != ErasureModeDelete {
+			entry.Anonymize(pseudonym)
+			kept = append(kept, entry)
+		}
+	}
+	return kept, erased
+}
+
+// ErasureRequest asks to erase the log entries of a user
+type ErasureRequest struct {
+	UserID string
+	// RequestedBy records who asked for the erasure in the receipt, such as the admin user ID
+	RequestedBy string
+	// EventID is the ID of the event that triggered the erasure, a redelivered event does not
+	// write a second receipt
+	EventID string
+}
+
+// ErasureResult reports an erasure, ReceiptID is nil when the receipt was already written
+type ErasureResult struct {
+	Mode          ErasureMode `json:"mode"`
+	EntriesErased int64       `json:"entries_erased"`
+	// RecordsErased counts the records erased by the UserDataErasers, such as webhook deliveries
+	RecordsErased int64  `json:"records_erased"`
+	ReceiptID     *int64 `json:"receipt_id"`
+}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Authula/authula-playground/requestid"
+)
+
+const (
+	MaxIPAddressLength = 45
+	MaxUserAgentLength = 512
+)
+
+var (
+	userIDKeys    = []string{"user_id", "userID", "userId"}
+	sessionIDKeys = []string{"session_id", "sessionID", "sessionId"}
+	ipAddressKeys = []string{"ip_address", "client_ip", "ipAddress", "clientIP", "ip"}
+	userAgentKeys = []string{"user_agent", "userAgent"}
+	// nestedKeys are the payload objects that are searched after the top level
+	nestedKeys = []string{"metadata", "session", "user"}
+)
+
+// ApplyEventFields fills the structured columns of the entry from an event payload and its metadata
+func (e *LogEntry) ApplyEventFields(payload []byte, metadata map[string]string) {
+	var object map[string]any
+	_ = json.Unmarshal(payload, &object)
+
+	lookup := func(keys []string) *string {
+		for _, key := range keys {
+			if value := metadata[key]; value != "" {
+				return &value
+			}
+		}
+		if value := lookupString(object, keys); value != nil {
+			return value
+		}
+		for _, nestedKey := range nestedKeys {
+			nested, _ := object[nestedKey].(map[string]any)
+			if value := lookupString(nested, keys); value != nil {
+				return value
+			}
+		}
+		return nil
+	}
+
+	e.UserID = lookup(userIDKeys)
+	if e.UserID == nil {
+		if _, hasEmail := object["email"]; hasEmail {
+			e.UserID = lookupString(object, []string{"id"})
+		}
+	}
+	e.SessionID = lookup(sessionIDKeys)
+	e.IPAddress = truncate(lookup(ipAddressKeys), MaxIPAddressLength)
+	e.UserAgent = truncate(lookup(userAgentKeys), MaxUserAgentLength)
+	if id := metadata[requestid.MetadataKey]; requestid.IsValid(id) {
+		e.RequestID = &id
+	}
+}
+
+// NormalizePayload returns the payload as a valid JSON document
+func NormalizePayload(payload []byte) json.RawMessage {
+	if len(payload) == 0 {
+		return json.RawMessage("{}")
+	}
+	if json.Valid(payload) {
+		return json.RawMessage(payload)
+	}
+	encoded, _ := json.Marshal(string(payload))
+	return json.RawMessage(encoded)
+}
+
+func lookupString(object map[string]any, keys []string) *string {
+	for _, key := range keys {
+		switch value := object[key].(type) {
+		case string:
+			if value != "" {
+				return &value
+			}
+		case float64:
+			formatted := fmt.Sprintf("%v", value)
+			return &formatted
+		}
+	}
+	return nil
+}
+
+func truncate(value *string, length int) *string {
+	if value == nil || len(*value) <= length {
+		return value
+	}
+	// Drop a multi-byte rune that was cut in half
+	truncated := strings.ToValidUTF8((*value)[:length], "")
+	return &truncated
+}
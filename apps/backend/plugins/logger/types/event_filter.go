@@ -0,0 +1,62 @@
+package types
+
+import "strings"
+
+// SamplingRule stores only a share of the events of the matching event types
+type SamplingRule struct {
+	// EventTypes are exact event types or glob patterns such as "session.*"
+	EventTypes []string `json:"event_types" toml:"event_types"`
+	// Rate is the share of events that is stored, between 0 (none) and 1 (all)
+	Rate float64 `json:"rate" toml:"rate"`
+}
+
+// EventFilterConfig is the effective set of event filters of the plugin
+type EventFilterConfig struct {
+	IncludeEventTypes []string       `json:"include_event_types"`
+	ExcludeEventTypes []string       `json:"exclude_event_types"`
+	SamplingRules     []SamplingRule `json:"sampling_rules"`
+	// Subscriptions are the event bus topics the plugin subscribes to
+	Subscriptions []string `json:"subscriptions"`
+}
+
+// IsEventTypePattern reports whether the event type is a glob pattern rather than an exact type
+func IsEventTypePattern(eventType string) bool {
+	return strings.ContainsAny(eventType, "*?")
+}
+
+// MatchEventType reports whether the event type matches the glob pattern
+func MatchEventType(pattern string, eventType string) bool {
+	p, t := []rune(pattern), []rune(eventType)
+	// starP and starT remember the last "*" to backtrack to when the rest does not match
+	starP, starT := -1, 0
+	i, j := 0, 0
+	for j < len(t) {
+		switch {
+		case i < len(p) && p[i] == '*':
+			starP, starT = i, j
+			i++
+		case i < len(p) && (p[i] == '?' || p[i] == t[j]):
+			i++
+			j++
+		case starP >= 0:
+			starT++
+			i, j = starP+1, starT
+		default:
+			return false
+		}
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
+
+// MatchesAnyEventType reports whether the event type matches any of the patterns
+func MatchesAnyEventType(patterns []string, eventType string) bool {
+	for _, pattern := range patterns {
+		if MatchEventType(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
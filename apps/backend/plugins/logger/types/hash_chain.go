@@ -0,0 +1,132 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// chainTimeFormat is the hashed created_at representation, in the microseconds every dialect stores
+const chainTimeFormat = "2006-01-02T15:04:05.000000Z"
+
+// ComputeContentHash returns the hex SHA-256 of the entry content with the details in canonical form
+func (e *LogEntry) ComputeContentHash() string {
+	fields := []any{
+		e.EventID,
+		e.EventType,
+		e.UserID,
+		e.SessionID,
+		e.IPAddress,
+		e.UserAgent,
+		canonicalJSON(e.Details),
+		e.CreatedAt.UTC().Format(chainTimeFormat),
+	}
+	// The request ID is only hashed when it is set, entries sealed before it existed keep their hash
+	if e.RequestID != nil {
+		fields = append(fields, *e.RequestID)
+	}
+	content, _ := json.Marshal(fields)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Seal links the entry to the previous entry of the chain by filling in its hash columns
+func (e *LogEntry) Seal(prevHash string) {
+	contentHash := e.ComputeContentHash()
+	hash := ChainHash(prevHash, contentHash)
+	e.ContentHash = &contentHash
+	e.PrevHash = &prevHash
+	e.Hash = &hash
+}
+
+// ChainHash returns the hash of an entry from the hash of the previous entry and its own content hash
+func ChainHash(prevHash string, contentHash string) string {
+	sum := sha256.Sum256([]byte(prevHash + ":" + contentHash))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalJSON re-encodes a JSON document with sorted object keys and without insignificant whitespace
+func canonicalJSON(document json.RawMessage) json.RawMessage {
+	var value any
+	if err := json.Unmarshal(document, &value); err != nil {
+		return document
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return document
+	}
+	return encoded
+}
+
+// ChainHead is the last sealed entry of the hash chain, its row lock serializes the writers
+type ChainHead struct {
+	bun.BaseModel `bun:"table:log_chain_head"`
+
+	ID        int64     `json:"-" bun:"column:id,pk"`
+	EntryID   int64     `json:"entry_id" bun:"column:entry_id"`
+	Hash      string    `json:"hash" bun:"column:hash"`
+	UpdatedAt time.Time `json:"updated_at" bun:"column:updated_at"`
+}
+
+// ChainCheckpoint is a signed record of the chain hash at a given entry
+type ChainCheckpoint struct {
+	bun.BaseModel `bun:"table:log_chain_checkpoints"`
+
+	ID        int64     `json:"id" bun:"column:id,pk,autoincrement"`
+	EntryID   int64     `json:"entry_id" bun:"column:entry_id"`
+	Hash      string    `json:"hash" bun:"column:hash"`
+	Signature string    `json:"signature" bun:"column:signature"`
+	CreatedAt time.Time `json:"created_at" bun:"column:created_at"`
+}
+
+// Sign fills in the HMAC-SHA256 signature of the checkpoint
+func (c *ChainCheckpoint) Sign(key []byte) {
+	c.Signature = c.signature(key)
+}
+
+// VerifySignature reports whether the checkpoint was signed with the key
+func (c *ChainCheckpoint) VerifySignature(key []byte) bool {
+	return hmac.Equal([]byte(c.Signature), []byte(c.signature(key)))
+}
+
+func (c *ChainCheckpoint) signature(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(c.EntryID, 10) + ":" + c.Hash + ":" + c.CreatedAt.UTC().Format(chainTimeFormat)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type ChainBreakReason string
+
+const (
+	// ChainBreakUnsealed means the entry has no hash, it was inserted around the logger
+	ChainBreakUnsealed ChainBreakReason = "unsealed"
+	// ChainBreakContentModified means the entry content no longer matches its content hash
+	ChainBreakContentModified ChainBreakReason = "content_modified"
+	// ChainBreakHashModified means the stored hash does not follow from the previous hash and the content hash
+	ChainBreakHashModified ChainBreakReason = "hash_modified"
+	// ChainBreakLinkBroken means the entry does not point at the entry before it, entries were removed, inserted or reordered
+	ChainBreakLinkBroken ChainBreakReason = "link_broken"
+	// ChainBreakCheckpointSignature means the checkpoint was not signed with the configured key
+	ChainBreakCheckpointSignature ChainBreakReason = "checkpoint_signature_invalid"
+	// ChainBreakCheckpointMismatch means the entry of a checkpoint no longer has the signed hash
+	ChainBreakCheckpointMismatch ChainBreakReason = "checkpoint_mismatch"
+)
+
+// ChainBreak is the first link of the chain that failed verification
+type ChainBreak struct {
+	EntryID      int64            `json:"entry_id"`
+	CheckpointID *int64           `json:"checkpoint_id,omitempty"`
+	Reason       ChainBreakReason `json:"reason"`
+}
+
+type ChainVerification struct {
+	Valid              bool        `json:"valid"`
+	EntriesChecked     int64       `json:"entries_checked"`
+	CheckpointsChecked int         `json:"checkpoints_checked"`
+	FirstBrokenLink    *ChainBreak `json:"first_broken_link,omitempty"`
+}
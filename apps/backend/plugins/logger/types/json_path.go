@@ -0,0 +1,122 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath selects fields of a JSON document, e.g. "$.user.email", "$..password", "$.items[*].token" or "$..*token*"
+type JSONPath struct {
+	raw      string
+	segments []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	// recursive segments may skip any number of levels before they match
+	recursive bool
+	// key is a lower-cased glob pattern, empty for array segments
+	key string
+	// index is the array index, -1 matches every element
+	index   int
+	isIndex bool
+}
+
+// ParseJSONPath parses a path such as "$.user.email" or "$..password"
+func ParseJSONPath(path string) (JSONPath, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return JSONPath{}, fmt.Errorf("json path %q must start with $", path)
+	}
+
+	var segments []jsonPathSegment
+	for rest != "" {
+		segment := jsonPathSegment{}
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			segment.recursive = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "["):
+		default:
+			return JSONPath{}, fmt.Errorf("json path %q has an unexpected %q", path, rest[0])
+		}
+
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return JSONPath{}, fmt.Errorf("json path %q has an unclosed [", path)
+			}
+			selector := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			switch {
+			case selector == "*":
+				segment.isIndex, segment.index = true, -1
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				segment.key = strings.ToLower(selector[1 : len(selector)-1])
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil || index < 0 {
+					return JSONPath{}, fmt.Errorf("json path %q has an invalid array selector %q", path, selector)
+				}
+				segment.isIndex, segment.index = true, index
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segment.key = strings.ToLower(rest[:end])
+			rest = rest[end:]
+		}
+
+		if !segment.isIndex && segment.key == "" {
+			return JSONPath{}, fmt.Errorf("json path %q has an empty field name", path)
+		}
+		segments = append(segments, segment)
+	}
+
+	if len(segments) == 0 {
+		return JSONPath{}, fmt.Errorf("json path %q must select at least one field", path)
+	}
+	return JSONPath{raw: path, segments: segments}, nil
+}
+
+// String returns the path as it was written
+func (p JSONPath) String() string {
+	return p.raw
+}
+
+// Match reports whether the path selects the field at the given field names and array indexes
+func (p JSONPath) Match(steps []any) bool {
+	return matchJSONPath(p.segments, steps)
+}
+
+func matchJSONPath(segments []jsonPathSegment, steps []any) bool {
+	if len(segments) == 0 {
+		return len(steps) == 0
+	}
+
+	segment := segments[0]
+	if !segment.recursive {
+		return len(steps) > 0 && segment.matches(steps[0]) && matchJSONPath(segments[1:], steps[1:])
+	}
+	for skip := range steps {
+		if segment.matches(steps[skip]) && matchJSONPath(segments[1:], steps[skip+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s jsonPathSegment) matches(step any) bool {
+	switch step := step.(type) {
+	case string:
+		return !s.isIndex && MatchEventType(s.key, strings.ToLower(step))
+	case int:
+		return s.isIndex && (s.index < 0 || s.index == step)
+	}
+	return false
+}
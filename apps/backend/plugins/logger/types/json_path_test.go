@@ -0,0 +1,119 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "$.user.email"},
+		{path: "$..password"},
+		{path: "$.items[*].token"},
+		{path: "$.items[0]"},
+		{path: "$['user']['e-mail']"},
+		{path: "$..*token*"},
+		{path: "user.email", wantErr: "must start with $"},
+		{path: "$", wantErr: "at least one field"},
+		{path: "$.", wantErr: "empty field name"},
+		{path: "$.items[0", wantErr: "unclosed ["},
+		{path: "$.items[-1]", wantErr: "invalid array selector"},
+		{path: "$.items[a]", wantErr: "invalid array selector"},
+		{path: "$user", wantErr: "unexpected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := ParseJSONPath(tt.path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.path, path.String())
+		})
+	}
+}
+
+func TestJSONPath_Match(t *testing.T) {
+	tests := []struct {
+		path  string
+		steps []any
+		want  bool
+	}{
+		{path: "$.user.email", steps: []any{"user", "email"}, want: true},
+		{path: "$.user.email", steps: []any{"User", "Email"}, want: true},
+		{path: "$.user.email", steps: []any{"email"}, want: false},
+		{path: "$.user.email", steps: []any{"account", "user", "email"}, want: false},
+		{path: "$..password", steps: []any{"password"}, want: true},
+		{path: "$..password", steps: []any{"user", "credentials", "password"}, want: true},
+		{path: "$..password", steps: []any{"sessions", 2, "password"}, want: true},
+		{path: "$..password", steps: []any{"password", "hash"}, want: false},
+		{path: "$.items[*].token", steps: []any{"items", 0, "token"}, want: true},
+		{path: "$.items[*].token", steps: []any{"items", 7, "token"}, want: true},
+		{path: "$.items[*].token", steps: []any{"items", "token"}, want: false},
+		{path: "$.items[0]", steps: []any{"items", 0}, want: true},
+		{path: "$.items[0]", steps: []any{"items", 1}, want: false},
+		{path: "$['user']['e-mail']", steps: []any{"user", "e-mail"}, want: true},
+		{path: "$..*token*", steps: []any{"auth", "refresh_token"}, want: true},
+		{path: "$..*token*", steps: []any{"auth", "tokens", 0}, want: false},
+	}
+
+	for _, tt := range tests {
+		path, err := ParseJSONPath(tt.path)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, path.Match(tt.steps), "%s %v", tt.path, tt.steps)
+	}
+}
+
+func TestLoggerPluginConfig_Validate_RedactionRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  LoggerPluginConfig
+		wantErr string
+	}{
+		{
+			name: "valid rules",
+			config: LoggerPluginConfig{
+				RedactionRules: []RedactionRule{
+					{Path: "$..email", Action: RedactionActionMask},
+					{Path: "$.user_id", Action: RedactionActionHMAC},
+				},
+				RedactionHMACKey: "0123456789abcdef0123456789abcdef",
+			},
+		},
+		{
+			name:    "invalid path",
+			config:  LoggerPluginConfig{RedactionRules: []RedactionRule{{Path: "$..email", Action: RedactionActionDrop}, {Path: "email", Action: RedactionActionDrop}}},
+			wantErr: "redaction rule 1",
+		},
+		{
+			name:    "unknown action",
+			config:  LoggerPluginConfig{RedactionRules: []RedactionRule{{Path: "$.email", Action: "hash"}}},
+			wantErr: "unknown action",
+		},
+		{
+			name:    "hmac rule without a key",
+			config:  LoggerPluginConfig{RedactionRules: []RedactionRule{{Path: "$.user_id", Action: RedactionActionHMAC}}},
+			wantErr: "hmac key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PartitionConfig configures the monthly partitions of log_entries on Postgres
+type PartitionConfig struct {
+	// PremakeMonths is how many future monthly partitions are kept ready, defaults to 3
+	PremakeMonths int `json:"premake_months" toml:"premake_months"`
+	// MaintenanceInterval is how often future partitions are created and old ones archived,
+	// defaults to an hour
+	MaintenanceInterval time.Duration `json:"maintenance_interval" toml:"maintenance_interval"`
+	// ArchiveDir is the local directory old partitions are archived to as gzip compressed NDJSON
+	// before they are detached and dropped. Archival is disabled when empty. Archived entries are
+	// out of reach of the erasure of deleted users.
+	ArchiveDir string `json:"archive_dir" toml:"archive_dir"`
+	// ArchiveAfterMonths is how many whole months a partition is kept after its month ended,
+	// defaults to 12
+	ArchiveAfterMonths int `json:"archive_after_months" toml:"archive_after_months"`
+}
+
+// DefaultPartitionPremakeMonths is the number of future partitions kept ready by default
+const DefaultPartitionPremakeMonths = 3
+
+func (c *PartitionConfig) validate() error {
+	if c.PremakeMonths < 0 || c.ArchiveAfterMonths < 0 {
+		return fmt.Errorf("partition months must not be negative")
+	}
+	if c.PremakeMonths == 0 {
+		c.PremakeMonths = DefaultPartitionPremakeMonths
+	}
+	if c.MaintenanceInterval <= 0 {
+		c.MaintenanceInterval = time.Hour
+	}
+	if c.ArchiveAfterMonths == 0 {
+		c.ArchiveAfterMonths = 12
+	}
+	return nil
+}
+
+// HasArchival reports whether old partitions are archived
+func (c *PartitionConfig) HasArchival() bool {
+	return c.ArchiveDir != ""
+}
+
+const (
+	// LogPartitionPrefix starts the name of every monthly partition, followed by the year and month
+	LogPartitionPrefix = "log_entries_p"
+	// LogDefaultPartition holds the entries no monthly partition covers
+	LogDefaultPartition = "log_entries_default"
+	// LogArchiveExtension ends the file name of every partition archive
+	LogArchiveExtension = ".ndjson.gz"
+	// LogRestoredPartitionComment is the table comment that marks a restored partition
+	LogRestoredPartitionComment = "restored"
+
+	logPartitionMonthLayout = "200601"
+)
+
+// LogPartition is a monthly partition of log_entries, from From up to but excluding To
+type LogPartition struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Restored marks a partition that was restored from its archive, it is not archived again
+	Restored bool `json:"restored"`
+}
+
+// NewLogPartition returns the partition of the month the given time falls into
+func NewLogPartition(at time.Time) LogPartition {
+	at = at.UTC()
+	from := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return LogPartition{
+		Name: LogPartitionPrefix + from.Format(logPartitionMonthLayout),
+		From: from,
+		To:   from.AddDate(0, 1, 0),
+	}
+}
+
+// ParseLogPartition returns the monthly partition with the given table name
+func ParseLogPartition(name string) (partition LogPartition, ok bool) {
+	month, found := strings.CutPrefix(name, LogPartitionPrefix)
+	if !found {
+		return LogPartition{}, false
+	}
+	from, err := time.Parse(logPartitionMonthLayout, month)
+	if err != nil {
+		return LogPartition{}, false
+	}
+	return NewLogPartition(from), true
+}
+
+// ArchiveFileName is the name of the archive of the partition
+func (p LogPartition) ArchiveFileName() string {
+	return p.Name + LogArchiveExtension
+}
+
+// Contains reports whether an entry created at the given time belongs to the partition
+func (p LogPartition) Contains(at time.Time) bool {
+	return !at.Before(p.From) && at.Before(p.To)
+}
+
+// ArchivedLogEntry is a single line of a partition archive
+type ArchivedLogEntry struct {
+	LogEntry
+	DedupEventID *string `json:"dedup_event_id,omitempty"`
+}
+
+// NewArchivedLogEntry wraps a stored entry for its archive
+func NewArchivedLogEntry(entry *LogEntry) *ArchivedLogEntry {
+	return &ArchivedLogEntry{LogEntry: *entry, DedupEventID: entry.DedupEventID}
+}
+
+// Entry returns the archived entry as it is stored again
+func (a *ArchivedLogEntry) Entry() LogEntry {
+	entry := a.LogEntry
+	entry.DedupEventID = a.DedupEventID
+	return entry
+}
+
+// ArchivedPartition reports a partition that was archived and dropped
+type ArchivedPartition struct {
+	Partition LogPartition `json:"partition"`
+	// File is the path of the archive, empty when the partition had no entries
+	File    string `json:"file"`
+	Entries int64  `json:"entries"`
+}
+
+// PartitionMaintenance reports a single round of partition maintenance
+type PartitionMaintenance struct {
+	Created  []LogPartition      `json:"created"`
+	Archived []ArchivedPartition `json:"archived"`
+}
+
+// PartitionRestore reports an archive that was attached as a partition again
+type PartitionRestore struct {
+	Partition LogPartition `json:"partition"`
+	Entries   int64        `json:"entries"`
+	// Erased counts the archived entries anonymized or left out because they were erased
+	Erased int64 `json:"erased"`
+}
@@ -0,0 +1,15 @@
+package types
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ProcessedEvent remembers the id of a stored event so that redeliveries are dropped after its entry was pruned
+type ProcessedEvent struct {
+	bun.BaseModel `bun:"table:log_processed_events"`
+
+	EventID     string    `json:"event_id" bun:"column:event_id,pk"`
+	ProcessedAt time.Time `json:"processed_at" bun:"column:processed_at"`
+}
@@ -0,0 +1,49 @@
+package types
+
+import "fmt"
+
+type RedactionAction string
+
+const (
+	// RedactionActionDrop removes the field
+	RedactionActionDrop RedactionAction = "drop"
+	// RedactionActionMask keeps a hint of the value, e.g. "j***@example.com" or "203.0.113.***"
+	RedactionActionMask RedactionAction = "mask"
+	// RedactionActionHMAC replaces the value with its keyed HMAC-SHA256 so that it can still be correlated
+	RedactionActionHMAC RedactionAction = "hmac"
+)
+
+// MinRedactionHMACKeyLength is the minimum length of the key of the hmac redaction action
+const MinRedactionHMACKeyLength = 32
+
+// RedactionRule rewrites the fields of an event payload or its metadata selected by a JSON path
+type RedactionRule struct {
+	// Path is a JSON path such as "$.user.email" or "$..password", see JSONPath
+	Path   string          `json:"path" toml:"path"`
+	Action RedactionAction `json:"action" toml:"action"`
+}
+
+// Parse validates the rule for the hmac key and returns its path
+func (r RedactionRule) Parse(hmacKey string) (JSONPath, error) {
+	path, err := ParseJSONPath(r.Path)
+	if err != nil {
+		return JSONPath{}, err
+	}
+	switch r.Action {
+	case RedactionActionDrop, RedactionActionMask:
+	case RedactionActionHMAC:
+		if len(hmacKey) < MinRedactionHMACKeyLength {
+			return JSONPath{}, fmt.Errorf("the hmac action needs a redaction hmac key of at least %d bytes", MinRedactionHMACKeyLength)
+		}
+	default:
+		return JSONPath{}, fmt.Errorf("unknown action %q", r.Action)
+	}
+	return path, nil
+}
+
+// DefaultRedactionRules drop token, secret and password like fields at any depth
+var DefaultRedactionRules = []RedactionRule{
+	{Path: "$..*token*", Action: RedactionActionDrop},
+	{Path: "$..*secret*", Action: RedactionActionDrop},
+	{Path: "$..*password*", Action: RedactionActionDrop},
+}
@@ -0,0 +1,146 @@
+package types
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSearchLength is the maximum length of a search in bytes
+	MaxSearchLength = 256
+	// MaxSearchTerms is the maximum number of terms of a search
+	MaxSearchTerms = 8
+	// SearchHighlightStart and SearchHighlightEnd wrap the matched terms in the highlighted snippets
+	SearchHighlightStart = "<mark>"
+	SearchHighlightEnd   = "</mark>"
+	// searchSnippetContext is the number of characters kept before and after the first match
+	searchSnippetContext = 40
+)
+
+// ParseSearchTerms splits a search into whitespace separated terms and double quoted phrases
+func ParseSearchTerms(search string) ([]string, error) {
+	if len(search) > MaxSearchLength {
+		return nil, fmt.Errorf("search must not be longer than %d characters", MaxSearchLength)
+	}
+
+	var terms []string
+	for i, part := range strings.Split(search, `"`) {
+		// Every odd part was inside double quotes
+		if i%2 == 1 {
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search has no terms")
+	}
+	if len(terms) > MaxSearchTerms {
+		return nil, fmt.Errorf("search must not have more than %d terms", MaxSearchTerms)
+	}
+	return terms, nil
+}
+
+// HighlightLogEntry returns an HTML escaped snippet of every searchable field that contains a term
+func HighlightLogEntry(entry *LogEntry, terms []string) map[string]string {
+	details := string(entry.Details)
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"event_type", &entry.EventType},
+		{"user_id", entry.UserID},
+		{"ip_address", entry.IPAddress},
+		{"user_agent", entry.UserAgent},
+		{"details", &details},
+	}
+
+	highlights := make(map[string]string)
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		if snippet, ok := highlightSnippet(*field.value, terms); ok {
+			highlights[field.name] = snippet
+		}
+	}
+	return highlights
+}
+
+// highlightSnippet cuts the text around its first match and marks every match within the cut
+func highlightSnippet(text string, terms []string) (string, bool) {
+	matches := findSearchMatches(text, terms)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start := max(matches[0][0]-searchSnippetContext, 0)
+	end := min(matches[0][1]+searchSnippetContext, len(text))
+	// Keep the cut on rune boundaries
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, match := range matches {
+		if match[0] < pos || match[1] > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:match[0]]))
+		b.WriteString(SearchHighlightStart)
+		b.WriteString(html.EscapeString(text[match[0]:match[1]]))
+		b.WriteString(SearchHighlightEnd)
+		pos = match[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// findSearchMatches returns the byte ranges of the non-overlapping matches of the terms in text
+func findSearchMatches(text string, terms []string) [][2]int {
+	lower := strings.ToLower(text)
+	// Lowercasing may change the byte length of some runes, the ranges would no longer fit the text
+	if len(lower) != len(text) {
+		return nil
+	}
+
+	var matches [][2]int
+	for pos := 0; pos < len(lower); {
+		best := [2]int{-1, -1}
+		for _, term := range terms {
+			term = strings.ToLower(term)
+			if term == "" {
+				continue
+			}
+			i := strings.Index(lower[pos:], term)
+			if i < 0 {
+				continue
+			}
+			match := [2]int{pos + i, pos + i + len(term)}
+			if best[0] < 0 || match[0] < best[0] || (match[0] == best[0] && match[1] > best[1]) {
+				best = match
+			}
+		}
+		if best[0] < 0 {
+			break
+		}
+		matches = append(matches, best)
+		pos = best[1]
+	}
+	return matches
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type SinkType string
+
+const (
+	// SinkTypeFile appends the entries as JSON lines to a file that is rotated by size
+	SinkTypeFile SinkType = "file"
+	// SinkTypeStdout writes the entries through the logger of the application
+	SinkTypeStdout SinkType = "stdout"
+	// SinkTypeSyslog sends the entries as JSON messages to a syslog daemon
+	SinkTypeSyslog SinkType = "syslog"
+)
+
+// SinkConfig configures a sink receiving the log entries next to the database
+type SinkConfig struct {
+	// Name identifies the sink in the logs, defaults to its type
+	Name string   `json:"name" toml:"name"`
+	Type SinkType `json:"type" toml:"type"`
+	// IncludeEventTypes are the exact event types or glob patterns sent to the sink, empty sends every
+	// event. They apply independently of the event filters of the database.
+	IncludeEventTypes []string `json:"include_event_types" toml:"include_event_types"`
+	// ExcludeEventTypes are the exact event types or glob patterns never sent to the sink
+	ExcludeEventTypes []string `json:"exclude_event_types" toml:"exclude_event_types"`
+	// BufferSize is how many batches are queued for the sink before new ones are dropped, defaults to 64
+	BufferSize int              `json:"buffer_size" toml:"buffer_size"`
+	File       FileSinkConfig   `json:"file" toml:"file"`
+	Syslog     SyslogSinkConfig `json:"syslog" toml:"syslog"`
+}
+
+type FileSinkConfig struct {
+	Path string `json:"path" toml:"path"`
+	// MaxSizeMB is the size at which the file is rotated, defaults to 100
+	MaxSizeMB int `json:"max_size_mb" toml:"max_size_mb"`
+	// MaxBackups is how many rotated files are kept next to the current one, defaults to 5
+	MaxBackups int `json:"max_backups" toml:"max_backups"`
+}
+
+type SyslogSinkConfig struct {
+	// Network and Address select a remote daemon such as "udp" and "localhost:514", both empty
+	// use the local syslog socket
+	Network string `json:"network" toml:"network"`
+	Address string `json:"address" toml:"address"`
+	// Tag is the program name of the messages, defaults to "authula"
+	Tag string `json:"tag" toml:"tag"`
+	// Facility is a syslog facility name such as "auth" or "local0", defaults to "local0"
+	Facility string `json:"facility" toml:"facility"`
+}
+
+// SyslogFacilities are the facility names accepted by SyslogSinkConfig.Facility
+var SyslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+func (c *SinkConfig) validate(index int) error {
+	switch c.Type {
+	case SinkTypeFile:
+		if strings.TrimSpace(c.File.Path) == "" {
+			return fmt.Errorf("sink %d needs a file path", index)
+		}
+		if c.File.MaxSizeMB < 0 || c.File.MaxBackups < 0 {
+			return fmt.Errorf("sink %d file size and backups must not be negative", index)
+		}
+		if c.File.MaxSizeMB == 0 {
+			c.File.MaxSizeMB = 100
+		}
+		if c.File.MaxBackups == 0 {
+			c.File.MaxBackups = 5
+		}
+	case SinkTypeStdout:
+	case SinkTypeSyslog:
+		if (c.Syslog.Network == "") != (c.Syslog.Address == "") {
+			return fmt.Errorf("sink %d needs both a syslog network and address, or neither", index)
+		}
+		if c.Syslog.Tag == "" {
+			c.Syslog.Tag = "authula"
+		}
+		if c.Syslog.Facility == "" {
+			c.Syslog.Facility = "local0"
+		}
+		if !slices.Contains(SyslogFacilities, c.Syslog.Facility) {
+			return fmt.Errorf("sink %d has an unknown syslog facility %q", index, c.Syslog.Facility)
+		}
+	default:
+		return fmt.Errorf("sink %d has an unknown type %q", index, c.Type)
+	}
+
+	if c.Name == "" {
+		c.Name = string(c.Type)
+	}
+	for _, eventType := range append(append([]string{}, c.IncludeEventTypes...), c.ExcludeEventTypes...) {
+		if strings.TrimSpace(eventType) == "" {
+			return fmt.Errorf("sink %d event type filters must not be empty", index)
+		}
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = 64
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type StatsBucket string
+
+const (
+	StatsBucketHour StatsBucket = "hour"
+	StatsBucketDay  StatsBucket = "day"
+	// StatsBucketWeek buckets start on Monday like ISO weeks
+	StatsBucketWeek StatsBucket = "week"
+)
+
+const (
+	// DefaultStatsRange is the range counted when the request does not give a start
+	DefaultStatsRange = 30 * 24 * time.Hour
+	// MaxStatsBuckets caps the number of buckets a single request may span
+	MaxStatsBuckets = 2000
+)
+
+// Duration returns the length of a bucket
+func (b StatsBucket) Duration() time.Duration {
+	switch b {
+	case StatsBucketHour:
+		return time.Hour
+	case StatsBucketWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Start returns the start of the bucket containing t in UTC
+func (b StatsBucket) Start(t time.Time) time.Time {
+	t = t.UTC()
+	switch b {
+	case StatsBucketHour:
+		return t.Truncate(time.Hour)
+	case StatsBucketWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		// Weekday counts from Sunday, weeks start on Monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// ParseStatsBucket validates a bucket name, an empty name is a day
+func ParseStatsBucket(value string) (StatsBucket, error) {
+	switch bucket := StatsBucket(value); bucket {
+	case "":
+		return StatsBucketDay, nil
+	case StatsBucketHour, StatsBucketDay, StatsBucketWeek:
+		return bucket, nil
+	default:
+		return "", fmt.Errorf("invalid bucket, expected %q, %q or %q", StatsBucketHour, StatsBucketDay, StatsBucketWeek)
+	}
+}
+
+// StatsQuery counts the entries of the given event types created in [From, To)
+type StatsQuery struct {
+	// EventTypes are exact event types or glob patterns, empty counts every event type
+	EventTypes []string
+	From       time.Time
+	To         time.Time
+	Bucket     StatsBucket
+}
+
+// StatsCount is the number of entries of an event type in the bucket starting at BucketStart
+type StatsCount struct {
+	BucketStart time.Time `json:"bucket_start"`
+	EventType   string    `json:"event_type"`
+	Count       int64     `json:"count"`
+}
+
+type LogStats struct {
+	Bucket StatsBucket `json:"bucket"`
+	From   time.Time   `json:"from"`
+	To     time.Time   `json:"to"`
+	// Source is "rollup" when the counts come from the rollup table and "entries" otherwise
+	Source string `json:"source"`
+	// Counts are ordered by bucket and event type, buckets without entries are left out
+	Counts []StatsCount `json:"counts"`
+	// Totals are the counts of the whole range by event type
+	Totals map[string]int64 `json:"totals"`
+}
+
+// StatsRollup is the number of entries of an event type created during an hour, pruned ones included
+type StatsRollup struct {
+	bun.BaseModel `bun:"table:log_stats_hourly"`
+
+	BucketStart time.Time `bun:"column:bucket_start,pk"`
+	EventType   string    `bun:"column:event_type,pk"`
+	EventCount  int64     `bun:"column:event_count"`
+}
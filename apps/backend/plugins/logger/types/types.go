@@ -1,15 +1,145 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/uptrace/bun"
+
+	"github.com/Authula/authula-playground/adminauth"
+)
+
+type RetentionMode string
+
+const (
+	// RetentionModeStop stops ingesting new logs once MaxLogCount is reached and publishes a warning event
+	RetentionModeStop RetentionMode = "stop"
+	// RetentionModePrune keeps the newest MaxLogCount logs and deletes the oldest ones like a ring buffer,
+	// writes prune once the count is a tenth above MaxLogCount and the background pruner prunes the rest
+	RetentionModePrune RetentionMode = "prune"
 )
 
 type LoggerPluginConfig struct {
 	Enabled bool `json:"enabled" toml:"enabled"`
-	// MaxLogCount is the maximum number of logs to keep before stopping
+	// MaxLogCount is the maximum number of logs to keep
 	MaxLogCount int `json:"max_log_count" toml:"max_log_count"`
+	// RetentionMode decides what happens once MaxLogCount is reached, defaults to RetentionModeStop
+	RetentionMode RetentionMode `json:"retention_mode" toml:"retention_mode"`
+	// PruneBatchSize is the maximum number of rows removed by a single delete statement when pruning
+	PruneBatchSize int `json:"prune_batch_size" toml:"prune_batch_size"`
+	// RetentionDays is how many days logs are kept, 0 keeps them until MaxLogCount applies
+	RetentionDays int `json:"retention_days" toml:"retention_days"`
+	// RetentionRules override RetentionDays for matching event types, the first matching rule wins
+	RetentionRules []RetentionRule `json:"retention_rules" toml:"retention_rules"`
+	// RetentionPruneInterval is how often the background pruner deletes expired logs and, in prune mode, the logs above MaxLogCount
+	RetentionPruneInterval time.Duration `json:"retention_prune_interval" toml:"retention_prune_interval"`
+	// ProcessedEventTTL is how long the ids of stored events are remembered to drop redeliveries, independently of the retention
+	ProcessedEventTTL time.Duration `json:"processed_event_ttl" toml:"processed_event_ttl"`
+	// LogCountReconcileInterval is how often the log count shared through the secondary storage is recounted from the database
+	LogCountReconcileInterval time.Duration `json:"log_count_reconcile_interval" toml:"log_count_reconcile_interval"`
+	// WriteBufferSize is how many events are queued before publishers block until the writer catches up
+	WriteBufferSize int `json:"write_buffer_size" toml:"write_buffer_size"`
+	// WriteBatchSize is the maximum number of log entries stored by a single insert statement
+	WriteBatchSize int `json:"write_batch_size" toml:"write_batch_size"`
+	// WriteFlushInterval is how long queued events wait for a batch to fill up before they are stored
+	WriteFlushInterval time.Duration `json:"write_flush_interval" toml:"write_flush_interval"`
+	// WriteMaxRetries is how many times a batch that failed to store is retried before its entries
+	// are stored one by one and the ones that still fail are dead-lettered
+	WriteMaxRetries int `json:"write_max_retries" toml:"write_max_retries"`
+	// WriteRetryBackoff is the delay before the first retry, it doubles for every further retry
+	WriteRetryBackoff time.Duration `json:"write_retry_backoff" toml:"write_retry_backoff"`
+	// WriteRetryMaxBackoff caps the delay between retries
+	WriteRetryMaxBackoff time.Duration `json:"write_retry_max_backoff" toml:"write_retry_max_backoff"`
+	// WriteRetryMaxTime caps the time a batch is retried for, the writer blocks on it meanwhile
+	WriteRetryMaxTime time.Duration `json:"write_retry_max_time" toml:"write_retry_max_time"`
+	// AckMode decides when events are acknowledged to the event bus, defaults to AckModeQueued
+	AckMode AckMode `json:"ack_mode" toml:"ack_mode"`
+	// ChainCheckpointKey signs periodic checkpoints of the hash chain, checkpoints are disabled when empty
+	ChainCheckpointKey string `json:"chain_checkpoint_key" toml:"chain_checkpoint_key"`
+	// ChainCheckpointInterval is how often a signed checkpoint of the hash chain is made
+	ChainCheckpointInterval time.Duration `json:"chain_checkpoint_interval" toml:"chain_checkpoint_interval"`
+	// IncludeEventTypes are the exact event types or glob patterns that are logged, empty logs every event
+	IncludeEventTypes []string `json:"include_event_types" toml:"include_event_types"`
+	// ExcludeEventTypes are the exact event types or glob patterns that are never logged, they win over IncludeEventTypes
+	ExcludeEventTypes []string `json:"exclude_event_types" toml:"exclude_event_types"`
+	// SamplingRules log only a share of the matching events, the first matching rule wins
+	SamplingRules []SamplingRule `json:"sampling_rules" toml:"sampling_rules"`
+	// RedactionRules rewrite sensitive fields of the event payload and metadata before they are
+	// stored. For every field the first matching rule wins, DefaultRedactionRules come last.
+	RedactionRules []RedactionRule `json:"redaction_rules" toml:"redaction_rules"`
+	// DisableDefaultRedaction turns off DefaultRedactionRules
+	DisableDefaultRedaction bool `json:"disable_default_redaction" toml:"disable_default_redaction"`
+	// RedactionHMACKey keys the hmac redaction action
+	RedactionHMACKey string `json:"redaction_hmac_key" toml:"redaction_hmac_key"`
+	// AdminUserIDs are the users allowed to use the admin routes of the plugin
+	AdminUserIDs []string `json:"admin_user_ids" toml:"admin_user_ids"`
+	// AdminEmails are the email addresses of the users allowed to use the admin routes, compared case-insensitively
+	AdminEmails []string `json:"admin_emails" toml:"admin_emails"`
+	// AdminRoleClaim is the session value, set by a hook of the app, holding the roles of the user, defaults to "role"
+	AdminRoleClaim string `json:"admin_role_claim" toml:"admin_role_claim"`
+	// AdminRoles are the roles allowed to use the admin routes
+	AdminRoles []string `json:"admin_roles" toml:"admin_roles"`
+	// StreamClientBufferSize is how many entries are queued for a live tail client before it is dropped as too slow
+	StreamClientBufferSize int `json:"stream_client_buffer_size" toml:"stream_client_buffer_size"`
+	// StreamHeartbeatInterval is how often a comment is sent to idle live tail clients to keep the connection open
+	StreamHeartbeatInterval time.Duration `json:"stream_heartbeat_interval" toml:"stream_heartbeat_interval"`
+	// StreamMaxResumeEntries is the maximum number of stored entries replayed to a client resuming with Last-Event-ID
+	StreamMaxResumeEntries int `json:"stream_max_resume_entries" toml:"stream_max_resume_entries"`
+	// StatsFromRollup answers the stats route from the hourly rollup table instead of counting the
+	// entries. The rollup is cheaper to read and keeps counting entries removed by retention.
+	StatsFromRollup bool `json:"stats_from_rollup" toml:"stats_from_rollup"`
+	// Detection raises security alerts for brute-force and credential-stuffing sign-in attempts
+	Detection DetectionConfig `json:"detection" toml:"detection"`
+	// Sinks receive the log entries in addition to the database, each with its own event filters
+	Sinks []SinkConfig `json:"sinks" toml:"sinks"`
+	// ErasureMode decides how the entries of an erased user are removed, defaults to ErasureModeAnonymize
+	ErasureMode ErasureMode `json:"erasure_mode" toml:"erasure_mode"`
+	// DisableErasureOnUserDeleted keeps the entries of users deleted through DELETE /admin/users/{user_id} of the
+	// admin plugin, the only deletion erased automatically, the erase route of the logger covers any other one
+	DisableErasureOnUserDeleted bool `json:"disable_erasure_on_user_deleted" toml:"disable_erasure_on_user_deleted"`
+	// Partitions configures the monthly partitions and their archival on Postgres
+	Partitions PartitionConfig `json:"partitions" toml:"partitions"`
+}
+
+// RetentionRule keeps the logs of the matching event types for a given number of days
+type RetentionRule struct {
+	// EventTypes are exact event types or glob patterns such as "session.*"
+	EventTypes []string `json:"event_types" toml:"event_types"`
+	Days       int      `json:"days" toml:"days"`
+}
+
+// MinChainCheckpointKeyLength is the minimum length of the key that signs chain checkpoints
+const MinChainCheckpointKeyLength = 32
+
+// HasChainCheckpoints reports whether signed checkpoints of the hash chain are made
+func (c *LoggerPluginConfig) HasChainCheckpoints() bool {
+	return c.ChainCheckpointKey != ""
+}
+
+// EffectiveRedactionRules returns the configured redaction rules followed by the defaults
+func (c *LoggerPluginConfig) EffectiveRedactionRules() []RedactionRule {
+	rules := append([]RedactionRule{}, c.RedactionRules...)
+	if !c.DisableDefaultRedaction {
+		rules = append(rules, DefaultRedactionRules...)
+	}
+	return rules
+}
+
+// HasTimeRetention reports whether logs expire after a number of days
+func (c *LoggerPluginConfig) HasTimeRetention() bool {
+	return c.RetentionDays > 0 || len(c.RetentionRules) > 0
+}
+
+// Admins returns the users allowed to use the admin routes
+func (c *LoggerPluginConfig) Admins() adminauth.Config {
+	return adminauth.Config{
+		UserIDs:   c.AdminUserIDs,
+		Emails:    c.AdminEmails,
+		RoleClaim: c.AdminRoleClaim,
+		Roles:     c.AdminRoles,
+	}
 }
 
 // Validate validates the configuration
@@ -17,14 +147,222 @@ func (c *LoggerPluginConfig) Validate() error {
 	if c.MaxLogCount <= 0 {
 		c.MaxLogCount = 1000
 	}
+	switch c.RetentionMode {
+	case "":
+		c.RetentionMode = RetentionModeStop
+	case RetentionModeStop, RetentionModePrune:
+	default:
+		return fmt.Errorf("unknown retention mode %q", c.RetentionMode)
+	}
+	if c.PruneBatchSize <= 0 {
+		c.PruneBatchSize = 500
+	}
+	if c.RetentionDays < 0 {
+		return fmt.Errorf("retention days must not be negative")
+	}
+	for i, rule := range c.RetentionRules {
+		if len(rule.EventTypes) == 0 {
+			return fmt.Errorf("retention rule %d has no event types", i)
+		}
+		if rule.Days <= 0 {
+			return fmt.Errorf("retention rule %d must keep logs for at least one day", i)
+		}
+	}
+	if c.RetentionPruneInterval <= 0 {
+		c.RetentionPruneInterval = time.Hour
+	}
+	if c.ProcessedEventTTL <= 0 {
+		c.ProcessedEventTTL = 7 * 24 * time.Hour
+	}
+	if c.LogCountReconcileInterval <= 0 {
+		c.LogCountReconcileInterval = 5 * time.Minute
+	}
+	if c.WriteBufferSize <= 0 {
+		c.WriteBufferSize = 1000
+	}
+	if c.WriteBatchSize <= 0 {
+		c.WriteBatchSize = 100
+	}
+	if c.WriteBatchSize > c.WriteBufferSize {
+		return fmt.Errorf("write batch size must not exceed the write buffer size")
+	}
+	if c.WriteFlushInterval <= 0 {
+		c.WriteFlushInterval = time.Second
+	}
+	if c.WriteMaxRetries <= 0 {
+		c.WriteMaxRetries = 3
+	}
+	if c.WriteRetryBackoff <= 0 {
+		c.WriteRetryBackoff = 100 * time.Millisecond
+	}
+	if c.WriteRetryMaxBackoff <= 0 {
+		c.WriteRetryMaxBackoff = 5 * time.Second
+	}
+	if c.WriteRetryMaxBackoff < c.WriteRetryBackoff {
+		return fmt.Errorf("write retry max backoff must not be shorter than the write retry backoff")
+	}
+	if c.WriteRetryMaxTime <= 0 {
+		c.WriteRetryMaxTime = 10 * time.Second
+	}
+	switch c.AckMode {
+	case "":
+		c.AckMode = AckModeQueued
+	case AckModeQueued, AckModeStored:
+	default:
+		return fmt.Errorf("unknown ack mode %q", c.AckMode)
+	}
+	if c.ChainCheckpointKey != "" && len(c.ChainCheckpointKey) < MinChainCheckpointKeyLength {
+		return fmt.Errorf("chain checkpoint key must be at least %d bytes long", MinChainCheckpointKeyLength)
+	}
+	if c.ChainCheckpointInterval <= 0 {
+		c.ChainCheckpointInterval = time.Hour
+	}
+	for _, eventType := range append(append([]string{}, c.IncludeEventTypes...), c.ExcludeEventTypes...) {
+		if strings.TrimSpace(eventType) == "" {
+			return fmt.Errorf("event type filters must not be empty")
+		}
+	}
+	for i, rule := range c.SamplingRules {
+		if len(rule.EventTypes) == 0 {
+			return fmt.Errorf("sampling rule %d has no event types", i)
+		}
+		if rule.Rate < 0 || rule.Rate > 1 {
+			return fmt.Errorf("sampling rule %d rate must be between 0 and 1", i)
+		}
+	}
+	for i, rule := range c.RedactionRules {
+		if _, err := rule.Parse(c.RedactionHMACKey); err != nil {
+			return fmt.Errorf("redaction rule %d: %w", i, err)
+		}
+	}
+	for _, admin := range append(append(append([]string{}, c.AdminUserIDs...), c.AdminEmails...), c.AdminRoles...) {
+		if strings.TrimSpace(admin) == "" {
+			return fmt.Errorf("admin user ids, emails and roles must not be empty")
+		}
+	}
+	if c.AdminRoleClaim == "" {
+		c.AdminRoleClaim = "role"
+	}
+	if c.StreamClientBufferSize <= 0 {
+		c.StreamClientBufferSize = 256
+	}
+	if c.StreamHeartbeatInterval <= 0 {
+		c.StreamHeartbeatInterval = 15 * time.Second
+	}
+	if c.StreamMaxResumeEntries <= 0 {
+		c.StreamMaxResumeEntries = 1000
+	}
+	if err := c.Detection.validate(); err != nil {
+		return err
+	}
+	if err := c.Partitions.validate(); err != nil {
+		return err
+	}
+	switch c.ErasureMode {
+	case "":
+		c.ErasureMode = ErasureModeAnonymize
+	case ErasureModeAnonymize, ErasureModeDelete:
+	default:
+		return fmt.Errorf("unknown erasure mode %q", c.ErasureMode)
+	}
+	sinkNames := make(map[string]bool, len(c.Sinks))
+	for i := range c.Sinks {
+		if err := c.Sinks[i].validate(i); err != nil {
+			return err
+		}
+		if sinkNames[c.Sinks[i].Name] {
+			return fmt.Errorf("sink name %q is used more than once", c.Sinks[i].Name)
+		}
+		sinkNames[c.Sinks[i].Name] = true
+	}
 	return nil
 }
 
 type LogEntry struct {
 	bun.BaseModel `bun:"table:log_entries"`
 
-	ID        int64     `json:"id" bun:"column:id,pk,autoincrement"`
-	EventType string    `json:"event_type" bun:"column:event_type"`
-	Details   string    `json:"details" bun:"column:details"`
-	CreatedAt time.Time `json:"created_at" bun:"column:created_at,default:current_timestamp"`
+	ID        int64           `json:"id" bun:"column:id,pk,autoincrement"`
+	EventID   *string         `json:"event_id" bun:"column:event_id"`
+	EventType string          `json:"event_type" bun:"column:event_type"`
+	UserID    *string         `json:"user_id" bun:"column:user_id"`
+	SessionID *string         `json:"session_id" bun:"column:session_id"`
+	IPAddress *string         `json:"ip_address" bun:"column:ip_address"`
+	UserAgent *string         `json:"user_agent" bun:"column:user_agent"`
+	Details   json.RawMessage `json:"details" bun:"column:details"`
+	CreatedAt time.Time       `json:"created_at" bun:"column:created_at,default:current_timestamp"`
+	// RequestID is the ID of the HTTP request that published the event
+	RequestID *string `json:"request_id" bun:"column:request_id"`
+	// ContentHash, PrevHash and Hash link the entry into the tamper-evident hash chain
+	ContentHash *string `json:"content_hash" bun:"column:content_hash"`
+	PrevHash    *string `json:"prev_hash" bun:"column:prev_hash"`
+	Hash        *string `json:"hash" bun:"column:hash"`
+	// DedupEventID repeats EventID under an index so that redelivered events are stored once.
+	// It is not hashed, entries duplicated before the index existed keep it empty and stay sealed.
+	DedupEventID *string `json:"-" bun:"column:dedup_event_id"`
+}
+
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"
+	SortOrderDesc SortOrder = "desc"
+)
+
+const (
+	DefaultLogEntriesLimit = 50
+	MaxLogEntriesLimit     = 500
+)
+
+// LogEntryFilter narrows down which log entries a query matches
+type LogEntryFilter struct {
+	// EventTypes matches entries whose event type equals any of the given values.
+	// A value may be a glob pattern where "*" matches any run of characters and
+	// "?" matches a single character, e.g. "user.*".
+	EventTypes []string
+	// ExcludeEventTypes drops entries whose event type matches any of the given values or glob patterns
+	ExcludeEventTypes []string
+	UserID            *string
+	SessionID         *string
+	IPAddress         *string
+	RequestID         *string
+	// From matches entries created at or after the given time
+	From *time.Time
+	// To matches entries created before the given time
+	To *time.Time
+	// AfterID matches entries with a greater ID, the live tail resumes from it
+	AfterID *int64
+}
+
+// IsEmpty reports whether the filter matches every entry
+func (f LogEntryFilter) IsEmpty() bool {
+	return len(f.EventTypes) == 0 && len(f.ExcludeEventTypes) == 0 && f.UserID == nil && f.SessionID == nil &&
+		f.IPAddress == nil && f.RequestID == nil && f.From == nil && f.To == nil && f.AfterID == nil
+}
+
+// LogEntryQuery describes a single page of log entries
+type LogEntryQuery struct {
+	Filter LogEntryFilter
+	// Cursor is the opaque NextCursor value of the previous page
+	Cursor *string
+	Limit  int
+	Order  SortOrder
+}
+
+type LogEntriesPage struct {
+	Entries    []LogEntry `json:"entries"`
+	NextCursor *string    `json:"next_cursor,omitempty"`
+	// Highlights holds the highlighted fields of every entry of a search, keyed by the entry ID
+	Highlights map[int64]map[string]string `json:"highlights,omitempty"`
+}
+
+// RetentionRuleResult reports how many expired logs a single retention rule deleted
+type RetentionRuleResult struct {
+	EventTypes []string `json:"event_types"`
+	Days       int      `json:"days"`
+	Deleted    int64    `json:"deleted"`
+}
+
+type RetentionPruneResult struct {
+	Rules        []RetentionRuleResult `json:"rules"`
+	TotalDeleted int64                 `json:"total_deleted"`
 }
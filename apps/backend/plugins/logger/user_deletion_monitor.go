@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/logger/constants"
+)
+
+// adminUsersPathSegment identifies the admin user routes under any base path
+const adminUsersPathSegment = "/admin/users/"
+
+// monitorUserDeletions publishes a user deleted event for the users deleted through the admin plugin route only
+func (p *LoggerPlugin) monitorUserDeletions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		index := strings.LastIndex(path, adminUsersPathSegment)
+		if index < 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID := path[index+len(adminUsersPathSegment):]
+		if userID == "" || strings.Contains(userID, "/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+
+		reqCtx, ok := models.GetRequestContext(r.Context())
+		if !ok || reqCtx.ResponseStatus != http.StatusOK {
+			return
+		}
+
+		request := map[string]string{"user_id": userID}
+		if reqCtx.UserID != nil {
+			request["deleted_by"] = *reqCtx.UserID
+		}
+		payload, err := json.Marshal(request)
+		if err != nil {
+			return
+		}
+		if err := p.ctx.EventBus.Publish(r.Context(), models.Event{
+			Type:      constants.EventUserDeleted,
+			Timestamp: time.Now().UTC(),
+			Payload:   payload,
+		}); err != nil {
+			p.logger.Error("failed to publish user deleted event", "error", err)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package constants
+
+import (
+	"errors"
+
+	"github.com/Authula/authula-playground/pagination"
+)
+
+var (
+	ErrInvalidCursor    = pagination.ErrInvalidCursor
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrEndpointDisabled = errors.New("webhook endpoint is disabled")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrLeaseExpired     = errors.New("webhook delivery lease expired")
+)
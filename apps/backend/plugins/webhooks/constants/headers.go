@@ -0,0 +1,14 @@
+package constants
+
+const (
+	// HeaderSignature carries "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot
+	// and the request body, keyed with the secret of the endpoint
+	HeaderSignature = "X-Webhook-Signature"
+	// HeaderTimestamp is the unix time in seconds the attempt was signed at. Receivers should
+	// reject old timestamps so that a captured request cannot be replayed.
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderDeliveryID identifies the delivery, it stays the same across the retries of a delivery
+	HeaderDeliveryID = "X-Webhook-Delivery"
+	// HeaderEventType is the type of the delivered event
+	HeaderEventType = "X-Webhook-Event"
+)
@@ -0,0 +1,160 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/services"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+type ListDeliveriesHandler struct {
+	service services.WebhooksService
+	logger  models.Logger
+}
+
+func (h *ListDeliveriesHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		endpointID, ok := parseIDParam(reqCtx, r, "invalid endpoint id")
+		if !ok {
+			return
+		}
+
+		query, err := parseDeliveryQuery(r.URL.Query())
+		if err != nil {
+			reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+				"message": err.Error(),
+			})
+			reqCtx.Handled = true
+			return
+		}
+		query.EndpointID = endpointID
+
+		page, err := h.service.ListDeliveries(ctx, query)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrInvalidCursor):
+				reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+					"message": err.Error(),
+				})
+			case errors.Is(err, constants.ErrEndpointNotFound):
+				reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+					"message": err.Error(),
+				})
+			default:
+				h.logger.Error("failed to list webhook deliveries", "endpoint_id", endpointID, "error", err)
+				reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+					"message": "failed to list webhook deliveries",
+				})
+			}
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, page)
+	}
+}
+
+type GetDeliveryHandler struct {
+	service services.WebhooksService
+	logger  models.Logger
+}
+
+func (h *GetDeliveryHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, ok := parseIDParam(reqCtx, r, "invalid delivery id")
+		if !ok {
+			return
+		}
+
+		delivery, err := h.service.GetDelivery(ctx, id)
+		if err != nil {
+			if errors.Is(err, constants.ErrDeliveryNotFound) {
+				reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+					"message": err.Error(),
+				})
+				reqCtx.Handled = true
+				return
+			}
+			h.logger.Error("failed to get webhook delivery", "delivery_id", id, "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to get webhook delivery",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, delivery)
+	}
+}
+
+type RedeliverHandler struct {
+	service    services.WebhooksService
+	dispatcher *Dispatcher
+	logger     models.Logger
+}
+
+func (h *RedeliverHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, ok := parseIDParam(reqCtx, r, "invalid delivery id")
+		if !ok {
+			return
+		}
+
+		delivery, err := h.service.Redeliver(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrDeliveryNotFound), errors.Is(err, constants.ErrEndpointNotFound):
+				reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+					"message": err.Error(),
+				})
+			case errors.Is(err, constants.ErrEndpointDisabled):
+				reqCtx.SetJSONResponse(http.StatusConflict, map[string]any{
+					"message": err.Error(),
+				})
+			default:
+				h.logger.Error("failed to redeliver webhook delivery", "delivery_id", id, "error", err)
+				reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+					"message": "failed to redeliver webhook delivery",
+				})
+			}
+			reqCtx.Handled = true
+			return
+		}
+
+		h.dispatcher.Notify()
+		reqCtx.SetJSONResponse(http.StatusAccepted, delivery)
+	}
+}
+
+// parseDeliveryQuery reads the status, cursor and limit query parameters
+func parseDeliveryQuery(values url.Values) (types.DeliveryQuery, error) {
+	var query types.DeliveryQuery
+
+	switch status := types.DeliveryStatus(strings.ToLower(strings.TrimSpace(values.Get("status")))); status {
+	case "", types.DeliveryStatusPending, types.DeliveryStatusSucceeded, types.DeliveryStatusFailed:
+		query.Status = status
+	default:
+		return query, fmt.Errorf("invalid status, expected %q, %q or %q", types.DeliveryStatusPending, types.DeliveryStatusSucceeded, types.DeliveryStatusFailed)
+	}
+
+	var err error
+	query.Cursor, query.Limit, err = pagination.ParseQuery(values, types.DefaultDeliveriesLimit)
+	return query, err
+}
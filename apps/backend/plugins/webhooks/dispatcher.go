@@ -0,0 +1,91 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/webhooks/services"
+)
+
+// Dispatcher sends the due deliveries every interval or when it is notified about new ones
+type Dispatcher struct {
+	logger   models.Logger
+	service  services.WebhooksService
+	interval time.Duration
+	notify   chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewDispatcher(logger models.Logger, service services.WebhooksService, interval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		logger:   logger,
+		service:  service,
+		interval: interval,
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs a first dispatch right away and then one every interval until Close is called
+func (d *Dispatcher) Start() {
+	go d.runDispatchLoop()
+}
+
+// Notify wakes the dispatcher up without waiting for the next interval, it never blocks
+func (d *Dispatcher) Notify() {
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the dispatch loop and waits for the attempts in flight to be recorded
+func (d *Dispatcher) Close() {
+	close(d.stop)
+	<-d.done
+}
+
+func (d *Dispatcher) runDispatchLoop() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	defer close(d.done)
+
+	d.dispatch()
+
+	for {
+		select {
+		case <-d.stop:
+			d.logger.Debug("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.dispatch()
+		case <-d.notify:
+			d.dispatch()
+		}
+	}
+}
+
+// dispatch sends due deliveries until a round finds none
+func (d *Dispatcher) dispatch() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		// Not cancelled on Close, an aborted request would use up an attempt of the delivery
+		sent, err := d.service.DispatchDue(context.Background())
+		if err != nil {
+			d.logger.Error("failed to dispatch webhook deliveries", "error", err)
+			return
+		}
+		if sent == 0 {
+			return
+		}
+	}
+}
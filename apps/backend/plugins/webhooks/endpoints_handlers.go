@@ -0,0 +1,197 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/services"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// maxRequestBodySize caps the JSON bodies accepted by the admin routes
+const maxRequestBodySize = 64 * 1024
+
+type ListEndpointsHandler struct {
+	service services.WebhooksService
+	logger  models.Logger
+}
+
+func (h *ListEndpointsHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		endpoints, err := h.service.ListEndpoints(ctx)
+		if err != nil {
+			h.logger.Error("failed to list webhook endpoints", "error", err)
+			reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+				"message": "failed to list webhook endpoints",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, map[string]any{
+			"endpoints": endpoints,
+		})
+	}
+}
+
+type CreateEndpointHandler struct {
+	service services.WebhooksService
+	logger  models.Logger
+}
+
+func (h *CreateEndpointHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		var request types.CreateEndpointRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			reqCtx.SetJSONResponse(http.StatusUnprocessableEntity, map[string]any{
+				"message": "invalid request body",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		endpoint, err := h.service.CreateEndpoint(ctx, request)
+		if err != nil {
+			writeEndpointError(reqCtx, h.logger, "failed to create webhook endpoint", err)
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusCreated, endpoint)
+	}
+}
+
+type GetEndpointHandler struct {
+	service services.WebhooksService
+	logger  models.Logger
+}
+
+func (h *GetEndpointHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, ok := parseIDParam(reqCtx, r, "invalid endpoint id")
+		if !ok {
+			return
+		}
+
+		endpoint, err := h.service.GetEndpoint(ctx, id)
+		if err != nil {
+			writeEndpointError(reqCtx, h.logger, "failed to get webhook endpoint", err)
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, endpoint)
+	}
+}
+
+type UpdateEndpointHandler struct {
+	service services.WebhooksService
+	logger  models.Logger
+}
+
+func (h *UpdateEndpointHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, ok := parseIDParam(reqCtx, r, "invalid endpoint id")
+		if !ok {
+			return
+		}
+
+		var request types.UpdateEndpointRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			reqCtx.SetJSONResponse(http.StatusUnprocessableEntity, map[string]any{
+				"message": "invalid request body",
+			})
+			reqCtx.Handled = true
+			return
+		}
+
+		endpoint, err := h.service.UpdateEndpoint(ctx, id, request)
+		if err != nil {
+			writeEndpointError(reqCtx, h.logger, "failed to update webhook endpoint", err)
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, endpoint)
+	}
+}
+
+type DeleteEndpointHandler struct {
+	service services.WebhooksService
+	logger  models.Logger
+}
+
+func (h *DeleteEndpointHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reqCtx, _ := models.GetRequestContext(ctx)
+
+		id, ok := parseIDParam(reqCtx, r, "invalid endpoint id")
+		if !ok {
+			return
+		}
+
+		if err := h.service.DeleteEndpoint(ctx, id); err != nil {
+			writeEndpointError(reqCtx, h.logger, "failed to delete webhook endpoint", err)
+			return
+		}
+
+		reqCtx.SetJSONResponse(http.StatusOK, map[string]any{
+			"message": "webhook endpoint deleted",
+		})
+	}
+}
+
+// writeEndpointError answers invalid requests with 422, unknown endpoints with 404 and logs the rest
+func writeEndpointError(reqCtx *models.RequestContext, logger models.Logger, message string, err error) {
+	switch {
+	case errors.Is(err, constants.ErrInvalidEndpoint):
+		reqCtx.SetJSONResponse(http.StatusUnprocessableEntity, map[string]any{
+			"message": err.Error(),
+		})
+	case errors.Is(err, constants.ErrEndpointNotFound):
+		reqCtx.SetJSONResponse(http.StatusNotFound, map[string]any{
+			"message": err.Error(),
+		})
+	default:
+		logger.Error(message, "error", err)
+		reqCtx.SetJSONResponse(http.StatusInternalServerError, map[string]any{
+			"message": message,
+		})
+	}
+	reqCtx.Handled = true
+}
+
+// parseIDParam reads the id path parameter and answers with 400 when it is not a positive integer
+func parseIDParam(reqCtx *models.RequestContext, r *http.Request, message string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		reqCtx.SetJSONResponse(http.StatusBadRequest, map[string]any{
+			"message": message,
+		})
+		reqCtx.Handled = true
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeJSONBody decodes a size-limited JSON body and rejects unknown fields
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dest any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(dest)
+}
@@ -0,0 +1,29 @@
+package webhooks
+
+import (
+	"github.com/Authula/authula/models"
+)
+
+type WebhooksHookID string
+
+const (
+	// HookIDWebhooksAdmin restricts a route to the admins of the webhooks plugin. Every route of the
+	// plugin carries it, the route must also run the session auth hook.
+	HookIDWebhooksAdmin WebhooksHookID = "webhooks.admin"
+)
+
+func (id WebhooksHookID) String() string {
+	return string(id)
+}
+
+func (p *WebhooksPlugin) Hooks() []models.Hook {
+	return []models.Hook{
+		{
+			Stage:    models.HookBefore,
+			PluginID: HookIDWebhooksAdmin.String(),
+			Handler:  p.adminChecker.RequireAdmin,
+			// Runs after the session auth hook has resolved the user
+			Order: 20,
+		},
+	}
+}
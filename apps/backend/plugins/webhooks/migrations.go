@@ -0,0 +1,367 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/uptrace/bun"
+
+	"github.com/Authula/authula/migrations"
+
+	loggertypes "github.com/Authula/authula-playground/plugins/logger/types"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// deliveryBackfillBatchSize is the number of deliveries read at once by the user_id backfill
+const deliveryBackfillBatchSize = 500
+
+func webhooksMigrations(provider string) []migrations.Migration {
+	return migrations.ForProvider(provider, migrations.ProviderVariants{
+		"sqlite": func() []migrations.Migration {
+			return []migrations.Migration{
+				webhooksSQLiteInitial(),
+				webhooksSQLiteDeliveryUserID(),
+			}
+		},
+		"postgres": func() []migrations.Migration {
+			return []migrations.Migration{
+				webhooksPostgresInitial(),
+				webhooksPostgresDeliveryUserID(),
+			}
+		},
+		"mysql": func() []migrations.Migration {
+			return []migrations.Migration{
+				webhooksMySQLInitial(),
+				webhooksMySQLDeliveryUserID(),
+			}
+		},
+	})
+}
+
+func webhooksSQLiteInitial() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017090040_webhooks_initial",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS webhook_endpoints (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  url VARCHAR(2048) NOT NULL,
+  description TEXT,
+  event_types TEXT NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+				`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  endpoint_id INTEGER NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(255) NOT NULL,
+  payload TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP,
+  last_attempt_at TIMESTAMP,
+  last_status_code INTEGER,
+  last_error TEXT,
+  redelivery_of INTEGER,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  completed_at TIMESTAMP
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries(status, next_attempt_at);`,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_id ON webhook_deliveries(endpoint_id, id);`,
+				`CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  delivery_id INTEGER NOT NULL,
+  attempt INTEGER NOT NULL,
+  status_code INTEGER,
+  error TEXT,
+  response_body TEXT,
+  duration_ms INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_webhook_delivery_attempts_delivery_id;`,
+				`DROP TABLE IF EXISTS webhook_delivery_attempts;`,
+				`DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint_id;`,
+				`DROP INDEX IF EXISTS idx_webhook_deliveries_status_next_attempt_at;`,
+				`DROP TABLE IF EXISTS webhook_deliveries;`,
+				`DROP TABLE IF EXISTS webhook_endpoints;`,
+			)
+		},
+	}
+}
+
+func webhooksPostgresInitial() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017090040_webhooks_initial",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS webhook_endpoints (
+  id BIGSERIAL PRIMARY KEY,
+  url VARCHAR(2048) NOT NULL,
+  description TEXT,
+  event_types JSONB NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);`,
+				`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  endpoint_id BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+  event_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(255) NOT NULL,
+  payload TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP,
+  last_attempt_at TIMESTAMP,
+  last_status_code INTEGER,
+  last_error TEXT,
+  redelivery_of BIGINT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  completed_at TIMESTAMP
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries(status, next_attempt_at);`,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_id ON webhook_deliveries(endpoint_id, id);`,
+				`CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+  id BIGSERIAL PRIMARY KEY,
+  delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+  attempt INTEGER NOT NULL,
+  status_code INTEGER,
+  error TEXT,
+  response_body TEXT,
+  duration_ms BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);`,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_webhook_delivery_attempts_delivery_id;`,
+				`DROP TABLE IF EXISTS webhook_delivery_attempts;`,
+				`DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint_id;`,
+				`DROP INDEX IF EXISTS idx_webhook_deliveries_status_next_attempt_at;`,
+				`DROP TABLE IF EXISTS webhook_deliveries;`,
+				`DROP TABLE IF EXISTS webhook_endpoints;`,
+			)
+		},
+	}
+}
+
+func webhooksMySQLInitial() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017090040_webhooks_initial",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE TABLE IF NOT EXISTS webhook_endpoints (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  url VARCHAR(2048) NOT NULL,
+  description TEXT NULL,
+  event_types JSON NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+				`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  endpoint_id BIGINT NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(255) NOT NULL,
+  payload MEDIUMTEXT NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP(6) NULL,
+  last_attempt_at TIMESTAMP(6) NULL,
+  last_status_code INT NULL,
+  last_error TEXT NULL,
+  redelivery_of BIGINT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  completed_at TIMESTAMP(6) NULL,
+  INDEX idx_webhook_deliveries_status_next_attempt_at (status, next_attempt_at),
+  INDEX idx_webhook_deliveries_endpoint_id (endpoint_id, id),
+  CONSTRAINT fk_webhook_deliveries_endpoint_id FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+				`CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  delivery_id BIGINT NOT NULL,
+  attempt INT NOT NULL,
+  status_code INT NULL,
+  error TEXT NULL,
+  response_body TEXT NULL,
+  duration_ms BIGINT NOT NULL,
+  created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  INDEX idx_webhook_delivery_attempts_delivery_id (delivery_id),
+  CONSTRAINT fk_webhook_delivery_attempts_delivery_id FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP TABLE IF EXISTS webhook_delivery_attempts;`,
+				`DROP TABLE IF EXISTS webhook_deliveries;`,
+				`DROP TABLE IF EXISTS webhook_endpoints;`,
+			)
+		},
+	}
+}
+
+func webhooksSQLiteDeliveryUserID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017111158_webhooks_delivery_user_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE webhook_deliveries ADD COLUMN user_id VARCHAR(255);`,
+			); err != nil {
+				return err
+			}
+			if err := backfillDeliveryUserID(ctx, tx); err != nil {
+				return err
+			}
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_user_id ON webhook_deliveries(user_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_webhook_deliveries_user_id;`,
+				`ALTER TABLE webhook_deliveries DROP COLUMN user_id;`,
+			)
+		},
+	}
+}
+
+func webhooksPostgresDeliveryUserID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017111158_webhooks_delivery_user_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS user_id VARCHAR(255);`,
+			); err != nil {
+				return err
+			}
+			if err := backfillDeliveryUserID(ctx, tx); err != nil {
+				return err
+			}
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_user_id ON webhook_deliveries(user_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`DROP INDEX IF EXISTS idx_webhook_deliveries_user_id;`,
+				`ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS user_id;`,
+			)
+		},
+	}
+}
+
+func webhooksMySQLDeliveryUserID() migrations.Migration {
+	return migrations.Migration{
+		Version: "20261017111158_webhooks_delivery_user_id",
+		Up: func(ctx context.Context, tx bun.Tx) error {
+			if err := migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE webhook_deliveries ADD COLUMN user_id VARCHAR(255) NULL;`,
+			); err != nil {
+				return err
+			}
+			if err := backfillDeliveryUserID(ctx, tx); err != nil {
+				return err
+			}
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`CREATE INDEX idx_webhook_deliveries_user_id ON webhook_deliveries(user_id);`,
+			)
+		},
+		Down: func(ctx context.Context, tx bun.Tx) error {
+			return migrations.ExecStatements(
+				ctx,
+				tx,
+				`ALTER TABLE webhook_deliveries
+  DROP INDEX idx_webhook_deliveries_user_id,
+  DROP COLUMN user_id;`,
+			)
+		},
+	}
+}
+
+// backfillDeliveryUserID fills user_id from the payloads of the existing deliveries
+func backfillDeliveryUserID(ctx context.Context, tx bun.Tx) error {
+	var lastID int64
+	for {
+		var rows []struct {
+			ID      int64  `bun:"id"`
+			Payload string `bun:"payload"`
+		}
+		if err := tx.NewSelect().
+			Table("webhook_deliveries").
+			Column("id", "payload").
+			Where("id > ?", lastID).
+			OrderExpr("id ASC").
+			Limit(deliveryBackfillBatchSize).
+			Scan(ctx, &rows); err != nil {
+			return fmt.Errorf("failed to read webhook deliveries for backfill: %w", err)
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			var payload types.EventPayload
+			if err := json.Unmarshal([]byte(row.Payload), &payload); err != nil {
+				continue
+			}
+			var entry loggertypes.LogEntry
+			entry.ApplyEventFields(payload.Payload, payload.Metadata)
+			if entry.UserID == nil {
+				continue
+			}
+
+			if _, err := tx.NewUpdate().
+				Table("webhook_deliveries").
+				Set("user_id = ?", *entry.UserID).
+				Where("id = ?", row.ID).
+				Exec(ctx); err != nil {
+				return fmt.Errorf("failed to backfill webhook delivery %d: %w", row.ID, err)
+			}
+		}
+
+		if len(rows) < deliveryBackfillBatchSize {
+			return nil
+		}
+	}
+}
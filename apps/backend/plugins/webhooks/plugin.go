@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Authula/authula/migrations"
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/adminauth"
+	loggerconstants "github.com/Authula/authula-playground/plugins/logger/constants"
+	loggerservices "github.com/Authula/authula-playground/plugins/logger/services"
+	"github.com/Authula/authula-playground/plugins/webhooks/repositories"
+	"github.com/Authula/authula-playground/plugins/webhooks/services"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+type WebhooksPlugin struct {
+	config          types.WebhooksPluginConfig
+	logger          models.Logger
+	ctx             *models.PluginContext
+	adminChecker    *adminauth.Checker
+	webhooksService services.WebhooksService
+	dispatcher      *Dispatcher
+	subscription    *models.SubscriptionID
+}
+
+func New(config types.WebhooksPluginConfig) *WebhooksPlugin {
+	return &WebhooksPlugin{config: config}
+}
+
+func (p *WebhooksPlugin) Metadata() models.PluginMetadata {
+	return models.PluginMetadata{
+		ID:          "webhooks",
+		Version:     "1.0.0",
+		Description: "Delivers signed events to the subscribed webhook endpoints",
+	}
+}
+
+func (p *WebhooksPlugin) Config() any {
+	return p.config
+}
+
+func (p *WebhooksPlugin) Init(ctx *models.PluginContext) error {
+	p.ctx = ctx
+	p.logger = ctx.Logger
+
+	if err := p.config.Validate(); err != nil {
+		return fmt.Errorf("invalid webhooks plugin configuration: %w", err)
+	}
+
+	p.adminChecker = adminauth.NewChecker(p.config.Admins(), ctx.ServiceRegistry, p.logger)
+	if p.config.Admins().IsEmpty() {
+		p.logger.Warn("no webhooks admins are configured, the admin routes will reject every request")
+	}
+
+	redactor, err := p.redactor()
+	if err != nil {
+		return fmt.Errorf("failed to create the webhooks redactor: %w", err)
+	}
+
+	repo := repositories.NewBunWebhooksRepository(ctx.DB)
+	p.webhooksService = services.NewService(repo, services.NewSender(p.config.RequestTimeout, p.config.AllowPrivateNetworks), redactor, p.logger, p.config)
+	if err := p.registerUserDataEraser(); err != nil {
+		return fmt.Errorf("failed to register the webhooks user data eraser: %w", err)
+	}
+
+	p.dispatcher = NewDispatcher(p.logger, p.webhooksService, p.config.DispatchInterval)
+	p.dispatcher.Start()
+	p.subscribeToEvents()
+
+	return nil
+}
+
+func (p *WebhooksPlugin) Routes() []models.Route {
+	if p.ctx == nil || p.webhooksService == nil {
+		return nil
+	}
+
+	return Routes(p.ctx.Logger, p.webhooksService, p.dispatcher)
+}
+
+func (p *WebhooksPlugin) Close() error {
+	if p.subscription != nil {
+		p.ctx.EventBus.Unsubscribe(models.EventTypeWildcard, *p.subscription)
+	}
+	if p.dispatcher != nil {
+		p.dispatcher.Close()
+	}
+	return nil
+}
+
+func (p *WebhooksPlugin) Migrations(provider string) []migrations.Migration {
+	return webhooksMigrations(provider)
+}
+
+func (p *WebhooksPlugin) DependsOn() []string {
+	return []string{loggerconstants.PluginID}
+}
+
+// subscribeToEvents queues a delivery of every event for the endpoints subscribed to its type
+func (p *WebhooksPlugin) subscribeToEvents() {
+	handler := func(ctx context.Context, event models.Event) error {
+		deliveries, err := p.webhooksService.EnqueueEvent(ctx, event)
+		if err != nil {
+			p.logger.Error("failed to queue webhook deliveries", "event_id", event.ID, "event", event.Type, "error", err)
+			// The event bus redelivers the event, no delivery was queued for it
+			return err
+		}
+		if len(deliveries) > 0 {
+			p.dispatcher.Notify()
+		}
+		return nil
+	}
+
+	id, err := p.ctx.EventBus.Subscribe(models.EventTypeWildcard, handler)
+	if err != nil {
+		p.logger.Error("failed to subscribe to events", "event", models.EventTypeWildcard, "error", err)
+		return
+	}
+	p.subscription = &id
+}
+
+// redactor returns the redactor of the logger plugin, the webhooks plugin depends on it
+func (p *WebhooksPlugin) redactor() (services.PayloadRedactor, error) {
+	redactor, ok := p.ctx.ServiceRegistry.Get(loggerconstants.ServiceRedactor).(*loggerservices.Redactor)
+	if !ok {
+		return nil, fmt.Errorf("the %s plugin must be registered before the webhooks plugin", loggerconstants.PluginID)
+	}
+	return redactor, nil
+}
+
+// registerUserDataEraser deletes the deliveries of a user when the logger plugin erases the user
+func (p *WebhooksPlugin) registerUserDataEraser() error {
+	erasers, ok := p.ctx.ServiceRegistry.Get(loggerconstants.ServiceUserDataErasers).(*loggerservices.UserDataErasers)
+	if !ok {
+		return fmt.Errorf("the %s plugin must be registered before the webhooks plugin", loggerconstants.PluginID)
+	}
+	erasers.Register(p.webhooksService)
+	return nil
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// WebhooksRepository defines the interface for webhook endpoint and delivery persistence
+type WebhooksRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *types.Endpoint) error
+	GetEndpoint(ctx context.Context, id int64) (*types.Endpoint, error)
+	ListEndpoints(ctx context.Context) ([]types.Endpoint, error)
+	ListEnabledEndpoints(ctx context.Context) ([]types.Endpoint, error)
+	UpdateEndpoint(ctx context.Context, endpoint *types.Endpoint) error
+	DeleteEndpoint(ctx context.Context, id int64) error
+	CreateDeliveries(ctx context.Context, deliveries []*types.Delivery) error
+	GetDelivery(ctx context.Context, id int64) (*types.Delivery, error)
+	ListDeliveries(ctx context.Context, query types.DeliveryQuery) ([]types.Delivery, *string, error)
+	ListDeliveryAttempts(ctx context.Context, deliveryID int64) ([]types.DeliveryAttempt, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]types.Delivery, error)
+	RecordAttempt(ctx context.Context, delivery *types.Delivery, attempt *types.DeliveryAttempt, leaseUntil time.Time) error
+	DeleteUserDeliveries(ctx context.Context, userID string) (int64, error)
+}
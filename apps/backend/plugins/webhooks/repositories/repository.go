@@ -0,0 +1,274 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/Authula/authula-playground/pagination"
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// BunWebhooksRepository implements WebhooksRepository
+type BunWebhooksRepository struct {
+	db bun.IDB
+}
+
+// NewBunWebhooksRepository creates a new bun-based repository
+func NewBunWebhooksRepository(db bun.IDB) *BunWebhooksRepository {
+	return &BunWebhooksRepository{db: db}
+}
+
+// CreateEndpoint stores a new endpoint and fills in its ID
+func (r *BunWebhooksRepository) CreateEndpoint(ctx context.Context, endpoint *types.Endpoint) error {
+	if _, err := r.db.NewInsert().Model(endpoint).Returning("id").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// GetEndpoint retrieves an endpoint by ID
+func (r *BunWebhooksRepository) GetEndpoint(ctx context.Context, id int64) (*types.Endpoint, error) {
+	endpoint := new(types.Endpoint)
+	if err := r.db.NewSelect().Model(endpoint).Where("id = ?", id).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, constants.ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// ListEndpoints retrieves every endpoint, oldest first
+func (r *BunWebhooksRepository) ListEndpoints(ctx context.Context) ([]types.Endpoint, error) {
+	endpoints := []types.Endpoint{}
+	if err := r.db.NewSelect().Model(&endpoints).OrderExpr("id ASC").Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// ListEnabledEndpoints retrieves the endpoints that receive deliveries
+func (r *BunWebhooksRepository) ListEnabledEndpoints(ctx context.Context) ([]types.Endpoint, error) {
+	var endpoints []types.Endpoint
+	if err := r.db.NewSelect().Model(&endpoints).Where("enabled = ?", true).OrderExpr("id ASC").Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list enabled webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// UpdateEndpoint saves every field of an existing endpoint
+func (r *BunWebhooksRepository) UpdateEndpoint(ctx context.Context, endpoint *types.Endpoint) error {
+	if _, err := r.db.NewUpdate().Model(endpoint).WherePK().ExcludeColumn("id", "created_at").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// DeleteEndpoint deletes an endpoint together with its deliveries and their attempts
+func (r *BunWebhooksRepository) DeleteEndpoint(ctx context.Context, id int64) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewDelete().Model((*types.Endpoint)(nil)).Where("id = ?", id).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return constants.ErrEndpointNotFound
+		}
+
+		deliveries := tx.NewSelect().Model((*types.Delivery)(nil)).Column("id").Where("endpoint_id = ?", id)
+		if _, err := tx.NewDelete().
+			Model((*types.DeliveryAttempt)(nil)).
+			Where("delivery_id IN (?)", deliveries).
+			Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete webhook delivery attempts: %w", err)
+		}
+		if _, err := tx.NewDelete().
+			Model((*types.Delivery)(nil)).
+			Where("endpoint_id = ?", id).
+			Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateDeliveries queues the deliveries with a single insert statement and fills in their IDs
+func (r *BunWebhooksRepository) CreateDeliveries(ctx context.Context, deliveries []*types.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if _, err := r.db.NewInsert().Model(&deliveries).Returning("id").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *BunWebhooksRepository) GetDelivery(ctx context.Context, id int64) (*types.Delivery, error) {
+	delivery := new(types.Delivery)
+	if err := r.db.NewSelect().Model(delivery).Where("id = ?", id).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, constants.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries retrieves a single page of the deliveries of an endpoint, newest first
+func (r *BunWebhooksRepository) ListDeliveries(ctx context.Context, query types.DeliveryQuery) ([]types.Delivery, *string, error) {
+	selectQuery := r.db.NewSelect().
+		Model((*types.Delivery)(nil)).
+		Where("endpoint_id = ?", query.EndpointID).
+		OrderExpr("id DESC").
+		Limit(query.Limit + 1)
+
+	if query.Status != "" {
+		selectQuery = selectQuery.Where("status = ?", query.Status)
+	}
+
+	if query.Cursor != nil && strings.TrimSpace(*query.Cursor) != "" {
+		id, err := pagination.DecodeIDCursor(strings.TrimSpace(*query.Cursor))
+		if err != nil {
+			return nil, nil, err
+		}
+		selectQuery = selectQuery.Where("id < ?", id)
+	}
+
+	var deliveries []types.Delivery
+	if err := selectQuery.Scan(ctx, &deliveries); err != nil {
+		return nil, nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	if deliveries == nil {
+		deliveries = []types.Delivery{}
+	}
+
+	if len(deliveries) <= query.Limit {
+		return deliveries, nil, nil
+	}
+
+	next := pagination.EncodeIDCursor(deliveries[query.Limit-1].ID)
+	return deliveries[:query.Limit], &next, nil
+}
+
+// ListDeliveryAttempts retrieves every attempt of a delivery, oldest first
+func (r *BunWebhooksRepository) ListDeliveryAttempts(ctx context.Context, deliveryID int64) ([]types.DeliveryAttempt, error) {
+	attempts := []types.DeliveryAttempt{}
+	if err := r.db.NewSelect().
+		Model(&attempts).
+		Where("delivery_id = ?", deliveryID).
+		OrderExpr("id ASC").
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list webhook delivery attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// ClaimDueDeliveries leases up to limit due pending deliveries of enabled endpoints until leaseUntil
+func (r *BunWebhooksRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]types.Delivery, error) {
+	now = now.UTC()
+
+	var dueIDs []int64
+	if err := r.db.NewSelect().
+		TableExpr("webhook_deliveries AS d").
+		Join("JOIN webhook_endpoints AS e ON e.id = d.endpoint_id").
+		Column("d.id").
+		Where("d.status = ?", types.DeliveryStatusPending).
+		Where("d.next_attempt_at <= ?", now).
+		Where("e.enabled = ?", true).
+		OrderExpr("d.next_attempt_at ASC").
+		Limit(limit).
+		Scan(ctx, &dueIDs); err != nil {
+		return nil, fmt.Errorf("failed to find due webhook deliveries: %w", err)
+	}
+
+	claimedIDs := make([]int64, 0, len(dueIDs))
+	for _, id := range dueIDs {
+		// Only the replica whose update still finds the delivery due claims it
+		result, err := r.db.NewUpdate().
+			Model((*types.Delivery)(nil)).
+			Set("next_attempt_at = ?", leaseUntil.UTC()).
+			Where("id = ?", id).
+			Where("status = ?", types.DeliveryStatusPending).
+			Where("next_attempt_at <= ?", now).
+			Exec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 1 {
+			claimedIDs = append(claimedIDs, id)
+		}
+	}
+
+	deliveries := []types.Delivery{}
+	if len(claimedIDs) == 0 {
+		return deliveries, nil
+	}
+	if err := r.db.NewSelect().
+		Model(&deliveries).
+		Where("id IN (?)", bun.In(claimedIDs)).
+		OrderExpr("id ASC").
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load claimed webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt stores the attempt and the new state of its delivery in a single transaction, as long as
+// the delivery is still leased until leaseUntil
+func (r *BunWebhooksRepository) RecordAttempt(ctx context.Context, delivery *types.Delivery, attempt *types.DeliveryAttempt, leaseUntil time.Time) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model(delivery).
+			Column("status", "attempts", "next_attempt_at", "last_attempt_at", "last_status_code", "last_error", "completed_at").
+			WherePK().
+			Where("status = ?", types.DeliveryStatusPending).
+			Where("next_attempt_at = ?", leaseUntil.UTC()).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		// Another replica claimed the delivery once the lease ran out, its outcome wins
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return constants.ErrLeaseExpired
+		}
+		_, err = tx.NewInsert().Model(attempt).Returning("id").Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserDeliveries deletes the deliveries of the user together with their attempts
+func (r *BunWebhooksRepository) DeleteUserDeliveries(ctx context.Context, userID string) (int64, error) {
+	var deleted int64
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		deliveries := tx.NewSelect().Model((*types.Delivery)(nil)).Column("id").Where("user_id = ?", userID)
+		if _, err := tx.NewDelete().
+			Model((*types.DeliveryAttempt)(nil)).
+			Where("delivery_id IN (?)", deliveries).
+			Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete webhook delivery attempts: %w", err)
+		}
+		result, err := tx.NewDelete().
+			Model((*types.Delivery)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		deleted, _ = result.RowsAffected()
+		return nil
+	})
+	return deleted, err
+}
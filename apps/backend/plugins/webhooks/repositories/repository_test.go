@@ -0,0 +1,153 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+
+	"github.com/Authula/authula/migrations"
+
+	"github.com/Authula/authula-playground/plugins/webhooks"
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/repositories"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// newRepository creates a repository on an in-memory SQLite database with the migrations of the plugin applied
+func newRepository(t *testing.T) *repositories.BunWebhooksRepository {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Every connection would open its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	db := bun.NewDB(sqlDB, sqlitedialect.New())
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := migrations.NewMigrator(db, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(context.Background(), []migrations.MigrationSet{{
+		PluginID:   "webhooks",
+		Migrations: webhooks.New(types.WebhooksPluginConfig{}).Migrations("sqlite"),
+	}}))
+	return repositories.NewBunWebhooksRepository(db)
+}
+
+func TestBunWebhooksRepository_ClaimDueDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepository(t)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	enabled := &types.Endpoint{URL: "https://example.com/a", EventTypes: []string{"*"}, Secret: "secret", Enabled: true, CreatedAt: now, UpdatedAt: now}
+	disabled := &types.Endpoint{URL: "https://example.com/b", EventTypes: []string{"*"}, Secret: "secret", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.CreateEndpoint(ctx, enabled))
+	require.NoError(t, repo.CreateEndpoint(ctx, disabled))
+
+	newDelivery := func(endpointID int64, eventID string, status types.DeliveryStatus, nextAttemptAt time.Time) *types.Delivery {
+		return &types.Delivery{
+			EndpointID:    endpointID,
+			EventID:       eventID,
+			EventType:     "user.created",
+			Payload:       []byte(`{}`),
+			Status:        status,
+			NextAttemptAt: &nextAttemptAt,
+			CreatedAt:     now,
+		}
+	}
+	due := newDelivery(enabled.ID, "due", types.DeliveryStatusPending, now.Add(-time.Minute))
+	deliveries := []*types.Delivery{
+		due,
+		newDelivery(enabled.ID, "later", types.DeliveryStatusPending, now.Add(time.Minute)),
+		newDelivery(enabled.ID, "succeeded", types.DeliveryStatusSucceeded, now.Add(-time.Minute)),
+		newDelivery(disabled.ID, "disabled endpoint", types.DeliveryStatusPending, now.Add(-time.Minute)),
+	}
+	require.NoError(t, repo.CreateDeliveries(ctx, deliveries))
+
+	leaseUntil := now.Add(20 * time.Second)
+	claimed, err := repo.ClaimDueDeliveries(ctx, now, leaseUntil, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, due.ID, claimed[0].ID)
+	require.NotNil(t, claimed[0].NextAttemptAt)
+	assert.True(t, leaseUntil.Equal(*claimed[0].NextAttemptAt), "the claimed delivery is leased")
+
+	claimed, err = repo.ClaimDueDeliveries(ctx, now, leaseUntil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "a leased delivery is not claimed twice")
+
+	claimed, err = repo.ClaimDueDeliveries(ctx, leaseUntil, leaseUntil.Add(20*time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "the delivery is claimed again once the lease ran out")
+	assert.Equal(t, due.ID, claimed[0].ID)
+}
+
+func TestBunWebhooksRepository_RecordAttempt(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepository(t)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	endpoint := &types.Endpoint{URL: "https://example.com/a", EventTypes: []string{"*"}, Secret: "secret", Enabled: true, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.CreateEndpoint(ctx, endpoint))
+	require.NoError(t, repo.CreateDeliveries(ctx, []*types.Delivery{{
+		EndpointID:    endpoint.ID,
+		EventID:       "e1",
+		EventType:     "user.created",
+		Payload:       []byte(`{}`),
+		Status:        types.DeliveryStatusPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}}))
+
+	firstLease := now.Add(20 * time.Second)
+	claimed, err := repo.ClaimDueDeliveries(ctx, now, firstLease, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	stale := claimed[0]
+	// The lease runs out before the first replica records its attempt and another replica claims the delivery
+	secondLease := firstLease.Add(20 * time.Second)
+	claimed, err = repo.ClaimDueDeliveries(ctx, firstLease, secondLease, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	current := claimed[0]
+
+	statusCode := 200
+	record := func(delivery types.Delivery, leaseUntil time.Time) error {
+		completedAt := time.Now().UTC()
+		delivery.Status = types.DeliveryStatusSucceeded
+		delivery.Attempts = 1
+		delivery.NextAttemptAt = nil
+		delivery.CompletedAt = &completedAt
+		return repo.RecordAttempt(ctx, &delivery, &types.DeliveryAttempt{
+			DeliveryID: delivery.ID,
+			Attempt:    1,
+			StatusCode: &statusCode,
+			CreatedAt:  completedAt,
+		}, leaseUntil)
+	}
+
+	assert.ErrorIs(t, record(stale, firstLease), constants.ErrLeaseExpired)
+	attempts, err := repo.ListDeliveryAttempts(ctx, stale.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts, "the attempt of an expired lease is not stored")
+	delivery, err := repo.GetDelivery(ctx, stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, types.DeliveryStatusPending, delivery.Status)
+
+	require.NoError(t, record(current, secondLease))
+	attempts, err = repo.ListDeliveryAttempts(ctx, current.ID)
+	require.NoError(t, err)
+	assert.Len(t, attempts, 1)
+	delivery, err = repo.GetDelivery(ctx, current.ID)
+	require.NoError(t, err)
+	assert.Equal(t, types.DeliveryStatusSucceeded, delivery.Status)
+
+	assert.ErrorIs(t, record(current, secondLease), constants.ErrLeaseExpired, "a completed delivery is not recorded again")
+}
@@ -0,0 +1,98 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/adminauth"
+	"github.com/Authula/authula-playground/plugins/webhooks/services"
+)
+
+// Routes creates and returns the plugin routes
+func Routes(logger models.Logger, service services.WebhooksService, dispatcher *Dispatcher) []models.Route {
+	listEndpointsHandler := &ListEndpointsHandler{
+		service: service,
+		logger:  logger,
+	}
+	createEndpointHandler := &CreateEndpointHandler{
+		service: service,
+		logger:  logger,
+	}
+	getEndpointHandler := &GetEndpointHandler{
+		service: service,
+		logger:  logger,
+	}
+	updateEndpointHandler := &UpdateEndpointHandler{
+		service: service,
+		logger:  logger,
+	}
+	deleteEndpointHandler := &DeleteEndpointHandler{
+		service: service,
+		logger:  logger,
+	}
+	listDeliveriesHandler := &ListDeliveriesHandler{
+		service: service,
+		logger:  logger,
+	}
+	getDeliveryHandler := &GetDeliveryHandler{
+		service: service,
+		logger:  logger,
+	}
+	redeliverHandler := &RedeliverHandler{
+		service:    service,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+
+	return []models.Route{
+		{
+			Method:   http.MethodGet,
+			Path:     "/webhooks/endpoints",
+			Handler:  listEndpointsHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/webhooks/endpoints",
+			Handler:  createEndpointHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/webhooks/endpoints/{id}",
+			Handler:  getEndpointHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+		{
+			Method:   http.MethodPatch,
+			Path:     "/webhooks/endpoints/{id}",
+			Handler:  updateEndpointHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+		{
+			Method:   http.MethodDelete,
+			Path:     "/webhooks/endpoints/{id}",
+			Handler:  deleteEndpointHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/webhooks/endpoints/{id}/deliveries",
+			Handler:  listDeliveriesHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/webhooks/deliveries/{id}",
+			Handler:  getDeliveryHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/webhooks/deliveries/{id}/redeliver",
+			Handler:  redeliverHandler.Handler(),
+			Metadata: adminauth.RouteMetadata(HookIDWebhooksAdmin.String()),
+		},
+	}
+}
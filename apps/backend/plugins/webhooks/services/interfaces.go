@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Authula/authula/models"
+
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// WebhooksService defines the interface for webhook operations
+type WebhooksService interface {
+	CreateEndpoint(ctx context.Context, request types.CreateEndpointRequest) (*types.EndpointWithSecret, error)
+	GetEndpoint(ctx context.Context, id int64) (*types.Endpoint, error)
+	ListEndpoints(ctx context.Context) ([]types.Endpoint, error)
+	UpdateEndpoint(ctx context.Context, id int64, request types.UpdateEndpointRequest) (*types.EndpointWithSecret, error)
+	DeleteEndpoint(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, query types.DeliveryQuery) (*types.DeliveriesPage, error)
+	GetDelivery(ctx context.Context, id int64) (*types.DeliveryWithAttempts, error)
+	Redeliver(ctx context.Context, id int64) (*types.Delivery, error)
+	EnqueueEvent(ctx context.Context, event models.Event) ([]*types.Delivery, error)
+	DispatchDue(ctx context.Context) (int, error)
+	EraseUserData(ctx context.Context, userID string) (int64, error)
+}
+
+// PayloadRedactor rewrites the sensitive fields of an event before it is queued
+type PayloadRedactor interface {
+	Redact(payload []byte, metadata map[string]string) ([]byte, map[string]string)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// Sign returns the value of the signature header for a body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// errNonPublicAddress is the error of an attempt whose endpoint resolves to a non-public address
+var errNonPublicAddress = errors.New("endpoint resolves to a non-public address")
+
+// Sender posts signed deliveries to their endpoints
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender that only connects to public addresses unless allowPrivateNetworks is set
+func NewSender(timeout time.Duration, allowPrivateNetworks bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		// Checked once the name was resolved, so that a name of an internal service is refused too
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !IsPublicAddress(addrPort.Addr()) {
+				return errNonPublicAddress
+			}
+			return nil
+		}
+	}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// A proxy would make the dialer check the address of the proxy instead of the endpoint
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// A redirect would send the signed payload to a URL the admin never configured
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not public either
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddress reports whether an address can be reached from the internet
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// Send makes a single attempt at the delivery and reports its outcome
+func (s *Sender) Send(ctx context.Context, endpoint *types.Endpoint, delivery *types.Delivery) *types.DeliveryAttempt {
+	startedAt := time.Now()
+	attempt := &types.DeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+		CreatedAt:  startedAt.UTC(),
+	}
+	fail := func(err error) *types.DeliveryAttempt {
+		message := err.Error()
+		attempt.Error = &message
+		attempt.DurationMS = time.Since(startedAt).Milliseconds()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fail(err)
+	}
+	timestamp := startedAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Authula-Webhooks/1.0")
+	req.Header.Set(constants.HeaderSignature, Sign(endpoint.Secret, timestamp, delivery.Payload))
+	req.Header.Set(constants.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(constants.HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(constants.HeaderEventType, delivery.EventType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, types.MaxResponseBodyLength))
+	// Drain a little more so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	attempt.StatusCode = &resp.StatusCode
+	if len(body) > 0 {
+		// The body is stored in a text column, which rejects invalid UTF-8 and NUL bytes
+		responseBody := strings.ReplaceAll(strings.ToValidUTF8(string(body), "\uFFFD"), "\x00", "")
+		attempt.ResponseBody = &responseBody
+	}
+	if !attempt.Succeeded() {
+		message := "unexpected status " + resp.Status
+		attempt.Error = &message
+	}
+	attempt.DurationMS = time.Since(startedAt).Milliseconds()
+	return attempt
+}
@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/services"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{address: "93.184.216.34", want: true},
+		{address: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{address: "127.0.0.1"},
+		{address: "::1"},
+		{address: "10.1.2.3"},
+		{address: "172.16.0.1"},
+		{address: "192.168.1.1"},
+		{address: "169.254.169.254"},
+		{address: "fe80::1"},
+		{address: "fd00::1"},
+		{address: "100.64.0.1"},
+		{address: "0.0.0.0"},
+		{address: "224.0.0.1"},
+		{address: "::ffff:127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			assert.Equal(t, tt.want, services.IsPublicAddress(netip.MustParseAddr(tt.address)))
+		})
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	const secret = "whsec_test_secret_of_the_endpoint"
+	payload := []byte(`{"id":"e1","type":"user.created"}`)
+
+	var received *http.Request
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(receiver.Close)
+
+	redirect := httptest.NewServer(http.RedirectHandler(receiver.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(redirect.Close)
+
+	tests := []struct {
+		name                 string
+		url                  string
+		allowPrivateNetworks bool
+		wantStatus           int
+		wantErr              string
+	}{
+		{name: "private address refused", url: receiver.URL, wantErr: "non-public address"},
+		{name: "private address allowed", url: receiver.URL, allowPrivateNetworks: true, wantStatus: http.StatusNoContent},
+		{name: "redirect not followed", url: redirect.URL, allowPrivateNetworks: true, wantStatus: http.StatusTemporaryRedirect, wantErr: "unexpected status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			sender := services.NewSender(time.Second, tt.allowPrivateNetworks)
+			endpoint := &types.Endpoint{ID: 1, URL: tt.url, Secret: secret}
+			delivery := &types.Delivery{ID: 7, EndpointID: 1, EventType: "user.created", Payload: payload}
+
+			attempt := sender.Send(context.Background(), endpoint, delivery)
+
+			assert.Equal(t, 1, attempt.Attempt)
+			if tt.wantErr != "" {
+				require.NotNil(t, attempt.Error)
+				assert.Contains(t, *attempt.Error, tt.wantErr)
+			}
+			if tt.wantStatus == 0 {
+				assert.Nil(t, attempt.StatusCode)
+				assert.Nil(t, received)
+				return
+			}
+			require.NotNil(t, attempt.StatusCode)
+			assert.Equal(t, tt.wantStatus, *attempt.StatusCode)
+			if tt.wantStatus != http.StatusNoContent {
+				assert.Nil(t, received, "the redirect target is not called")
+				return
+			}
+
+			require.NotNil(t, received)
+			assert.Nil(t, attempt.Error)
+			assert.Equal(t, payload, receivedBody)
+			assert.Equal(t, "7", received.Header.Get(constants.HeaderDeliveryID))
+			assert.Equal(t, "user.created", received.Header.Get(constants.HeaderEventType))
+			timestamp, err := strconv.ParseInt(received.Header.Get(constants.HeaderTimestamp), 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, services.Sign(secret, timestamp, receivedBody), received.Header.Get(constants.HeaderSignature))
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"e1"}`)
+	signature := services.Sign("secret", 1700000000, body)
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.Len(t, signature, 7+64)
+	assert.Equal(t, signature, services.Sign("secret", 1700000000, body))
+	assert.NotEqual(t, signature, services.Sign("other secret", 1700000000, body))
+	assert.NotEqual(t, signature, services.Sign("secret", 1700000001, body))
+	assert.NotEqual(t, signature, services.Sign("secret", 1700000000, []byte(`{"id":"e2"}`)))
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Authula/authula/models"
+
+	loggertypes "github.com/Authula/authula-playground/plugins/logger/types"
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/repositories"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// secretPrefix makes generated secrets recognizable, e.g. by secret scanners
+const secretPrefix = "whsec_"
+
+// service implements the WebhooksService interface
+type service struct {
+	repo     repositories.WebhooksRepository
+	sender   *Sender
+	redactor PayloadRedactor
+	logger   models.Logger
+	config   types.WebhooksPluginConfig
+}
+
+// NewService creates a new webhooks service
+func NewService(repo repositories.WebhooksRepository, sender *Sender, redactor PayloadRedactor, logger models.Logger, config types.WebhooksPluginConfig) WebhooksService {
+	return &service{
+		repo:     repo,
+		sender:   sender,
+		redactor: redactor,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// CreateEndpoint validates and stores a new endpoint, generating its secret when none is given
+func (s *service) CreateEndpoint(ctx context.Context, request types.CreateEndpointRequest) (*types.EndpointWithSecret, error) {
+	endpointURL, err := s.validateURL(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	eventTypes, err := validateEventTypes(request.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := resolveSecret(request.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	endpoint := &types.Endpoint{
+		URL:         endpointURL,
+		Description: normalizeDescription(request.Description),
+		EventTypes:  eventTypes,
+		Secret:      secret,
+		Enabled:     request.Enabled == nil || *request.Enabled,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	return &types.EndpointWithSecret{Endpoint: endpoint, Secret: secret}, nil
+}
+
+// GetEndpoint retrieves an endpoint
+func (s *service) GetEndpoint(ctx context.Context, id int64) (*types.Endpoint, error) {
+	return s.repo.GetEndpoint(ctx, id)
+}
+
+// ListEndpoints retrieves every endpoint
+func (s *service) ListEndpoints(ctx context.Context) ([]types.Endpoint, error) {
+	return s.repo.ListEndpoints(ctx)
+}
+
+// UpdateEndpoint changes the fields given in the request
+func (s *service) UpdateEndpoint(ctx context.Context, id int64, request types.UpdateEndpointRequest) (*types.EndpointWithSecret, error) {
+	endpoint, err := s.repo.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.URL != nil {
+		if endpoint.URL, err = s.validateURL(*request.URL); err != nil {
+			return nil, err
+		}
+	}
+	if request.Description != nil {
+		endpoint.Description = normalizeDescription(request.Description)
+	}
+	if request.EventTypes != nil {
+		if endpoint.EventTypes, err = validateEventTypes(request.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+	if request.Enabled != nil {
+		endpoint.Enabled = *request.Enabled
+	}
+
+	var newSecret string
+	switch {
+	case request.RotateSecret && request.Secret != nil:
+		return nil, fmt.Errorf("%w: secret and rotate_secret must not be given together", constants.ErrInvalidEndpoint)
+	case request.RotateSecret:
+		if newSecret, err = resolveSecret(nil); err != nil {
+			return nil, err
+		}
+	case request.Secret != nil:
+		if newSecret, err = resolveSecret(request.Secret); err != nil {
+			return nil, err
+		}
+	}
+	if newSecret != "" {
+		endpoint.Secret = newSecret
+	}
+
+	endpoint.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	return &types.EndpointWithSecret{Endpoint: endpoint, Secret: newSecret}, nil
+}
+
+// DeleteEndpoint deletes an endpoint and its delivery history
+func (s *service) DeleteEndpoint(ctx context.Context, id int64) error {
+	return s.repo.DeleteEndpoint(ctx, id)
+}
+
+// ListDeliveries retrieves a page of the deliveries of an endpoint, newest first
+func (s *service) ListDeliveries(ctx context.Context, query types.DeliveryQuery) (*types.DeliveriesPage, error) {
+	if _, err := s.repo.GetEndpoint(ctx, query.EndpointID); err != nil {
+		return nil, err
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = types.DefaultDeliveriesLimit
+	}
+	if query.Limit > types.MaxDeliveriesLimit {
+		query.Limit = types.MaxDeliveriesLimit
+	}
+
+	deliveries, nextCursor, err := s.repo.ListDeliveries(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.DeliveriesPage{
+		Deliveries: deliveries,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// GetDelivery retrieves a delivery together with its attempts
+func (s *service) GetDelivery(ctx context.Context, id int64) (*types.DeliveryWithAttempts, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.repo.ListDeliveryAttempts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &types.DeliveryWithAttempts{Delivery: delivery, AttemptHistory: attempts}, nil
+}
+
+// Redeliver queues a new delivery of the same payload to the same endpoint
+func (s *service) Redeliver(ctx context.Context, id int64) (*types.Delivery, error) {
+	original, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := s.repo.GetEndpoint(ctx, original.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Enabled {
+		return nil, constants.ErrEndpointDisabled
+	}
+
+	now := time.Now().UTC()
+	delivery := &types.Delivery{
+		EndpointID:    original.EndpointID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		UserID:        original.UserID,
+		Payload:       original.Payload,
+		Status:        types.DeliveryStatusPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+		CreatedAt:     now,
+	}
+	if err := s.repo.CreateDeliveries(ctx, []*types.Delivery{delivery}); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// EnqueueEvent queues a delivery of the redacted event for every enabled endpoint subscribed to its type
+func (s *service) EnqueueEvent(ctx context.Context, event models.Event) ([]*types.Delivery, error) {
+	endpoints, err := s.repo.ListEnabledEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	var deliveries []*types.Delivery
+	now := time.Now().UTC()
+	for i := range endpoints {
+		if !endpoints[i].Matches(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = s.encodePayload(event); err != nil {
+				return nil, err
+			}
+		}
+		deliveries = append(deliveries, &types.Delivery{
+			EndpointID:    endpoints[i].ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			UserID:        eventUserID(event),
+			Payload:       payload,
+			Status:        types.DeliveryStatusPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		})
+	}
+
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// encodePayload builds the body sent to the endpoints, with the sensitive fields redacted
+func (s *service) encodePayload(event models.Event) ([]byte, error) {
+	eventPayload := types.EventPayload(event)
+	if s.redactor != nil {
+		eventPayload.Payload, eventPayload.Metadata = s.redactor.Redact(event.Payload, event.Metadata)
+	}
+	payload, err := json.Marshal(eventPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return payload, nil
+}
+
+// eventUserID extracts the user of the event like the logger does for its entries
+func eventUserID(event models.Event) *string {
+	var entry loggertypes.LogEntry
+	entry.ApplyEventFields(event.Payload, event.Metadata)
+	return entry.UserID
+}
+
+// EraseUserData deletes the deliveries of the user, pending ones included
+func (s *service) EraseUserData(ctx context.Context, userID string) (int64, error) {
+	return s.repo.DeleteUserDeliveries(ctx, userID)
+}
+
+// DispatchDue sends the deliveries that are due and returns how many were attempted
+func (s *service) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	// The lease outlives the whole round so that no other replica picks a delivery up meanwhile, it
+	// is truncated to the precision of the databases so that RecordAttempt can compare it
+	leaseUntil := now.Add(s.config.DispatchLease()).UTC().Truncate(time.Microsecond)
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, now, leaseUntil, s.config.DispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	endpoints := make(map[int64]*types.Endpoint)
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, s.config.DispatchConcurrency)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			if endpoint, err = s.repo.GetEndpoint(ctx, delivery.EndpointID); err != nil {
+				// The lease runs out and the delivery is claimed again in a later round
+				s.logger.Error("failed to load webhook endpoint", "endpoint_id", delivery.EndpointID, "error", err)
+				continue
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.attempt(ctx, endpoint, delivery, leaseUntil)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// attempt sends the delivery once and records the outcome unless its lease ran out
+func (s *service) attempt(ctx context.Context, endpoint *types.Endpoint, delivery *types.Delivery, leaseUntil time.Time) {
+	attempt := s.sender.Send(ctx, endpoint, delivery)
+
+	delivery.Attempts = attempt.Attempt
+	delivery.LastAttemptAt = &attempt.CreatedAt
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+
+	switch {
+	case attempt.Succeeded():
+		completedAt := time.Now().UTC()
+		delivery.Status = types.DeliveryStatusSucceeded
+		delivery.NextAttemptAt = nil
+		delivery.CompletedAt = &completedAt
+	case delivery.Attempts >= s.config.MaxAttempts:
+		completedAt := time.Now().UTC()
+		delivery.Status = types.DeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		delivery.CompletedAt = &completedAt
+		s.logger.Warn("webhook delivery failed", "delivery_id", delivery.ID, "endpoint_id", endpoint.ID, "attempts", delivery.Attempts)
+	default:
+		nextAttemptAt := time.Now().UTC().Add(s.config.Backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+
+	// The outcome is recorded even when the dispatch was cancelled in the meantime
+	err := s.repo.RecordAttempt(context.WithoutCancel(ctx), delivery, attempt, leaseUntil)
+	switch {
+	case errors.Is(err, constants.ErrLeaseExpired):
+		s.logger.Warn("webhook delivery lease expired before its attempt was recorded", "delivery_id", delivery.ID, "endpoint_id", endpoint.ID)
+	case err != nil:
+		s.logger.Error("failed to record webhook delivery attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// validateURL accepts absolute https URLs of public hosts, and http ones when AllowInsecureURLs is set
+func (s *service) validateURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", fmt.Errorf("%w: url is required", constants.ErrInvalidEndpoint)
+	}
+	if len(rawURL) > types.MaxURLLength {
+		return "", fmt.Errorf("%w: url must not be longer than %d characters", constants.ErrInvalidEndpoint, types.MaxURLLength)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("%w: url must be absolute", constants.ErrInvalidEndpoint)
+	}
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		if !s.config.AllowInsecureURLs {
+			return "", fmt.Errorf("%w: url must use https", constants.ErrInvalidEndpoint)
+		}
+	default:
+		return "", fmt.Errorf("%w: url must use https", constants.ErrInvalidEndpoint)
+	}
+	// Names are checked again by the sender once resolved, literal addresses are refused right away
+	if !s.config.AllowPrivateNetworks {
+		host := parsed.Hostname()
+		if addr, err := netip.ParseAddr(host); (err == nil && !IsPublicAddress(addr)) || strings.EqualFold(host, "localhost") {
+			return "", fmt.Errorf("%w: url must point to a public address", constants.ErrInvalidEndpoint)
+		}
+	}
+	return rawURL, nil
+}
+
+// validateEventTypes requires at least one event type and rejects malformed glob patterns
+func validateEventTypes(eventTypes []string) ([]string, error) {
+	validated := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			return nil, fmt.Errorf("%w: event types must not be empty", constants.ErrInvalidEndpoint)
+		}
+		if _, err := path.Match(eventType, ""); err != nil {
+			return nil, fmt.Errorf("%w: invalid event type pattern %q", constants.ErrInvalidEndpoint, eventType)
+		}
+		validated = append(validated, eventType)
+	}
+	if len(validated) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required, \"*\" subscribes to every event", constants.ErrInvalidEndpoint)
+	}
+	return validated, nil
+}
+
+// resolveSecret checks a secret chosen by the admin or generates one when none is given
+func resolveSecret(secret *string) (string, error) {
+	if secret != nil && *secret != "" {
+		if len(*secret) < types.MinSecretLength {
+			return "", fmt.Errorf("%w: secret must be at least %d characters long", constants.ErrInvalidEndpoint, types.MinSecretLength)
+		}
+		return *secret, nil
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+func normalizeDescription(description *string) *string {
+	if description == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*description)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
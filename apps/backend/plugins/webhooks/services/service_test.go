@@ -0,0 +1,289 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+
+	"github.com/Authula/authula/migrations"
+	"github.com/Authula/authula/models"
+
+	loggerservices "github.com/Authula/authula-playground/plugins/logger/services"
+	loggertypes "github.com/Authula/authula-playground/plugins/logger/types"
+	"github.com/Authula/authula-playground/plugins/webhooks"
+	"github.com/Authula/authula-playground/plugins/webhooks/constants"
+	"github.com/Authula/authula-playground/plugins/webhooks/repositories"
+	"github.com/Authula/authula-playground/plugins/webhooks/services"
+	"github.com/Authula/authula-playground/plugins/webhooks/types"
+)
+
+// newTestService creates a service on an in-memory SQLite database with the migrations of the plugin applied
+func newTestService(t *testing.T, config types.WebhooksPluginConfig) (services.WebhooksService, repositories.WebhooksRepository) {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Every connection would open its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	db := bun.NewDB(sqlDB, sqlitedialect.New())
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := migrations.NewMigrator(db, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(context.Background(), []migrations.MigrationSet{{
+		PluginID:   "webhooks",
+		Migrations: webhooks.New(config).Migrations("sqlite"),
+	}}))
+
+	require.NoError(t, config.Validate())
+	repo := repositories.NewBunWebhooksRepository(db)
+	redactor, err := loggerservices.NewRedactor(loggertypes.DefaultRedactionRules, "")
+	require.NoError(t, err)
+	service := services.NewService(repo, services.NewSender(config.RequestTimeout, true), redactor, slog.New(slog.DiscardHandler), config)
+	return service, repo
+}
+
+// createEndpoint stores an endpoint subscribed to the event types
+func createEndpoint(t *testing.T, service services.WebhooksService, url string, eventTypes ...string) *types.Endpoint {
+	t.Helper()
+
+	endpoint, err := service.CreateEndpoint(context.Background(), types.CreateEndpointRequest{URL: url, EventTypes: eventTypes})
+	require.NoError(t, err)
+	return endpoint.Endpoint
+}
+
+func TestService_EnqueueEvent(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService(t, types.WebhooksPluginConfig{})
+	users := createEndpoint(t, service, "https://example.com/users", "user.*")
+	createEndpoint(t, service, "https://example.com/sessions", "session.*")
+
+	deliveries, err := service.EnqueueEvent(ctx, models.Event{
+		ID:       "e1",
+		Type:     "user.created",
+		Payload:  json.RawMessage(`{"id":"u1","email":"user@example.com","password":"hunter2"}`),
+		Metadata: map[string]string{"access_token": "secret"},
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1, "only the subscribed endpoint gets a delivery")
+
+	stored, err := repo.GetDelivery(ctx, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, users.ID, stored.EndpointID)
+	assert.Equal(t, types.DeliveryStatusPending, stored.Status)
+	require.NotNil(t, stored.UserID)
+	assert.Equal(t, "u1", *stored.UserID)
+
+	var payload types.EventPayload
+	require.NoError(t, json.Unmarshal(stored.Payload, &payload))
+	assert.Equal(t, "e1", payload.ID)
+	assert.JSONEq(t, `{"id":"u1","email":"user@example.com"}`, string(payload.Payload), "the password is redacted")
+	assert.NotContains(t, payload.Metadata, "access_token")
+}
+
+func TestService_EraseUserData(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService(t, types.WebhooksPluginConfig{})
+	createEndpoint(t, service, "https://example.com/users", "*")
+
+	var deliveries []*types.Delivery
+	for _, event := range []models.Event{
+		{ID: "e1", Type: "user.created", Payload: json.RawMessage(`{"id":"u1","email":"u1@example.com"}`)},
+		{ID: "e2", Type: "user.signed_in", Payload: json.RawMessage(`{"user_id":"u1"}`)},
+		{ID: "e3", Type: "user.signed_in", Payload: json.RawMessage(`{"user_id":"u2"}`)},
+	} {
+		enqueued, err := service.EnqueueEvent(ctx, event)
+		require.NoError(t, err)
+		deliveries = append(deliveries, enqueued...)
+	}
+	statusCode := 500
+	require.NoError(t, repo.RecordAttempt(ctx, deliveries[0], &types.DeliveryAttempt{
+		DeliveryID: deliveries[0].ID,
+		Attempt:    1,
+		StatusCode: &statusCode,
+		CreatedAt:  time.Now().UTC(),
+	}, *deliveries[0].NextAttemptAt))
+
+	erased, err := service.EraseUserData(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), erased)
+
+	for _, delivery := range deliveries[:2] {
+		_, err := repo.GetDelivery(ctx, delivery.ID)
+		assert.Error(t, err, "the deliveries of the user are deleted")
+	}
+	attempts, err := repo.ListDeliveryAttempts(ctx, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+	_, err = repo.GetDelivery(ctx, deliveries[2].ID)
+	assert.NoError(t, err, "the deliveries of other users are kept")
+}
+
+func TestService_DispatchDue(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		maxAttempts   int
+		wantStatus    types.DeliveryStatus
+		wantRetryWait time.Duration
+	}{
+		{name: "accepted", statusCode: http.StatusOK, maxAttempts: 3, wantStatus: types.DeliveryStatusSucceeded},
+		{name: "rejected and retried", statusCode: http.StatusInternalServerError, maxAttempts: 3, wantStatus: types.DeliveryStatusPending, wantRetryWait: time.Minute},
+		{name: "rejected without attempts left", statusCode: http.StatusInternalServerError, maxAttempts: 1, wantStatus: types.DeliveryStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+			}))
+			t.Cleanup(receiver.Close)
+
+			service, repo := newTestService(t, types.WebhooksPluginConfig{
+				AllowInsecureURLs:    true,
+				AllowPrivateNetworks: true,
+				MaxAttempts:          tt.maxAttempts,
+				InitialBackoff:       time.Minute,
+			})
+			createEndpoint(t, service, receiver.URL, "*")
+			deliveries, err := service.EnqueueEvent(ctx, models.Event{ID: "e1", Type: "user.created", Payload: json.RawMessage(`{}`)})
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+
+			before := time.Now().UTC()
+			dispatched, err := service.DispatchDue(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, dispatched)
+
+			delivery, err := service.GetDelivery(ctx, deliveries[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, delivery.Status)
+			assert.Equal(t, 1, delivery.Attempts)
+			require.Len(t, delivery.AttemptHistory, 1)
+			require.NotNil(t, delivery.AttemptHistory[0].StatusCode)
+			assert.Equal(t, tt.statusCode, *delivery.AttemptHistory[0].StatusCode)
+			if tt.wantStatus != types.DeliveryStatusPending {
+				assert.Nil(t, delivery.NextAttemptAt)
+				assert.NotNil(t, delivery.CompletedAt)
+			} else {
+				require.NotNil(t, delivery.NextAttemptAt)
+				assert.False(t, delivery.NextAttemptAt.Before(before.Add(tt.wantRetryWait)), "the retry waits for the backoff")
+			}
+
+			dispatched, err = service.DispatchDue(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, dispatched, "nothing is due until the backoff ran out")
+
+			claimed, err := repo.ClaimDueDeliveries(ctx, before.Add(tt.wantRetryWait+time.Second), before.Add(time.Hour), 10)
+			require.NoError(t, err)
+			if tt.wantStatus == types.DeliveryStatusPending {
+				assert.Len(t, claimed, 1, "the delivery is retried once the backoff ran out")
+			} else {
+				assert.Empty(t, claimed, "completed deliveries are not retried")
+			}
+		})
+	}
+}
+
+func TestService_DispatchDue_SharedRepository(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	received := map[string]int{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.Header.Get(constants.HeaderDeliveryID)]++
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.Close)
+
+	// Sending the batch takes 3 rounds of 100ms, longer than two request timeouts
+	config := types.WebhooksPluginConfig{
+		AllowInsecureURLs:    true,
+		AllowPrivateNetworks: true,
+		RequestTimeout:       120 * time.Millisecond,
+		DispatchBatchSize:    6,
+		DispatchConcurrency:  2,
+	}
+	first, repo := newTestService(t, config)
+	require.NoError(t, config.Validate())
+	redactor, err := loggerservices.NewRedactor(loggertypes.DefaultRedactionRules, "")
+	require.NoError(t, err)
+	second := services.NewService(repo, services.NewSender(config.RequestTimeout, true), redactor, slog.New(slog.DiscardHandler), config)
+
+	createEndpoint(t, first, receiver.URL, "*")
+	var deliveries []*types.Delivery
+	for _, id := range []string{"e1", "e2", "e3", "e4", "e5", "e6"} {
+		enqueued, err := first.EnqueueEvent(ctx, models.Event{ID: id, Type: "user.created", Payload: json.RawMessage(`{}`)})
+		require.NoError(t, err)
+		deliveries = append(deliveries, enqueued...)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := first.DispatchDue(ctx)
+		assert.NoError(t, err)
+	}()
+	// The second replica keeps looking for due deliveries while the first one sends its batch
+	for dispatching := true; dispatching; {
+		select {
+		case <-done:
+			dispatching = false
+		case <-time.After(20 * time.Millisecond):
+			_, err := second.DispatchDue(ctx)
+			require.NoError(t, err)
+		}
+	}
+
+	for _, delivery := range deliveries {
+		assert.Equal(t, 1, received[strconv.FormatInt(delivery.ID, 10)], "delivery %d is sent once", delivery.ID)
+		stored, err := first.GetDelivery(ctx, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, types.DeliveryStatusSucceeded, stored.Status)
+		assert.Len(t, stored.AttemptHistory, 1)
+	}
+}
+
+func TestService_Redeliver(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService(t, types.WebhooksPluginConfig{})
+	endpoint := createEndpoint(t, service, "https://example.com/users", "*")
+	deliveries, err := service.EnqueueEvent(ctx, models.Event{ID: "e1", Type: "user.created", Payload: json.RawMessage(`{"id":"u1","email":"u1@example.com"}`)})
+	require.NoError(t, err)
+	original := deliveries[0]
+
+	redelivery, err := service.Redeliver(ctx, original.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, original.ID, redelivery.ID)
+	require.NotNil(t, redelivery.RedeliveryOf)
+	assert.Equal(t, original.ID, *redelivery.RedeliveryOf)
+	assert.Equal(t, types.DeliveryStatusPending, redelivery.Status)
+	assert.Zero(t, redelivery.Attempts, "the redelivery gets every attempt")
+	assert.JSONEq(t, string(original.Payload), string(redelivery.Payload))
+	assert.Equal(t, original.UserID, redelivery.UserID)
+
+	_, err = service.Redeliver(ctx, 999)
+	assert.ErrorIs(t, err, constants.ErrDeliveryNotFound)
+
+	enabled := false
+	_, err = service.UpdateEndpoint(ctx, endpoint.ID, types.UpdateEndpointRequest{Enabled: &enabled})
+	require.NoError(t, err)
+	_, err = service.Redeliver(ctx, original.ID)
+	assert.ErrorIs(t, err, constants.ErrEndpointDisabled)
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusPending deliveries are waiting for their next attempt
+	DeliveryStatusPending DeliveryStatus = "pending"
+	// DeliveryStatusSucceeded deliveries were answered with a 2xx status code
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	// DeliveryStatusFailed deliveries used up their attempts, they can be redelivered manually
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// Delivery is an event queued for an endpoint, its payload is kept for the retries
+type Delivery struct {
+	bun.BaseModel `bun:"table:webhook_deliveries"`
+
+	ID         int64           `json:"id" bun:"column:id,pk,autoincrement"`
+	EndpointID int64           `json:"endpoint_id" bun:"column:endpoint_id"`
+	EventID    string          `json:"event_id" bun:"column:event_id"`
+	EventType  string          `json:"event_type" bun:"column:event_type"`
+	Payload    json.RawMessage `json:"payload" bun:"column:payload"`
+	Status     DeliveryStatus  `json:"status" bun:"column:status"`
+	Attempts   int             `json:"attempts" bun:"column:attempts"`
+	// NextAttemptAt is when a pending delivery is sent next, it is moved forward while an attempt is in flight
+	NextAttemptAt  *time.Time `json:"next_attempt_at" bun:"column:next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at" bun:"column:last_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code" bun:"column:last_status_code"`
+	LastError      *string    `json:"last_error" bun:"column:last_error"`
+	// UserID is the user the event is about, the deliveries of an erased user are deleted
+	UserID *string `json:"user_id" bun:"column:user_id"`
+	// RedeliveryOf is the delivery this one was manually redelivered from
+	RedeliveryOf *int64     `json:"redelivery_of" bun:"column:redelivery_of"`
+	CreatedAt    time.Time  `json:"created_at" bun:"column:created_at"`
+	CompletedAt  *time.Time `json:"completed_at" bun:"column:completed_at"`
+}
+
+// DeliveryAttempt is the outcome of sending a delivery once
+type DeliveryAttempt struct {
+	bun.BaseModel `bun:"table:webhook_delivery_attempts"`
+
+	ID         int64 `json:"id" bun:"column:id,pk,autoincrement"`
+	DeliveryID int64 `json:"delivery_id" bun:"column:delivery_id"`
+	Attempt    int   `json:"attempt" bun:"column:attempt"`
+	// StatusCode is missing when the endpoint could not be reached
+	StatusCode *int    `json:"status_code" bun:"column:status_code"`
+	Error      *string `json:"error" bun:"column:error"`
+	// ResponseBody is the start of the response, cut after MaxResponseBodyLength bytes
+	ResponseBody *string   `json:"response_body" bun:"column:response_body"`
+	DurationMS   int64     `json:"duration_ms" bun:"column:duration_ms"`
+	CreatedAt    time.Time `json:"created_at" bun:"column:created_at"`
+}
+
+// Succeeded reports whether the endpoint accepted the delivery
+func (a *DeliveryAttempt) Succeeded() bool {
+	return a.StatusCode != nil && *a.StatusCode >= 200 && *a.StatusCode < 300
+}
+
+// MaxResponseBodyLength is how much of the response of an endpoint is kept with the attempt
+const MaxResponseBodyLength = 1024
+
+// DeliveryWithAttempts is a delivery together with every attempt made so far, oldest first
+type DeliveryWithAttempts struct {
+	*Delivery
+	AttemptHistory []DeliveryAttempt `json:"attempt_history"`
+}
+
+// EventPayload is the JSON body sent to the endpoints
+type EventPayload struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Timestamp time.Time         `json:"timestamp"`
+	Payload   json.RawMessage   `json:"payload"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+const (
+	DefaultDeliveriesLimit = 50
+	MaxDeliveriesLimit     = 200
+)
+
+// DeliveryQuery describes a single page of the deliveries of an endpoint, newest first
+type DeliveryQuery struct {
+	EndpointID int64
+	// Status restricts the deliveries to a single status, empty returns every status
+	Status DeliveryStatus
+	// Cursor is the opaque NextCursor value of the previous page
+	Cursor *string
+	Limit  int
+}
+
+type DeliveriesPage struct {
+	Deliveries []Delivery `json:"deliveries"`
+	NextCursor *string    `json:"next_cursor,omitempty"`
+}
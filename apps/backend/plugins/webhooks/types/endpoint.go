@@ -0,0 +1,68 @@
+package types
+
+import (
+	"path"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	// MinSecretLength is the minimum length of a secret chosen by the admin
+	MinSecretLength = 16
+	// MaxURLLength is the maximum length of an endpoint URL
+	MaxURLLength = 2048
+)
+
+// Endpoint is a URL subscribed to the events matching its event types
+type Endpoint struct {
+	bun.BaseModel `bun:"table:webhook_endpoints"`
+
+	ID          int64   `json:"id" bun:"column:id,pk,autoincrement"`
+	URL         string  `json:"url" bun:"column:url"`
+	Description *string `json:"description" bun:"column:description"`
+	// EventTypes are exact event types or glob patterns such as "user.*", "*" matches every event
+	EventTypes []string `json:"event_types" bun:"column:event_types,type:json"`
+	// Secret keys the signature of the deliveries, it is only returned when it is set
+	Secret    string    `json:"-" bun:"column:secret"`
+	Enabled   bool      `json:"enabled" bun:"column:enabled"`
+	CreatedAt time.Time `json:"created_at" bun:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" bun:"column:updated_at"`
+}
+
+// Matches reports whether the endpoint is subscribed to the event type
+func (e *Endpoint) Matches(eventType string) bool {
+	for _, pattern := range e.EventTypes {
+		if matched, _ := path.Match(pattern, eventType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// EndpointWithSecret reveals the secret of an endpoint right after it was set, Secret is empty otherwise
+type EndpointWithSecret struct {
+	*Endpoint
+	Secret string `json:"secret,omitempty"`
+}
+
+// CreateEndpointRequest is the body of the create endpoint route
+type CreateEndpointRequest struct {
+	URL         string   `json:"url"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	// Secret is generated when it is left empty
+	Secret  *string `json:"secret"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// UpdateEndpointRequest is the body of the update endpoint route, fields left out are kept
+type UpdateEndpointRequest struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Secret      *string  `json:"secret"`
+	// RotateSecret replaces the secret with a generated one
+	RotateSecret bool  `json:"rotate_secret"`
+	Enabled      *bool `json:"enabled"`
+}
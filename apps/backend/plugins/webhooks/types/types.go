@@ -0,0 +1,105 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Authula/authula-playground/adminauth"
+)
+
+type WebhooksPluginConfig struct {
+	Enabled bool `json:"enabled" toml:"enabled"`
+	// AdminUserIDs are the users allowed to manage the webhook endpoints
+	AdminUserIDs []string `json:"admin_user_ids" toml:"admin_user_ids"`
+	// AdminEmails are the email addresses of the users allowed to manage the webhook endpoints, compared case-insensitively
+	AdminEmails []string `json:"admin_emails" toml:"admin_emails"`
+	// AdminRoleClaim is the session value, set by a hook of the app, holding the roles of the user, defaults to "role"
+	AdminRoleClaim string `json:"admin_role_claim" toml:"admin_role_claim"`
+	// AdminRoles are the roles allowed to manage the webhook endpoints
+	AdminRoles []string `json:"admin_roles" toml:"admin_roles"`
+	// AllowInsecureURLs accepts plain http endpoint URLs, only https is accepted otherwise
+	AllowInsecureURLs bool `json:"allow_insecure_urls" toml:"allow_insecure_urls"`
+	// AllowPrivateNetworks lets endpoints resolve to loopback, private and link-local addresses, for local development only
+	AllowPrivateNetworks bool `json:"allow_private_networks" toml:"allow_private_networks"`
+	// MaxAttempts is how many times a delivery is attempted before it is marked as failed, defaults to 8
+	MaxAttempts int `json:"max_attempts" toml:"max_attempts"`
+	// InitialBackoff is the delay before the first retry, it doubles with every failed attempt. Defaults to 30 seconds.
+	InitialBackoff time.Duration `json:"initial_backoff" toml:"initial_backoff"`
+	// MaxBackoff caps the delay between two attempts, defaults to 1 hour
+	MaxBackoff time.Duration `json:"max_backoff" toml:"max_backoff"`
+	// RequestTimeout is how long an endpoint has to answer a delivery, defaults to 10 seconds
+	RequestTimeout time.Duration `json:"request_timeout" toml:"request_timeout"`
+	// DispatchInterval is how often the dispatcher looks for deliveries that are due, defaults to 5 seconds
+	DispatchInterval time.Duration `json:"dispatch_interval" toml:"dispatch_interval"`
+	// DispatchBatchSize is the maximum number of deliveries claimed by a single dispatch round, defaults to 50
+	DispatchBatchSize int `json:"dispatch_batch_size" toml:"dispatch_batch_size"`
+	// DispatchConcurrency is how many deliveries are sent at the same time, defaults to 4
+	DispatchConcurrency int `json:"dispatch_concurrency" toml:"dispatch_concurrency"`
+}
+
+// Backoff returns how long to wait before retrying a delivery that failed attempts times
+func (c *WebhooksPluginConfig) Backoff(attempts int) time.Duration {
+	backoff := c.InitialBackoff
+	for i := 1; i < attempts && backoff < c.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, c.MaxBackoff)
+}
+
+// DispatchLease returns how long a dispatch round leases its deliveries, long enough to send the whole
+// batch DispatchConcurrency at a time with one request timeout to spare
+func (c *WebhooksPluginConfig) DispatchLease() time.Duration {
+	rounds := (c.DispatchBatchSize + c.DispatchConcurrency - 1) / c.DispatchConcurrency
+	return time.Duration(rounds+1) * c.RequestTimeout
+}
+
+// Admins returns the users allowed to use the admin routes
+func (c *WebhooksPluginConfig) Admins() adminauth.Config {
+	return adminauth.Config{
+		UserIDs:   c.AdminUserIDs,
+		Emails:    c.AdminEmails,
+		RoleClaim: c.AdminRoleClaim,
+		Roles:     c.AdminRoles,
+	}
+}
+
+// Validate validates the configuration
+func (c *WebhooksPluginConfig) Validate() error {
+	for _, admin := range append(append(append([]string{}, c.AdminUserIDs...), c.AdminEmails...), c.AdminRoles...) {
+		if strings.TrimSpace(admin) == "" {
+			return fmt.Errorf("admin user ids, emails and roles must not be empty")
+		}
+	}
+	if c.AdminRoleClaim == "" {
+		c.AdminRoleClaim = "role"
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative")
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = 8
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = 30 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("max backoff must not be shorter than the initial backoff")
+	}
+	if c.RequestTimeout <= 0 {
+		c.RequestTimeout = 10 * time.Second
+	}
+	if c.DispatchInterval <= 0 {
+		c.DispatchInterval = 5 * time.Second
+	}
+	if c.DispatchBatchSize <= 0 {
+		c.DispatchBatchSize = 50
+	}
+	if c.DispatchConcurrency <= 0 {
+		c.DispatchConcurrency = 4
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooksPluginConfig_Backoff(t *testing.T) {
+	config := WebhooksPluginConfig{InitialBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 5, want: 5 * time.Minute},
+		{attempts: 60, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, config.Backoff(tt.attempts), "attempts %d", tt.attempts)
+	}
+}
+
+func TestWebhooksPluginConfig_DispatchLease(t *testing.T) {
+	tests := []struct {
+		batchSize   int
+		concurrency int
+		want        time.Duration
+	}{
+		{batchSize: 50, concurrency: 4, want: 140 * time.Second},
+		{batchSize: 4, concurrency: 4, want: 20 * time.Second},
+		{batchSize: 1, concurrency: 4, want: 20 * time.Second},
+		{batchSize: 10, concurrency: 1, want: 110 * time.Second},
+	}
+
+	for _, tt := range tests {
+		config := WebhooksPluginConfig{RequestTimeout: 10 * time.Second, DispatchBatchSize: tt.batchSize, DispatchConcurrency: tt.concurrency}
+		assert.Equal(t, tt.want, config.DispatchLease(), "batch size %d, concurrency %d", tt.batchSize, tt.concurrency)
+	}
+}
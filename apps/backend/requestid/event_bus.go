@@ -0,0 +1,45 @@
+package requestid
+
+import (
+	"context"
+	"maps"
+
+	"github.com/Authula/authula/models"
+)
+
+// eventBus adds the request ID of the publishing context to the metadata of every event
+type eventBus struct {
+	models.EventBus
+}
+
+// WrapEventBus returns an event bus that adds the request ID of the context to the metadata of the events published through it
+func WrapEventBus(bus models.EventBus) models.EventBus {
+	if bus == nil {
+		return nil
+	}
+	if _, ok := bus.(*eventBus); ok {
+		return bus
+	}
+	return &eventBus{EventBus: bus}
+}
+
+func (b *eventBus) Publish(ctx context.Context, event models.Event) error {
+	if id := FromContext(ctx); id != "" && event.Metadata[MetadataKey] == "" {
+		// The caller may reuse the metadata map, it is copied rather than changed
+		metadata := make(map[string]string, len(event.Metadata)+1)
+		maps.Copy(metadata, event.Metadata)
+		metadata[MetadataKey] = id
+		event.Metadata = metadata
+	}
+	return b.EventBus.Publish(ctx, event)
+}
+
+// HandleEvents wraps an event handler so that it runs with the request ID of the event in its context
+func HandleEvents(handler models.EventHandler) models.EventHandler {
+	return func(ctx context.Context, event models.Event) error {
+		if id := event.Metadata[MetadataKey]; id != "" {
+			ctx = NewContext(ctx, id)
+		}
+		return handler(ctx, event)
+	}
+}
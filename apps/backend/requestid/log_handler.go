@@ -0,0 +1,32 @@
+package requestid
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logHandler adds the request ID of the context to every record
+type logHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps a slog handler so that records logged with a request context carry its ID
+func NewLogHandler(handler slog.Handler) slog.Handler {
+	return &logHandler{Handler: handler}
+}
+
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String(MetadataKey, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,84 @@
+// Package requestid correlates an HTTP request with the slog records written while it is handled and
+// with the events published through a wrapped event bus, which only the logger plugin uses.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/Authula/authula/models"
+)
+
+const (
+	// HeaderName is the header a request ID is read from and echoed in
+	HeaderName = "X-Request-ID"
+	// MetadataKey holds the request ID in RequestContext.Values, event metadata and slog records
+	MetadataKey = "request_id"
+	// MaxLength is the maximum length of a propagated request ID, longer ones are replaced
+	MaxLength = 128
+)
+
+type contextKey struct{}
+
+// Hook assigns every request an ID, propagating a valid X-Request-ID header
+func Hook() models.Hook {
+	return models.Hook{
+		Stage:   models.HookOnRequest,
+		Handler: assign,
+	}
+}
+
+func assign(reqCtx *models.RequestContext) error {
+	id := reqCtx.Headers.Get(HeaderName)
+	if !IsValid(id) {
+		id = New()
+	}
+	reqCtx.Values[MetadataKey] = id
+	reqCtx.ResponseWriter.Header().Set(HeaderName, id)
+	return nil
+}
+
+// New generates a random request ID
+func New() string {
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
+
+// IsValid reports whether a request ID received from a client is safe to echo and to log
+func IsValid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a context carrying the request ID, for work that outlives the request
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of the context, empty outside of a request
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(contextKey{}).(string); ok {
+		return id
+	}
+	if reqCtx, ok := models.GetRequestContext(ctx); ok {
+		if id, ok := reqCtx.Values[MetadataKey].(string); ok {
+			return id
+		}
+	}
+	return ""
+}